@@ -148,6 +148,7 @@ func MakeSystemNode(keydir string, privkey string, test *tests.BlockTest) (*node
 		TestGenesisBlock: test.Genesis,
 		ChainConfig:      core.DefaultConfigMainnet.ChainConfig,
 		AccountManager:   accman,
+		TxPool:           core.DefaultTxPoolConfig,
 	}
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) { return eth.New(ctx, ethConf) }); err != nil {
 		return nil, err
@@ -20,6 +20,10 @@ var buildAddrTxIndexCommand = cli.Command{
 	run the command on multiple occasions and pick up indexing progress where the last session
 	left off.
 	To enable address-transaction indexing during block sync and import, use the '--atxi' flag.
+	With '--atxi.internal', internal value transfers and contract creations are indexed too;
+	this executes the blocks again and requires the state of their parent blocks.
+	With '--tokens', the command builds the token Transfer event index enabled by '--atxi.tokens'
+	instead, with a placeholder of its own.
 			`,
 	Flags: []cli.Flag{
 		cli.IntFlag{
@@ -30,6 +34,10 @@ var buildAddrTxIndexCommand = cli.Command{
 			Name:  "stop",
 			Usage: "Block number at which to stop building index",
 		},
+		cli.BoolFlag{
+			Name:  "tokens",
+			Usage: "Build the token transfer index instead of the address transaction index",
+		},
 		cli.IntFlag{
 			Name:  "step",
 			Usage: "Step increment for batching. Higher number requires more mem, but may be faster",
@@ -65,6 +73,15 @@ func buildAddrTxIndexCmd(ctx *cli.Context) error {
 	}
 	defer chainDB.Close()
 
-	bc.SetAtxi(&core.AtxiT{Db: indexDB, AutoMode: false, Progress: &core.AtxiProgressT{}})
+	bc.SetAtxi(&core.AtxiT{
+		Db:       indexDB,
+		AutoMode: false,
+		Progress: &core.AtxiProgressT{},
+		Internal: ctx.GlobalBool(aliasableName(AddrTxIndexInternalFlag.Name, ctx)),
+		Tokens:   ctx.Bool("tokens"),
+	})
+	if ctx.Bool("tokens") {
+		return core.BuildTokenTransferIndex(bc, indexDB, startIndex, stopIndex, step)
+	}
 	return core.BuildAddrTxIndex(bc, chainDB, indexDB, startIndex, stopIndex, step)
 }
@@ -19,6 +19,8 @@ package main
 
 import (
 	"bufio"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -29,8 +31,10 @@ import (
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core"
-	"github.com/webchain-network/webchaind/core/state"
 	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/eth"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -67,6 +71,79 @@ var (
 	Use "$ webchaind dump 0" to dump the genesis block.
 		`,
 	}
+	dumpBadBlocksCommand = cli.Command{
+		Action:  dumpBadBlocks,
+		Name:    "dump-bad-blocks",
+		Aliases: []string{"dumpbadblocks"},
+		Usage:   "Dump the blocks rejected by the chain as JSON",
+		Description: `
+	Writes the bad blocks kept in the chain database to stdout, most recent first.
+	Each entry holds the hash, number and RLP of the block, the error it was
+	rejected with, the ID of the peer it came from (if known) and the time it
+	was received.
+		`,
+	}
+	migrateAncientCommand = cli.Command{
+		Action:  migrateAncient,
+		Name:    "migrate-ancient",
+		Aliases: []string{"migrateancient"},
+		Usage:   "Move the finalized blocks of the chain database to the ancient block freezer",
+		Description: `
+	Moves the headers, bodies, receipts and total difficulties of all canonical
+	blocks older than --ancient-depth (default 90000) from the chain database to
+	the append-only freezer files in the ancient directory of the datadir.
+	The node must not be running. Reads of moved blocks go to the freezer
+	transparently; run the node with --ancient-depth to keep freezing new blocks.
+		`,
+	}
+	dbCommand = cli.Command{
+		Name:  "db",
+		Usage: "Low level inspection and maintenance of the chain database",
+		Description: `
+	Works on the chain database, and on the index database if there is one, of
+	a stopped node. Run a node with the debug API to get the same statistics
+	from the running node (debug_chaindbInspect, debug_chaindbStats).
+		`,
+		Subcommands: []cli.Command{
+			{
+				Action: inspectDB,
+				Name:   "inspect",
+				Usage:  "Count the keys and bytes of the databases by kind of data",
+				Description: `
+	Iterates over all keys and sums the number and size of the headers, bodies,
+	receipts, transactions and their lookups, trie nodes, indexes and so on.
+	This reads the whole database and may take a long time.
+				`,
+			},
+			{
+				Action: dbStats,
+				Name:   "stats",
+				Usage:  "Print the LevelDB level statistics of the databases",
+			},
+			{
+				Action: compactDB,
+				Name:   "compact",
+				Usage:  "Compact the databases [optional arguments: start and limit hex keys]",
+				Description: `
+	Compacts all keys of the databases, or the keys in [start, limit) if hex
+	encoded start and limit keys are given. An empty key extends the range to
+	the first or last key.
+				`,
+			},
+		},
+	}
+	backupCommand = cli.Command{
+		Action: backup,
+		Name:   "backup",
+		Usage:  "Copy the chain database to a directory [REQUIRED argument: directory]",
+		Description: `
+	Writes a consistent copy of the chain database, the index database and the
+	ancient block freezer of a stopped node into the given directory, which must
+	not exist or be empty, along with a manifest.json describing the head block
+	of the copy. To restore, copy the directory contents into the chaindata
+	directory of a stopped node. Use admin.backup(directory) on a running node.
+		`,
+	}
 	dumpChainConfigCommand = cli.Command{
 		Action:  dumpChainConfig,
 		Name:    "dump-chain-config",
@@ -209,6 +286,188 @@ func upgradeDB(ctx *cli.Context) error {
 // unsorted dump is used by default.
 // revised use: $ webchaind dump [sorted] [hash|num],[hash|num],...,[hash|num] [address],[address],...,[address]
 
+func dumpBadBlocks(ctx *cli.Context) error {
+	chainDb := MakeChainDatabase(ctx)
+	defer chainDb.Close()
+
+	blocks, err := eth.GetBadBlocks(chainDb)
+	if err != nil {
+		glog.Fatalf("Could not read bad blocks: %v", err)
+	}
+	out, err := json.MarshalIndent(blocks, "", "    ")
+	if err != nil {
+		glog.Fatalf("Could not encode bad blocks: %v", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func migrateAncient(ctx *cli.Context) error {
+	depth := mustMakeAncientDepth(ctx, core.DefaultAncientDepth)
+	if depth == 0 {
+		glog.Fatalf("%v: --%s must not be 0", ErrInvalidFlag, aliasableName(AncientDepthFlag.Name, ctx))
+	}
+	chaindir := MustMakeChainDataDir(ctx)
+	backend := ctx.GlobalString(aliasableName(DatabaseBackendFlag.Name, ctx))
+	db, err := ethdb.OpenDatabase(backend, filepath.Join(chaindir, "chaindata"), ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx)), MakeDatabaseHandles())
+	if err != nil {
+		glog.Fatal("Could not open database: ", err)
+	}
+	chainDb, err := core.NewFreezerDatabase(db, filepath.Join(chaindir, "ancient"), 0)
+	if err != nil {
+		glog.Fatal("Could not open ancient block freezer: ", err)
+	}
+	defer chainDb.Close()
+
+	head := core.GetHeader(chainDb, core.GetHeadBlockHash(chainDb))
+	if head == nil {
+		glog.Fatal("Could not find the head block of the chain database")
+	}
+	if head.Number.Uint64() <= depth {
+		glog.D(logger.Warn).Infof("Head block #%d is within the ancient depth of %d blocks, nothing to migrate", head.Number, depth)
+		return nil
+	}
+	limit := head.Number.Uint64() - depth
+
+	start := time.Now()
+	glog.D(logger.Warn).Infof("Moving blocks below #%d to the ancient block freezer (%d already frozen)", limit, chainDb.Freezer().Frozen())
+	moved, err := chainDb.Freeze(limit, nil, func(frozen uint64) {
+		glog.D(logger.Warn).Infof("Frozen %d/%d blocks (%.2f%%), elapsed %v", frozen, limit, float64(frozen)*100/float64(limit), time.Since(start))
+	})
+	if err != nil {
+		glog.Fatalf("Could not freeze blocks: %v", err)
+	}
+	glog.D(logger.Warn).Infof("Moved %d blocks to the ancient block freezer in %v, %d frozen", moved, time.Since(start), chainDb.Freezer().Frozen())
+	if frozen := chainDb.Freezer().Frozen(); frozen < limit {
+		glog.D(logger.Warn).Warnf("Stopped at block #%d, which is missing from the chain database", frozen)
+	}
+	return nil
+}
+
+// openDatabases opens the chain database of a stopped node, and its index
+// database if there is one, without the ancient block freezer.
+func openDatabases(ctx *cli.Context) (names []string, dbs []ethdb.Database) {
+	chaindir := MustMakeChainDataDir(ctx)
+	backend := ctx.GlobalString(aliasableName(DatabaseBackendFlag.Name, ctx))
+	for _, name := range []string{"chaindata", "indexes"} {
+		path := filepath.Join(chaindir, name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if _, err := os.Stat(path + ".db"); os.IsNotExist(err) {
+				continue
+			}
+		}
+		db, err := ethdb.OpenDatabase(backend, path, ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx)), MakeDatabaseHandles())
+		if err != nil {
+			glog.Fatalf("Could not open %s database: %v", name, err)
+		}
+		names, dbs = append(names, name), append(dbs, db)
+	}
+	if len(dbs) == 0 {
+		glog.Fatalf("No database found in %s", chaindir)
+	}
+	return names, dbs
+}
+
+func inspectDB(ctx *cli.Context) error {
+	names, dbs := openDatabases(ctx)
+	for i, db := range dbs {
+		defer db.Close()
+
+		start := time.Now()
+		stats, err := core.InspectDatabase(db, nil, func(keys uint64) {
+			glog.D(logger.Warn).Infof("Inspected %d keys of the %s database, elapsed %v", keys, names[i], time.Since(start))
+		})
+		if err != nil {
+			glog.Fatalf("Could not inspect %s database: %v", names[i], err)
+		}
+		var count uint64
+		var size common.StorageSize
+		fmt.Printf("%s:\n", names[i])
+		for _, stat := range stats {
+			if stat.Count > 0 {
+				fmt.Printf("  %-28s %12d keys %12v\n", stat.Name, stat.Count, stat.Size)
+				count, size = count+stat.Count, size+stat.Size
+			}
+		}
+		fmt.Printf("  %-28s %12d keys %12v\n", "Total", count, size)
+	}
+	return nil
+}
+
+func dbStats(ctx *cli.Context) error {
+	names, dbs := openDatabases(ctx)
+	for i, db := range dbs {
+		defer db.Close()
+
+		stats, err := core.DatabaseStats(db)
+		if err != nil {
+			glog.Fatalf("Could not read %s database statistics: %v", names[i], err)
+		}
+		fmt.Printf("%s:\n%s\n", names[i], stats)
+	}
+	return nil
+}
+
+func compactDB(ctx *cli.Context) error {
+	var keys [2][]byte
+	for i := 0; i < ctx.NArg() && i < len(keys); i++ {
+		key, err := hex.DecodeString(strings.TrimPrefix(ctx.Args()[i], "0x"))
+		if err != nil {
+			return fmt.Errorf("%v: invalid hex key %q: %v", ErrInvalidFlag, ctx.Args()[i], err)
+		}
+		if len(key) > 0 {
+			keys[i] = key
+		}
+	}
+	names, dbs := openDatabases(ctx)
+	for i, db := range dbs {
+		defer db.Close()
+
+		start := time.Now()
+		glog.D(logger.Warn).Infof("Compacting %s database", names[i])
+		if err := core.CompactDatabase(db, keys[0], keys[1]); err != nil {
+			glog.Fatalf("Could not compact %s database: %v", names[i], err)
+		}
+		glog.D(logger.Warn).Infof("Compacted %s database in %v", names[i], time.Since(start))
+	}
+	return nil
+}
+
+func backup(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("%v: use: $ webchaind backup <directory>", ErrInvalidFlag)
+	}
+	names, dbs := openDatabases(ctx)
+	for _, db := range dbs {
+		defer db.Close()
+	}
+	if names[0] != "chaindata" {
+		glog.Fatalf("No chain database found in %s", MustMakeChainDataDir(ctx))
+	}
+	chainDb, indexDb := dbs[0], ethdb.Database(nil)
+	if len(dbs) > 1 {
+		indexDb = dbs[1]
+	}
+	if dir := filepath.Join(MustMakeChainDataDir(ctx), "ancient"); core.HasFreezer(dir) {
+		fdb, err := core.NewFreezerDatabase(chainDb, dir, 0)
+		if err != nil {
+			glog.Fatal("Could not open ancient block freezer: ", err)
+		}
+		defer fdb.Freezer().Close()
+		chainDb = fdb
+	}
+
+	start := time.Now()
+	manifest, err := core.Backup(ctx.Args().First(), chainDb, indexDb, func(name string, keys uint64) {
+		glog.D(logger.Warn).Infof("Backed up %d keys of the %s database, elapsed %v", keys, name, time.Since(start))
+	})
+	if err != nil {
+		glog.Fatalf("Could not back up chain database: %v", err)
+	}
+	glog.D(logger.Warn).Infof("Backed up chain database at block #%d [%x…] in %v", manifest.HeadNumber, manifest.HeadHash.Bytes()[:4], time.Since(start))
+	return nil
+}
+
 func dump(ctx *cli.Context) error {
 
 	if ctx.NArg() == 0 {
@@ -261,7 +520,7 @@ func dump(ctx *cli.Context) error {
 			out.WriteString("{}\n")
 			log.Fatal("block not found")
 		} else {
-			state, err := state.New(block.Root(), state.NewDatabase(chainDb))
+			state, err := chain.StateAt(block.Root())
 			if err != nil {
 				return fmt.Errorf("could not create new state: %v", err)
 			}
@@ -724,6 +724,9 @@ func startNode(ctx *cli.Context, stack *node.Node) *eth.Ethereum {
 		}
 		a.AutoMode = true
 		go core.BuildAddrTxIndex(ethereum.BlockChain(), ethereum.ChainDb(), a.Db, math.MaxUint64, math.MaxUint64, 10000)
+		if a.Tokens {
+			go core.BuildTokenTransferIndex(ethereum.BlockChain(), a.Db, math.MaxUint64, math.MaxUint64, 10000)
+		}
 	}
 	if ctx.GlobalBool(aliasableName(MiningEnabledFlag.Name, ctx)) {
 		if err := ethereum.StartMining(ctx.GlobalInt(aliasableName(MinerThreadsFlag.Name, ctx)), ctx.GlobalString(aliasableName(MiningGPUFlag.Name, ctx))); err != nil {
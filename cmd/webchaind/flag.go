@@ -534,6 +534,7 @@ func mustMakeStackConf(ctx *cli.Context, name string, config *core.SufficientCha
 	// Configure the node's service container
 	stackConf = &node.Config{
 		DataDir:         MustMakeChainDataDir(ctx),
+		DatabaseBackend: ctx.GlobalString(aliasableName(DatabaseBackendFlag.Name, ctx)),
 		PrivateKey:      MakeNodeKey(ctx),
 		Name:            name,
 		NoDiscovery:     ctx.GlobalBool(aliasableName(NoDiscoverFlag.Name, ctx)),
@@ -592,6 +593,8 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 		ChainConfig:             sconf.ChainConfig,
 		Genesis:                 sconf.Genesis,
 		UseAddrTxIndex:          ctx.GlobalBool(aliasableName(AddrTxIndexFlag.Name, ctx)),
+		AddrTxIndexInternal:     ctx.GlobalBool(aliasableName(AddrTxIndexInternalFlag.Name, ctx)),
+		AddrTxIndexTokens:       ctx.GlobalBool(aliasableName(AddrTxIndexTokensFlag.Name, ctx)),
 		FastSync:                ctx.GlobalBool(aliasableName(FastSyncFlag.Name, ctx)),
 		BlockChainVersion:       ctx.GlobalInt(aliasableName(BlockchainVersionFlag.Name, ctx)),
 		DatabaseCache:           ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx)),
@@ -611,7 +614,33 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 		GpobaseStepUp:           ctx.GlobalInt(aliasableName(GpobaseStepUpFlag.Name, ctx)),
 		GpobaseCorrectionFactor: ctx.GlobalInt(aliasableName(GpobaseCorrectionFactorFlag.Name, ctx)),
 		SolcPath:                ctx.GlobalString(aliasableName(SolcPathFlag.Name, ctx)),
+		TxPool:                  core.DefaultTxPoolConfig,
+	}
+
+	for _, setting := range []struct {
+		flag  cli.IntFlag
+		value *uint64
+	}{
+		{TxPoolPriceBumpFlag, &ethConf.TxPool.PriceBump},
+		{TxPoolAccountSlotsFlag, &ethConf.TxPool.AccountSlots},
+		{TxPoolGlobalSlotsFlag, &ethConf.TxPool.GlobalSlots},
+		{TxPoolAccountQueueFlag, &ethConf.TxPool.AccountQueue},
+		{TxPoolGlobalQueueFlag, &ethConf.TxPool.GlobalQueue},
+	} {
+		name := aliasableName(setting.flag.Name, ctx)
+		if v := ctx.GlobalInt(name); v < 0 {
+			log.Fatalf("invalid %s flag value %d: must not be negative", name, v)
+		} else {
+			*setting.value = uint64(v)
+		}
 	}
+	ethConf.TxPool.Lifetime = ctx.GlobalDuration(aliasableName(TxPoolLifetimeFlag.Name, ctx))
+	ethConf.TxPool.Journal = ctx.GlobalString(aliasableName(TxPoolJournalFlag.Name, ctx))
+	ethConf.TxPool.Rejournal = ctx.GlobalDuration(aliasableName(TxPoolRejournalFlag.Name, ctx))
+
+	ethConf.MinerPolicy = mustMakeMinerPolicy(ctx)
+	ethConf.AncientDepth = mustMakeAncientDepth(ctx, 0)
+	ethConf.StateGCDepth = mustMakeStateGCDepth(ctx)
 
 	if _, ok := ethConf.GasPrice.SetString(ctx.GlobalString(aliasableName(GasPriceFlag.Name, ctx)), 0); !ok {
 		log.Fatalf("malformed %s flag value %q", aliasableName(GasPriceFlag.Name, ctx), ctx.GlobalString(aliasableName(GasPriceFlag.Name, ctx)))
@@ -639,6 +668,76 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 	return ethConf
 }
 
+// mustMakeAncientDepth reads the depth of the ancient block freezer from the
+// flags, falling back to def if it isn't set, or fails hard.
+func mustMakeAncientDepth(ctx *cli.Context, def uint64) uint64 {
+	name := aliasableName(AncientDepthFlag.Name, ctx)
+	if !ctx.GlobalIsSet(name) {
+		return def
+	}
+	depth := ctx.GlobalInt(name)
+	if depth != 0 && depth < core.MinAncientDepth {
+		log.Fatalf("invalid %s flag value %d: must be 0 or at least %d", name, depth, core.MinAncientDepth)
+	}
+	return uint64(depth)
+}
+
+// mustMakeStateGCDepth reads the garbage collection mode of the state from the
+// flags, and returns the number of recent block states to keep, 0 for an
+// archive node. It fails hard on invalid values.
+func mustMakeStateGCDepth(ctx *cli.Context) uint64 {
+	switch mode := ctx.GlobalString(aliasableName(GCModeFlag.Name, ctx)); mode {
+	case "archive":
+		return 0
+	case "full":
+		name := aliasableName(GCDepthFlag.Name, ctx)
+		depth := ctx.GlobalInt(name)
+		if depth < core.MinStateGCDepth {
+			log.Fatalf("invalid %s flag value %d: must be at least %d", name, depth, core.MinStateGCDepth)
+		}
+		return uint64(depth)
+	default:
+		log.Fatalf("invalid %s flag value %q: must be full or archive", aliasableName(GCModeFlag.Name, ctx), mode)
+	}
+	return 0
+}
+
+// mustMakeMinerPolicy reads the transaction selection policy of the miner from
+// the flags, or fails hard.
+func mustMakeMinerPolicy(ctx *cli.Context) miner.PolicyConfig {
+	var config miner.PolicyConfig
+	for _, setting := range []struct {
+		flag  cli.StringFlag
+		value *[]common.Address
+	}{
+		{MinerPriorityFlag, &config.Priority},
+		{MinerExcludeFlag, &config.Exclude},
+	} {
+		name := aliasableName(setting.flag.Name, ctx)
+		for _, addr := range strings.Split(ctx.GlobalString(name), ",") {
+			if addr = strings.TrimSpace(addr); addr == "" {
+				continue
+			}
+			if !common.IsHexAddress(addr) {
+				log.Fatalf("malformed %s flag value %q: invalid address", name, addr)
+			}
+			*setting.value = append(*setting.value, common.HexToAddress(addr))
+		}
+	}
+	name := aliasableName(MinerLocalReserveFlag.Name, ctx)
+	if v := ctx.GlobalInt(name); v < 0 || v > 100 {
+		log.Fatalf("invalid %s flag value %d: must be a percentage between 0 and 100", name, v)
+	} else {
+		config.LocalReserve = uint64(v)
+	}
+	name = aliasableName(MinerSenderGasCapFlag.Name, ctx)
+	config.SenderGasCap = new(big.Int)
+	if _, ok := config.SenderGasCap.SetString(ctx.GlobalString(name), 0); !ok || config.SenderGasCap.Sign() < 0 {
+		log.Fatalf("malformed %s flag value %q", name, ctx.GlobalString(name))
+	}
+	return config
+}
+
 // mustMakeSufficientChainConfig makes a sufficent chain configuration (id, chainconfig, nodes,...)
 // based on --chain or defaults or fails hard.
 // - User must provide a full and complete config file if any is specified located at /custom/chain.json
@@ -796,29 +895,36 @@ func MustMakeChainConfigFromDefaults(ctx *cli.Context) *core.ChainConfig {
 	return c
 }
 
-// MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
+// MakeChainDatabase opens the chain database with the backend and settings passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context) ethdb.Database {
 	var (
 		chaindir = MustMakeChainDataDir(ctx)
+		backend  = ctx.GlobalString(aliasableName(DatabaseBackendFlag.Name, ctx))
 		cache    = ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx))
 		handles  = MakeDatabaseHandles()
 	)
 
-	chainDb, err := ethdb.NewLDBDatabase(filepath.Join(chaindir, "chaindata"), cache, handles)
+	chainDb, err := ethdb.OpenDatabase(backend, filepath.Join(chaindir, "chaindata"), cache, handles)
 	if err != nil {
 		glog.Fatal("Could not open database: ", err)
 	}
+	if dir := filepath.Join(chaindir, "ancient"); core.HasFreezer(dir) {
+		if chainDb, err = core.NewFreezerDatabase(chainDb, dir, 0); err != nil {
+			glog.Fatal("Could not open ancient block freezer: ", err)
+		}
+	}
 	return chainDb
 }
 
 func MakeIndexDatabase(ctx *cli.Context) ethdb.Database {
 	var (
 		chaindir = MustMakeChainDataDir(ctx)
+		backend  = ctx.GlobalString(aliasableName(DatabaseBackendFlag.Name, ctx))
 		cache    = ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx))
 		handles  = MakeDatabaseHandles()
 	)
 
-	indexesDb, err := ethdb.NewLDBDatabase(filepath.Join(chaindir, "indexes"), cache, handles)
+	indexesDb, err := ethdb.OpenDatabase(backend, filepath.Join(chaindir, "indexes"), cache, handles)
 	if err != nil {
 		glog.Fatal("Could not open database: ", err)
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core"
 	"github.com/webchain-network/webchaind/eth"
+	"github.com/webchain-network/webchaind/ethdb"
 	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/rpc"
 	"gopkg.in/urfave/cli.v1"
@@ -85,6 +86,26 @@ var (
 		Usage: "Megabytes of memory allocated to internal caching (min 16MB / database forced)",
 		Value: 1024,
 	}
+	DatabaseBackendFlag = cli.StringFlag{
+		Name:  "backend",
+		Usage: "Database backend for chain data (leveldb|bolt|memory). bolt syncs every single write to disk, which makes block import slower than with leveldb",
+		Value: ethdb.BackendLevelDB,
+	}
+	AncientDepthFlag = cli.IntFlag{
+		Name:  "ancient-depth,ancientdepth",
+		Usage: "Number of recent blocks kept in the chain database, older ones are moved to the ancient block freezer (0 = disabled)",
+		Value: 0,
+	}
+	GCModeFlag = cli.StringFlag{
+		Name:  "gcmode",
+		Usage: `Garbage collection mode of the state: "full" prunes old states, "archive" keeps all of them`,
+		Value: "archive",
+	}
+	GCDepthFlag = cli.IntFlag{
+		Name:  "gc-depth,gcdepth",
+		Usage: "Number of recent block states kept in full garbage collection mode",
+		Value: core.DefaultStateGCDepth,
+	}
 	BlockchainVersionFlag = cli.IntFlag{
 		Name:  "blockchain-version,blockchainversion",
 		Usage: "Blockchain version (integer)",
@@ -106,6 +127,14 @@ var (
 		Name:  "atxi.autobuild,atxi.auto-build",
 		Usage: "Begins automatic concurrent indexes building process that runs alongside a normally running geth.",
 	}
+	AddrTxIndexInternalFlag = cli.BoolFlag{
+		Name:  "atxi.internal",
+		Usage: "Also index addresses of internal value transfers and contract creations (kindof 'i'). Blocks are then imported with the native EVM, even with --sputnikvm. Requires archive mode state to build for pre-existing chaindata",
+	}
+	AddrTxIndexTokensFlag = cli.BoolFlag{
+		Name:  "atxi.tokens",
+		Usage: "Also index token Transfer events by address. Pre-existing chaindata can be indexed with command 'atxi-build --tokens'",
+	}
 	// Network Split settings
 	ETFChain = cli.BoolFlag{
 		Name:  "etf",
@@ -146,6 +175,26 @@ var (
 		Name:  "extra-data,extradata",
 		Usage: "Freeform header field set by the miner",
 	}
+	MinerPriorityFlag = cli.StringFlag{
+		Name:  "miner-priority,minerpriority",
+		Usage: "Comma separated list of sender addresses whose transactions are included in mined blocks first",
+		Value: "",
+	}
+	MinerExcludeFlag = cli.StringFlag{
+		Name:  "miner-exclude,minerexclude",
+		Usage: "Comma separated list of contract addresses whose transactions are never included in mined blocks",
+		Value: "",
+	}
+	MinerLocalReserveFlag = cli.IntFlag{
+		Name:  "miner-local-reserve,minerlocalreserve",
+		Usage: "Percentage of the block gas limit reserved for transactions of local accounts",
+		Value: 0,
+	}
+	MinerSenderGasCapFlag = cli.StringFlag{
+		Name:  "miner-sender-gascap,minersendergascap",
+		Usage: "Maximum gas the transactions of a single remote sender may use in a mined block (0 = unlimited)",
+		Value: "0",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -411,6 +460,47 @@ var (
 		Usage: "Suggested gas price base correction factor (%)",
 		Value: 110,
 	}
+	// Transaction pool settings
+	TxPoolPriceBumpFlag = cli.IntFlag{
+		Name:  "txpool-pricebump,txpoolpricebump",
+		Usage: "Price bump percentage to replace an already existing transaction",
+		Value: int(core.DefaultTxPoolConfig.PriceBump),
+	}
+	TxPoolAccountSlotsFlag = cli.IntFlag{
+		Name:  "txpool-accountslots,txpoolaccountslots",
+		Usage: "Number of executable transaction slots guaranteed per account",
+		Value: int(core.DefaultTxPoolConfig.AccountSlots),
+	}
+	TxPoolGlobalSlotsFlag = cli.IntFlag{
+		Name:  "txpool-globalslots,txpoolglobalslots",
+		Usage: "Maximum number of executable transaction slots for all accounts",
+		Value: int(core.DefaultTxPoolConfig.GlobalSlots),
+	}
+	TxPoolAccountQueueFlag = cli.IntFlag{
+		Name:  "txpool-accountqueue,txpoolaccountqueue",
+		Usage: "Maximum number of non-executable transaction slots permitted per account",
+		Value: int(core.DefaultTxPoolConfig.AccountQueue),
+	}
+	TxPoolGlobalQueueFlag = cli.IntFlag{
+		Name:  "txpool-globalqueue,txpoolglobalqueue",
+		Usage: "Maximum number of non-executable transaction slots for all accounts",
+		Value: int(core.DefaultTxPoolConfig.GlobalQueue),
+	}
+	TxPoolLifetimeFlag = cli.DurationFlag{
+		Name:  "txpool-lifetime,txpoollifetime",
+		Usage: "Maximum amount of time non-executable transactions of an inactive account are queued",
+		Value: core.DefaultTxPoolConfig.Lifetime,
+	}
+	TxPoolJournalFlag = cli.StringFlag{
+		Name:  "txpool-journal,txpooljournal",
+		Usage: "Disk journal for local transactions to survive node restarts, relative to the chain data directory (empty to disable)",
+		Value: eth.DefaultTxPoolJournal,
+	}
+	TxPoolRejournalFlag = cli.DurationFlag{
+		Name:  "txpool-rejournal,txpoolrejournal",
+		Usage: "Time interval to regenerate the local transaction journal",
+		Value: core.DefaultTxPoolConfig.Rejournal,
+	}
 	Unused1 = cli.BoolFlag{
 		Name:  "oppose-dao-fork",
 		Usage: "Use classic blockchain (always set, flag is unused and exists for compatibility only)",
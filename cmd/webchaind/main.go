@@ -107,6 +107,10 @@ func makeCLIApp() (app *cli.App) {
 		dumpChainConfigCommand,
 		upgradedbCommand,
 		dumpCommand,
+		dumpBadBlocksCommand,
+		migrateAncientCommand,
+		dbCommand,
+		backupCommand,
 		rollbackCommand,
 		recoverCommand,
 		resetCommand,
@@ -142,7 +146,13 @@ func makeCLIApp() (app *cli.App) {
 		FastSyncFlag,
 		AddrTxIndexFlag,
 		AddrTxIndexAutoBuildFlag,
+		AddrTxIndexInternalFlag,
+		AddrTxIndexTokensFlag,
 		CacheFlag,
+		DatabaseBackendFlag,
+		AncientDepthFlag,
+		GCModeFlag,
+		GCDepthFlag,
 		LightKDFFlag,
 		JSpathFlag,
 		ListenPortFlag,
@@ -153,6 +163,10 @@ func makeCLIApp() (app *cli.App) {
 		MinerThreadsFlag,
 		MiningEnabledFlag,
 		MiningGPUFlag,
+		MinerPriorityFlag,
+		MinerExcludeFlag,
+		MinerLocalReserveFlag,
+		MinerSenderGasCapFlag,
 		TargetGasLimitFlag,
 		NATFlag,
 		NatspecEnabledFlag,
@@ -204,6 +218,14 @@ func makeCLIApp() (app *cli.App) {
 		GpobaseStepDownFlag,
 		GpobaseStepUpFlag,
 		GpobaseCorrectionFactorFlag,
+		TxPoolPriceBumpFlag,
+		TxPoolAccountSlotsFlag,
+		TxPoolGlobalSlotsFlag,
+		TxPoolAccountQueueFlag,
+		TxPoolGlobalQueueFlag,
+		TxPoolLifetimeFlag,
+		TxPoolJournalFlag,
+		TxPoolRejournalFlag,
 		ExtraDataFlag,
 		Unused1,
 	}
@@ -67,6 +67,9 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			exportCommand,
 			dumpChainConfigCommand,
 			dumpCommand,
+			migrateAncientCommand,
+			dbCommand,
+			backupCommand,
 			rollbackCommand,
 			recoverCommand,
 			resetCommand,
@@ -79,6 +82,10 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			NodeNameFlag,
 			FastSyncFlag,
 			CacheFlag,
+			DatabaseBackendFlag,
+			AncientDepthFlag,
+			GCModeFlag,
+			GCDepthFlag,
 			LightKDFFlag,
 			SputnikVMFlag,
 			BlockchainVersionFlag,
@@ -98,6 +105,8 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			AccountsIndexFlag,
 			AddrTxIndexFlag,
 			AddrTxIndexAutoBuildFlag,
+			AddrTxIndexInternalFlag,
+			AddrTxIndexTokensFlag,
 		},
 	},
 	{
@@ -150,6 +159,10 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			TargetGasLimitFlag,
 			GasPriceFlag,
 			ExtraDataFlag,
+			MinerPriorityFlag,
+			MinerExcludeFlag,
+			MinerLocalReserveFlag,
+			MinerSenderGasCapFlag,
 		},
 	},
 	{
@@ -163,6 +176,19 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			GpobaseCorrectionFactorFlag,
 		},
 	},
+	{
+		Name: "TRANSACTION POOL",
+		Flags: []cli.Flag{
+			TxPoolPriceBumpFlag,
+			TxPoolAccountSlotsFlag,
+			TxPoolGlobalSlotsFlag,
+			TxPoolAccountQueueFlag,
+			TxPoolGlobalQueueFlag,
+			TxPoolLifetimeFlag,
+			TxPoolJournalFlag,
+			TxPoolRejournalFlag,
+		},
+	},
 	{
 		Name: "LOGGING AND DEBUGGING",
 		Commands: []cli.Command{
@@ -33,6 +33,12 @@ type AtxiT struct {
 	AutoMode bool
 	Progress *AtxiProgressT
 	Step     uint64
+	// Internal enables indexing the addresses of internal value transfers and
+	// contract creations, with kindof 'i'.
+	Internal bool
+	// Tokens enables the token transfer index, built with its own progress.
+	Tokens        bool
+	TokenProgress *AtxiProgressT
 }
 
 type AtxiProgressT struct {
@@ -70,7 +76,7 @@ func formatAddrTxIterator(address common.Address) (iteratorPrefix []byte) {
 	return
 }
 
-// formatAddrTxBytesIndex formats the index key, eg. atx-<addr><blockNumber><t|f><s|c|><txhash>
+// formatAddrTxBytesIndex formats the index key, eg. atx-<addr><blockNumber><t|f><s|c|i><txhash>
 // The values for these arguments should be of determinate length and format, see test TestFormatAndResolveAddrTxBytesKey
 // for example.
 func formatAddrTxBytesIndex(address, blockNumber, direction, kindof, txhash []byte) (key []byte) {
@@ -95,18 +101,25 @@ func resolveAddrTxBytes(key []byte) (address, blockNumber, direction, kindof, tx
 	return
 }
 
-// WriteBlockAddTxIndexes writes atx-indexes for a given block.
+// WriteBlockAddTxIndexes writes atx-indexes for a given block, including those
+// of its internal transfers if they are stored in indexDb.
 func WriteBlockAddTxIndexes(indexDb ethdb.Database, block *types.Block) error {
 	batch := indexDb.NewBatch()
-	if _, err := putBlockAddrTxsToBatch(batch, block); err != nil {
+	internal, _ := readInternalTransfers(indexDb, block.Hash())
+	if _, err := putBlockAddrTxsToBatch(batch, block, internal); err != nil {
 		return err
 	}
 	return batch.Write()
 }
 
-// putBlockAddrTxsToBatch formats and puts keys for a given block to a db Batch.
+// putBlockAddrTxsToBatch formats and puts keys for a given block, and for the given
+// internal transfers of the block, to a db Batch.
 // Batch can be written afterward if no errors, ie. batch.Write()
-func putBlockAddrTxsToBatch(putBatch ethdb.Batch, block *types.Block) (txsCount int, err error) {
+func putBlockAddrTxsToBatch(putBatch ethdb.Batch, block *types.Block, internal []internalTransfer) (txsCount int, err error) {
+	// Note that len 8 because uint64 guaranteed <= 8 bytes.
+	bn := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bn, block.NumberU64())
+
 	for _, tx := range block.Transactions() {
 		txsCount++
 
@@ -123,10 +136,6 @@ func putBlockAddrTxsToBatch(putBatch ethdb.Batch, block *types.Block) (txsCount
 			txKindOf = []byte("c")
 		}
 
-		// Note that len 8 because uint64 guaranteed <= 8 bytes.
-		bn := make([]byte, 8)
-		binary.LittleEndian.PutUint64(bn, block.NumberU64())
-
 		if err := putBatch.Put(formatAddrTxBytesIndex(from.Bytes(), bn, []byte("f"), txKindOf, tx.Hash().Bytes()), nil); err != nil {
 			return txsCount, err
 		}
@@ -134,6 +143,15 @@ func putBlockAddrTxsToBatch(putBatch ethdb.Batch, block *types.Block) (txsCount
 			return txsCount, err
 		}
 	}
+	// i: internal
+	for _, t := range internal {
+		if err := putBatch.Put(formatAddrTxBytesIndex(t.From.Bytes(), bn, []byte("f"), []byte("i"), t.Tx.Bytes()), nil); err != nil {
+			return txsCount, err
+		}
+		if err := putBatch.Put(formatAddrTxBytesIndex(t.To.Bytes(), bn, []byte("t"), []byte("i"), t.Tx.Bytes()), nil); err != nil {
+			return txsCount, err
+		}
+	}
 	return txsCount, nil
 }
 
@@ -300,8 +318,8 @@ func GetAddrTxs(db ethdb.Database, address common.Address, blockStartN uint64, b
 		err = errWithReason(errAtxiInvalidUse, "Address transactions list signature requires direction param to be empty string or [b|t|f] prefix (eg. both, to, or from)")
 		return
 	}
-	if len(kindof) > 0 && !strings.Contains("bsc", kindof[:1]) {
-		err = errWithReason(errAtxiInvalidUse, "Address transactions list signature requires 'kind of' param to be empty string or [s|c] prefix (eg. both, standard, or contract)")
+	if len(kindof) > 0 && !strings.Contains("bsci", kindof[:1]) {
+		err = errWithReason(errAtxiInvalidUse, "Address transactions list signature requires 'kind of' param to be empty string or [s|c|i] prefix (eg. both, standard, contract, or internal)")
 		return
 	}
 	if paginationStart > 0 && paginationEnd > 0 && paginationStart > paginationEnd {
@@ -312,13 +330,6 @@ func GetAddrTxs(db ethdb.Database, address common.Address, blockStartN uint64, b
 		paginationStart = 0
 	}
 
-	// Have to cast to LevelDB to use iterator. Yuck.
-	ldb, ok := db.(*ethdb.LDBDatabase)
-	if !ok {
-		err = errWithReason(errors.New("internal interface error; please file a bug report"), "could not cast eth db to level db")
-		return nil, nil
-	}
-
 	// This will be the returnable.
 	var hashes []string
 
@@ -332,9 +343,8 @@ func GetAddrTxs(db ethdb.Database, address common.Address, blockStartN uint64, b
 		wantKindOf = kindof[0]
 	}
 
-	// Create address prefix for iteration.
-	prefix := ethdb.NewBytesPrefix(formatAddrTxIterator(address))
-	it := ldb.NewIteratorRange(prefix)
+	// Iterate the indexes of the address.
+	it := db.NewIteratorWithPrefix(formatAddrTxIterator(address))
 
 	var atxis sortableAtxis
 
@@ -357,7 +367,7 @@ func GetAddrTxs(db ethdb.Database, address common.Address, blockStartN uint64, b
 		if wantDirectionB != 'b' && wantDirectionB != torf[0] {
 			continue
 		}
-		// Ensure filter for/agnostic transaction kind of (contract, standard, both)
+		// Ensure filter for/agnostic transaction kind of (contract, standard, internal, both)
 		if wantKindOf != 'b' && wantKindOf != k[0] {
 			continue
 		}
@@ -404,11 +414,6 @@ func RmAddrTx(db ethdb.Database, tx *types.Transaction) error {
 		return nil
 	}
 
-	ldb, ok := db.(*ethdb.LDBDatabase)
-	if !ok {
-		return nil
-	}
-
 	txH := tx.Hash()
 	from, err := tx.From()
 	if err != nil {
@@ -418,8 +423,7 @@ func RmAddrTx(db ethdb.Database, tx *types.Transaction) error {
 	removals := [][]byte{}
 
 	// TODO: not DRY, could be refactored
-	pre := ethdb.NewBytesPrefix(formatAddrTxIterator(from))
-	it := ldb.NewIteratorRange(pre)
+	it := db.NewIteratorWithPrefix(formatAddrTxIterator(from))
 	for it.Next() {
 		key := it.Key()
 		_, _, _, _, txh := resolveAddrTxBytes(key)
@@ -436,8 +440,7 @@ func RmAddrTx(db ethdb.Database, tx *types.Transaction) error {
 	to := tx.To()
 	if to != nil {
 		toRef := *to
-		pre := ethdb.NewBytesPrefix(formatAddrTxIterator(toRef))
-		it := ldb.NewIteratorRange(pre)
+		it := db.NewIteratorWithPrefix(formatAddrTxIterator(toRef))
 		for it.Next() {
 			key := it.Key()
 			_, _, _, _, txh := resolveAddrTxBytes(key)
@@ -0,0 +1,276 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+var (
+	// txAddressInternalPrefix + block hash -> RLP list of the internal transfers
+	// of the block, kept in the index database so that the internal atx- keys of
+	// a block can be written and removed without executing it again. Only blocks
+	// with internal transfers have a key.
+	txAddressInternalPrefix = []byte("atxint-")
+
+	// txAddressInternalRangeKey holds the first and last numbers of the blocks
+	// imported in a row with internal indexing enabled. Their internal transfers
+	// were stored on import, so those without a key have none.
+	txAddressInternalRangeKey = []byte("ATXIInternalRange")
+)
+
+// internalTransfer is a value transfer or contract creation made by a
+// contract while executing a transaction: a CALL carrying value, a CREATE or
+// a SELFDESTRUCT of a contract with a balance. It is indexed with kindof 'i'.
+type internalTransfer struct {
+	Tx   common.Hash
+	From common.Address
+	To   common.Address
+}
+
+// internalTransferTracer is a vm.FrameTracer collecting the internal transfers
+// of a transaction. Transfers of frames which fail are dropped along with the
+// frame, as they are reverted.
+type internalTransferTracer struct {
+	tx        common.Hash
+	frames    [][]internalTransfer // transfers of the frames entered but not yet left
+	transfers []internalTransfer
+}
+
+func newInternalTransferTracer(tx common.Hash) *internalTransferTracer {
+	return &internalTransferTracer{tx: tx}
+}
+
+// CaptureSuicide records SELFDESTRUCT operations sending a balance.
+func (t *internalTransferTracer) CaptureSuicide(env vm.Environment, contract, beneficiary common.Address, balance *big.Int) {
+	if len(t.frames) > 0 && balance.Sign() > 0 {
+		t.add(contract, beneficiary)
+	}
+}
+
+// CaptureEnter records value carrying calls and contract creations below the
+// top level frame, which is the transaction itself.
+func (t *internalTransferTracer) CaptureEnter(env vm.Environment, typ vm.OpCode, from, to common.Address, input []byte, gas, value *big.Int) {
+	t.frames = append(t.frames, nil)
+	if len(t.frames) == 1 {
+		return
+	}
+	if typ == vm.CREATE || (typ == vm.CALL && value != nil && value.Sign() > 0) {
+		t.add(from, to)
+	}
+}
+
+// CaptureExit hands the transfers of a successful frame over to its parent.
+func (t *internalTransferTracer) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	transfers := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err != nil {
+		return
+	}
+	if len(t.frames) == 0 {
+		t.transfers = append(t.transfers, transfers...)
+	} else {
+		parent := len(t.frames) - 1
+		t.frames[parent] = append(t.frames[parent], transfers...)
+	}
+}
+
+func (t *internalTransferTracer) add(from, to common.Address) {
+	top := len(t.frames) - 1
+	t.frames[top] = append(t.frames[top], internalTransfer{Tx: t.tx, From: from, To: to})
+}
+
+// processWithInternalTransfers processes block like Process, tracing the
+// internal transfers of its transactions. Only call frames and SELFDESTRUCT
+// operations are traced, but that takes the native EVM even if UseSputnikVM is
+// set.
+func processWithInternalTransfers(processor *StateProcessor, block *types.Block, statedb *state.StateDB) (types.Receipts, vm.Logs, *big.Int, []internalTransfer, error) {
+	var tracers []*internalTransferTracer
+	vmConfig := func(i int, tx *types.Transaction) vm.Config {
+		tracer := newInternalTransferTracer(tx.Hash())
+		tracers = append(tracers, tracer)
+		return vm.Config{FrameTracer: tracer}
+	}
+	receipts, logs, usedGas, err := processor.ProcessWithConfig(block, statedb, vmConfig)
+	if err != nil {
+		return nil, nil, usedGas, nil, err
+	}
+	var transfers []internalTransfer
+	for _, tracer := range tracers {
+		transfers = append(transfers, tracer.transfers...)
+	}
+	return receipts, logs, usedGas, transfers, nil
+}
+
+// process processes block with the block processor. If the addr-tx index
+// covers internal transfers, they are traced and returned too, as a non-nil
+// slice.
+func (bc *BlockChain) process(block *types.Block, statedb *state.StateDB) (types.Receipts, vm.Logs, *big.Int, []internalTransfer, error) {
+	if processor, ok := bc.processor.(*StateProcessor); ok && bc.atxi != nil && bc.atxi.Internal {
+		receipts, logs, usedGas, internal, err := processWithInternalTransfers(processor, block, statedb)
+		if internal == nil {
+			internal = []internalTransfer{}
+		}
+		return receipts, logs, usedGas, internal, err
+	}
+	receipts, logs, usedGas, err := bc.processor.Process(block, statedb)
+	return receipts, logs, usedGas, nil, err
+}
+
+// blockInternalTransfers returns the internal transfers of block to index. They
+// are read from indexDb if they were stored when the block was imported, or
+// traced and stored otherwise. Without internal indexing it returns nil.
+func (bc *BlockChain) blockInternalTransfers(indexDb ethdb.Database, block *types.Block) ([]internalTransfer, error) {
+	if transfers, ok := readInternalTransfers(indexDb, block.Hash()); ok {
+		return transfers, nil
+	}
+	if bc.atxi == nil || !bc.atxi.Internal {
+		return nil, nil
+	}
+	if first, last, ok := readInternalRange(indexDb); ok && first <= block.NumberU64() && block.NumberU64() <= last {
+		return nil, nil
+	}
+	transfers, err := bc.traceInternalTransfers(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace internal transfers of block #%d: %v", block.NumberU64(), err)
+	}
+	return transfers, writeInternalTransfers(indexDb, block.Hash(), transfers)
+}
+
+// traceInternalTransfers executes block again on the state of its parent to
+// collect its internal transfers. It fails if that state is not available.
+func (bc *BlockChain) traceInternalTransfers(block *types.Block) ([]internalTransfer, error) {
+	if len(block.Transactions()) == 0 {
+		return nil, nil
+	}
+	parent := bc.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("block #%d parent %x not found", block.NumberU64(), block.ParentHash())
+	}
+	statedb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	_, _, _, transfers, err := processWithInternalTransfers(NewStateProcessor(bc.config, bc), block, statedb)
+	return transfers, err
+}
+
+func internalTransfersKey(hash common.Hash) []byte {
+	return append(append([]byte{}, txAddressInternalPrefix...), hash.Bytes()...)
+}
+
+// initInternalRange starts a new range of blocks imported with internal
+// indexing, unless blocks were imported without it since the last one.
+func (bc *BlockChain) initInternalRange() error {
+	head := bc.CurrentBlock().NumberU64()
+	if _, last, ok := readInternalRange(bc.atxi.Db); ok && last == head {
+		return nil
+	}
+	return writeInternalRange(bc.atxi.Db, head+1, head)
+}
+
+// writeImportedInternalTransfers stores the internal transfers of a block
+// imported with internal indexing, and extends the range of such blocks with
+// it if it follows the range.
+func writeImportedInternalTransfers(db ethdb.Database, block *types.Block, transfers []internalTransfer) error {
+	if err := writeInternalTransfers(db, block.Hash(), transfers); err != nil {
+		return err
+	}
+	n := block.NumberU64()
+	first, last, ok := readInternalRange(db)
+	switch {
+	case !ok:
+		return nil
+	case first > last:
+		return writeInternalRange(db, n, n)
+	case n > last && n >= first:
+		return writeInternalRange(db, first, n)
+	}
+	return nil
+}
+
+func readInternalRange(db ethdb.Database) (first, last uint64, ok bool) {
+	data, err := db.Get(txAddressInternalRangeKey)
+	if err != nil || len(data) != 16 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint64(data[:8]), binary.BigEndian.Uint64(data[8:]), true
+}
+
+func writeInternalRange(db ethdb.Putter, first, last uint64) error {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint64(data[:8], first)
+	binary.BigEndian.PutUint64(data[8:], last)
+	return db.Put(txAddressInternalRangeKey, data)
+}
+
+// writeInternalTransfers stores the internal transfers of a block, if there
+// are any.
+func writeInternalTransfers(db ethdb.Putter, hash common.Hash, transfers []internalTransfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	data, err := rlp.EncodeToBytes(transfers)
+	if err != nil {
+		return err
+	}
+	return db.Put(internalTransfersKey(hash), data)
+}
+
+// readInternalTransfers returns the stored internal transfers of a block, and
+// whether there are any stored for it at all.
+func readInternalTransfers(db ethdb.Database, hash common.Hash) ([]internalTransfer, bool) {
+	data, err := db.Get(internalTransfersKey(hash))
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	var transfers []internalTransfer
+	if err := rlp.DecodeBytes(data, &transfers); err != nil {
+		return nil, false
+	}
+	return transfers, true
+}
+
+// rmBlockInternalAddrTxs removes the internal atx- keys of a block, eg. when
+// the block leaves the canonical chain in a reorg. The stored transfers are
+// kept, in case the block becomes canonical again.
+func rmBlockInternalAddrTxs(db ethdb.Database, block *types.Block) error {
+	transfers, _ := readInternalTransfers(db, block.Hash())
+	bn := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bn, block.NumberU64())
+	for _, t := range transfers {
+		if err := db.Delete(formatAddrTxBytesIndex(t.From.Bytes(), bn, []byte("f"), []byte("i"), t.Tx.Bytes())); err != nil {
+			return err
+		}
+		if err := db.Delete(formatAddrTxBytesIndex(t.To.Bytes(), bn, []byte("t"), []byte("i"), t.Tx.Bytes())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
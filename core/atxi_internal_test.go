@@ -0,0 +1,150 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+func TestInternalTransferTracer(t *testing.T) {
+	var (
+		tx      = common.Hash{0x01}
+		a, b, c = common.Address{0xa}, common.Address{0xb}, common.Address{0xc}
+		value   = big.NewInt(1)
+		gas     = big.NewInt(0)
+	)
+	tracer := newInternalTransferTracer(tx)
+	tracer.CaptureEnter(nil, vm.CALL, common.Address{}, a, nil, gas, value) // the transaction itself
+	tracer.CaptureEnter(nil, vm.CALL, a, b, nil, gas, value)
+	tracer.CaptureEnter(nil, vm.CREATE, b, c, nil, gas, new(big.Int))
+	tracer.CaptureSuicide(nil, c, a, new(big.Int)) // no balance
+	tracer.CaptureExit(nil, gas, nil)
+	tracer.CaptureSuicide(nil, b, c, value)
+	tracer.CaptureExit(nil, gas, nil)
+	tracer.CaptureEnter(nil, vm.CALL, a, b, nil, gas, new(big.Int)) // no value
+	tracer.CaptureExit(nil, gas, nil)
+	tracer.CaptureEnter(nil, vm.CALL, a, c, nil, gas, value) // reverted
+	tracer.CaptureExit(nil, gas, vm.ErrRevert)
+	tracer.CaptureExit(nil, gas, nil)
+
+	want := []internalTransfer{{tx, a, b}, {tx, b, c}, {tx, b, c}}
+	if !reflect.DeepEqual(tracer.transfers, want) {
+		t.Errorf("transfers mismatch: have %v, want %v", tracer.transfers, want)
+	}
+}
+
+func TestAtxiInternalTransfers(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	var (
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = crypto.CreateAddress(addr, 0)
+		suicidal = crypto.CreateAddress(addr, 1)
+		sink     = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		config   = DefaultConfigMorden.ChainConfig
+	)
+	// The contract forwards the value it receives to the sink
+	code := common.Hex2Bytes("6021600c60003960216000f3" + "600060006000600034" + "73" + common.Bytes2Hex(sink.Bytes()) + "5af100")
+	// The suicidal contract self-destructs in favour of the sink
+	suicide := common.Hex2Bytes("6016600c60003960166000f3" + "73" + common.Bytes2Hex(sink.Bytes()) + "ff")
+
+	db, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000000000000000)})
+	gendb, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(gendb, GenesisAccount{addr, big.NewInt(1000000000000000000)})
+
+	var call, kill *types.Transaction
+	blocks, _ := GenerateChain(config, genesis, gendb, 3, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			tx, _ := types.NewContractCreation(0, new(big.Int), big.NewInt(100000), new(big.Int), code).SignECDSA(key)
+			gen.AddTx(tx)
+			tx, _ = types.NewContractCreation(1, new(big.Int), big.NewInt(100000), new(big.Int), suicide).SignECDSA(key)
+			gen.AddTx(tx)
+		case 1:
+			call, _ = types.NewTransaction(2, contract, big.NewInt(1000), big.NewInt(100000), new(big.Int), nil).SignECDSA(key)
+			gen.AddTx(call)
+		case 2:
+			kill, _ = types.NewTransaction(3, suicidal, big.NewInt(500), big.NewInt(100000), new(big.Int), nil).SignECDSA(key)
+			gen.AddTx(kill)
+		}
+	})
+
+	bc, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop()
+	indexDb, _ := ethdb.NewMemDatabase()
+	bc.SetAtxi(&AtxiT{Db: indexDb, Internal: true})
+
+	if res := bc.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert block %d: %v", res.Index, res.Error)
+	}
+	statedb, _ := bc.State()
+	if balance := statedb.GetBalance(sink); balance.Cmp(big.NewInt(1500)) != 0 {
+		t.Fatalf("sink balance mismatch: have %v, want 1500", balance)
+	}
+
+	check := func(db ethdb.Database, address common.Address, direction, kindof string, want []string) {
+		txs, err := GetAddrTxs(db, address, 0, 0, direction, kindof, 0, -1, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(txs) != len(want) || (len(want) > 0 && txs[0] != want[0]) {
+			t.Errorf("%x %s %s: transactions mismatch: have %v, want %v", address, direction, kindof, txs, want)
+		}
+	}
+	callHash, killHash := []string{call.Hash().Hex()}, []string{kill.Hash().Hex()}
+	check(indexDb, sink, "t", "i", append(killHash, callHash...))
+	check(indexDb, contract, "f", "i", callHash)
+	check(indexDb, contract, "t", "s", callHash)
+	check(indexDb, suicidal, "f", "i", killHash)
+	check(indexDb, sink, "", "s", nil)
+
+	// Only blocks with internal transfers have them stored, the imported range
+	// tells the others apart from blocks never traced
+	if _, ok := readInternalTransfers(indexDb, blocks[0].Hash()); ok {
+		t.Errorf("internal transfers stored for a block without any")
+	}
+	if first, last, ok := readInternalRange(indexDb); !ok || first != 1 || last != 3 {
+		t.Errorf("imported range mismatch: have [%d, %d] (%v), want [1, 3]", first, last, ok)
+	}
+
+	// Backfilling traces the blocks again
+	backfillDb, _ := ethdb.NewMemDatabase()
+	if _, err := bc.WriteBlockAddrTxIndexesBatch(backfillDb, 0, 3, 10); err != nil {
+		t.Fatalf("failed to backfill indexes: %v", err)
+	}
+	check(backfillDb, sink, "t", "i", append(killHash, callHash...))
+
+	// Removing the blocks, eg. in a reorg, drops their internal indexes
+	for _, block := range blocks[1:] {
+		if err := rmBlockInternalAddrTxs(indexDb, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check(indexDb, sink, "", "", nil)
+}
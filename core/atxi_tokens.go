@@ -0,0 +1,514 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+var (
+	tokenTransferIndexPrefix = []byte("ttx-")
+	tokenTransferBookmarkKey = []byte("ATXITokensBookmark")
+
+	// TransferEventTopic is the topic of the Transfer(address,address,uint256)
+	// event emitted by ERC20, ERC223 and ERC721 token contracts.
+	TransferEventTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+// TokenTransfer is a Transfer event of a token contract. Value is the amount
+// of tokens for fungible tokens, and the token id for ERC721 tokens.
+type TokenTransfer struct {
+	Token       common.Address `json:"token"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+}
+
+// tokenTransferValue is the value of a token transfer index key.
+type tokenTransferValue struct {
+	From, To common.Address
+	Value    *big.Int
+}
+
+func dbGetTokenTransferBookmark(db ethdb.Database) uint64 {
+	v, err := db.Get(tokenTransferBookmarkKey)
+	if err != nil || v == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(v)
+}
+
+func dbSetTokenTransferBookmark(db ethdb.Database, i uint64) error {
+	bn := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bn, i)
+	return db.Put(tokenTransferBookmarkKey, bn)
+}
+
+// formatTokenTransferIterator formats the index key prefix iterator, eg. ttx-<address>
+// or ttx-<address><token> if token isn't nil.
+func formatTokenTransferIterator(address common.Address, token *common.Address) (iteratorPrefix []byte) {
+	iteratorPrefix = append(iteratorPrefix, tokenTransferIndexPrefix...)
+	iteratorPrefix = append(iteratorPrefix, address.Bytes()...)
+	if token != nil {
+		iteratorPrefix = append(iteratorPrefix, token.Bytes()...)
+	}
+	return
+}
+
+// formatTokenTransferBytesIndex formats the index key, eg. ttx-<addr><token><blockNumber><t|f><txhash><logIndex>
+// Unlike in atx- keys, the block number is big endian, so that the keys of an
+// address and token are ordered by block.
+func formatTokenTransferBytesIndex(address, token, blockNumber, direction, txhash, logIndex []byte) (key []byte) {
+	key = make([]byte, 0, 89) // prefix(4)+addr(20)+token(20)+blockNumber(8)+dir(1)+txhash(32)+logIndex(4)
+	key = append(key, tokenTransferIndexPrefix...)
+	key = append(key, address...)
+	key = append(key, token...)
+	key = append(key, blockNumber...)
+	key = append(key, direction...)
+	key = append(key, txhash...)
+	key = append(key, logIndex...)
+	return
+}
+
+// resolveTokenTransferBytes resolves the index key to individual []byte values
+func resolveTokenTransferBytes(key []byte) (address, token, blockNumber, direction, txhash, logIndex []byte) {
+	address = key[4:24]
+	token = key[24:44]
+	blockNumber = key[44:52] // uint64 via big endian
+	direction = key[52:53]
+	txhash = key[53:85]
+	logIndex = key[85:89] // uint32 via little endian
+	return
+}
+
+// decodeTransferEvent returns the sender, recipient and value of a token
+// Transfer event. The value is the data of ERC20 and ERC223 events, and the
+// indexed token id of ERC721 ones.
+func decodeTransferEvent(log *vm.Log) (from, to common.Address, value *big.Int, ok bool) {
+	if len(log.Topics) < 3 || log.Topics[0] != TransferEventTopic {
+		return
+	}
+	switch {
+	case len(log.Topics) == 3 && len(log.Data) == 32:
+		value = new(big.Int).SetBytes(log.Data)
+	case len(log.Topics) == 4 && len(log.Data) == 0:
+		value = log.Topics[3].Big()
+	default:
+		return
+	}
+	return common.BytesToAddress(log.Topics[1].Bytes()), common.BytesToAddress(log.Topics[2].Bytes()), value, true
+}
+
+// blockTokenTransferKeys calls fn with the index keys and value of every token
+// transfer of a block, given its receipts.
+func blockTokenTransferKeys(block *types.Block, receipts types.Receipts, fn func(key, value []byte) error) (transfers int, err error) {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return 0, fmt.Errorf("block #%d has %d receipts for %d transactions", block.NumberU64(), len(receipts), len(txs))
+	}
+	bn := make([]byte, 8)
+	binary.BigEndian.PutUint64(bn, block.NumberU64())
+
+	var logIndex uint32
+	for i, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			li := make([]byte, 4)
+			binary.LittleEndian.PutUint32(li, logIndex)
+			logIndex++
+
+			from, to, value, ok := decodeTransferEvent(log)
+			if !ok {
+				continue
+			}
+			transfers++
+			v, err := rlp.EncodeToBytes(tokenTransferValue{from, to, value})
+			if err != nil {
+				return transfers, err
+			}
+			hash := txs[i].Hash().Bytes()
+			if err := fn(formatTokenTransferBytesIndex(from.Bytes(), log.Address.Bytes(), bn, []byte("f"), hash, li), v); err != nil {
+				return transfers, err
+			}
+			if err := fn(formatTokenTransferBytesIndex(to.Bytes(), log.Address.Bytes(), bn, []byte("t"), hash, li), v); err != nil {
+				return transfers, err
+			}
+		}
+	}
+	return transfers, nil
+}
+
+// putBlockTokenTransfersToBatch formats and puts the token transfer keys of a
+// block to a db Batch, and returns the number of transfers.
+func putBlockTokenTransfersToBatch(putBatch ethdb.Batch, block *types.Block, receipts types.Receipts) (int, error) {
+	return blockTokenTransferKeys(block, receipts, putBatch.Put)
+}
+
+// WriteBlockTokenTransferIndexes writes the token transfer indexes of a block.
+func WriteBlockTokenTransferIndexes(indexDb ethdb.Database, block *types.Block, receipts types.Receipts) error {
+	batch := indexDb.NewBatch()
+	if _, err := putBlockTokenTransfersToBatch(batch, block, receipts); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// rmBlockTokenTransfers removes the token transfer indexes of a block, eg. when
+// the block leaves the canonical chain in a reorg.
+func rmBlockTokenTransfers(db ethdb.Database, block *types.Block, receipts types.Receipts) error {
+	_, err := blockTokenTransferKeys(block, receipts, func(key, _ []byte) error {
+		return db.Delete(key)
+	})
+	return err
+}
+
+// writeTokenTransferIndexes writes the token transfer indexes of a canonical
+// block if they are enabled, and moves the bookmark of the auto build mode
+// along once the build is done.
+func (bc *BlockChain) writeTokenTransferIndexes(block *types.Block, receipts types.Receipts) error {
+	if bc.atxi == nil || !bc.atxi.Tokens {
+		return nil
+	}
+	if err := WriteBlockTokenTransferIndexes(bc.atxi.Db, block, receipts); err != nil {
+		return err
+	}
+	if progress := bc.atxi.TokenProgress; bc.atxi.AutoMode && progress != nil && progress.Current == progress.Stop {
+		return dbSetTokenTransferBookmark(bc.atxi.Db, block.NumberU64())
+	}
+	return nil
+}
+
+// WriteBlockTokenTransferIndexesBatch builds the token transfer indexes of the
+// blocks in [startBlockN, stopBlockN], writing batches every stepN blocks. It
+// returns the number of transfers indexed.
+func (bc *BlockChain) WriteBlockTokenTransferIndexesBatch(indexDb ethdb.Database, startBlockN, stopBlockN, stepN uint64) (transfers int, err error) {
+	batch := indexDb.NewBatch()
+	for n := startBlockN; n <= stopBlockN; n++ {
+		block := bc.GetBlockByNumber(n)
+		if block == nil {
+			break
+		}
+		count, err := putBlockTokenTransfersToBatch(batch, block, GetBlockReceipts(bc.chainDb, block.Hash()))
+		if err != nil {
+			return transfers, err
+		}
+		transfers += count
+
+		if (n-startBlockN+1)%stepN == 0 {
+			if err := batch.Write(); err != nil {
+				return transfers, err
+			}
+			batch = indexDb.NewBatch()
+		}
+	}
+	return transfers, batch.Write()
+}
+
+// BuildTokenTransferIndex builds the token transfer indexes of the blocks in
+// [startIndex, stopIndex), like BuildAddrTxIndex does for the address
+// transaction indexes. Its progress is reported by GetTokenTransferBuildProgress.
+func BuildTokenTransferIndex(bc *BlockChain, indexDB ethdb.Database, startIndex, stopIndex, step uint64) error {
+	if bc.atxi == nil || !bc.atxi.Tokens {
+		return errors.New("token transfer indexing not enabled for blockchain")
+	}
+	if bc.atxi.TokenProgress == nil {
+		bc.atxi.TokenProgress = &AtxiProgressT{}
+	}
+	progress := bc.atxi.TokenProgress
+
+	// Use persistent placeholder in case start not spec'd
+	if startIndex == math.MaxUint64 {
+		startIndex = dbGetTokenTransferBookmark(indexDB)
+	}
+	if step == math.MaxUint64 {
+		step = 10000
+	}
+	if step == 0 {
+		progress.LastError = errors.New("step must be greater than zero")
+		return progress.LastError
+	}
+	if stopIndex == 0 || stopIndex == math.MaxUint64 {
+		stopIndex = bc.CurrentBlock().NumberU64()
+		if n := bc.CurrentFastBlock().NumberU64(); n > stopIndex {
+			stopIndex = n
+		}
+	}
+	if stopIndex <= startIndex {
+		progress.LastError = fmt.Errorf("start must be prior to (smaller than) or equal to stop, got start=%d stop=%d", startIndex, stopIndex)
+		return progress.LastError
+	}
+	// sigc is a single-val channel for listening to program interrupt
+	var sigc = make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	startTime := time.Now()
+	total := 0
+	glog.D(logger.Error).Infoln("Token transfer indexing start:", startIndex, "stop:", stopIndex, "step:", step)
+	progress.LastError = nil
+	progress.Start, progress.Current, progress.Stop = startIndex, startIndex, stopIndex
+
+	for i := startIndex; i < stopIndex; i += step {
+		if i+step > stopIndex {
+			step = stopIndex - i
+		}
+		stepStartTime := time.Now()
+
+		transfers, err := bc.WriteBlockTokenTransferIndexesBatch(indexDB, i, i+step-1, step)
+		if err != nil {
+			progress.LastError = err
+			return err
+		}
+		total += transfers
+
+		progress.Current = i + step
+		if bc.atxi.AutoMode {
+			if err := dbSetTokenTransferBookmark(indexDB, progress.Current); err != nil {
+				progress.LastError = err
+				return err
+			}
+		}
+		glog.D(logger.Error).Infof("token-transfer-build: block %d / %d transfers: %d took: %v %.2f bps", i+step, stopIndex, transfers, time.Since(stepStartTime).Round(time.Millisecond), float64(step)/time.Since(stepStartTime).Seconds())
+
+		// Listen for interrupts, nonblocking
+		select {
+		case s := <-sigc:
+			glog.D(logger.Info).Warnln("token transfer build", "got interrupt:", s, "quitting")
+			return nil
+		default:
+		}
+	}
+
+	took := time.Since(startTime)
+	glog.D(logger.Error).Infof("Finished token-transfer-build in %v: %d blocks (~ %.2f blocks/sec), %d transfers",
+		took.Round(time.Second), stopIndex-startIndex, float64(stopIndex-startIndex)/took.Seconds(), total)
+	return nil
+}
+
+// GetTokenTransferBuildProgress returns the progress of the token transfer
+// index build.
+func (bc *BlockChain) GetTokenTransferBuildProgress() (*AtxiProgressT, error) {
+	if bc.atxi == nil || !bc.atxi.Tokens {
+		return nil, errors.New("token transfer indexing not enabled")
+	}
+	return bc.atxi.TokenProgress, nil
+}
+
+// GetTokenTransfers gets the indexed token transfers of a given account address,
+// optionally only those of the token contract token. Transfers are sorted newest
+// first, or oldest first if reverse is set, before the pagination applies. A
+// paginationEnd of 0 or less doesn't limit the transfers returned.
+func GetTokenTransfers(db ethdb.Database, address common.Address, token *common.Address, blockStartN uint64, blockEndN uint64, paginationStart int, paginationEnd int, reverse bool) ([]*TokenTransfer, error) {
+	if paginationStart > 0 && paginationEnd > 0 && paginationStart > paginationEnd {
+		return nil, fmt.Errorf("%v: %s", errAtxiInvalidUse, "Pagination start must be less than or equal to pagination end params")
+	}
+	if paginationStart < 0 {
+		paginationStart = 0
+	}
+
+	var (
+		transfers []*TokenTransfer
+		err       error
+	)
+	if token != nil && paginationEnd > 0 {
+		transfers, err = getTokenTransfersPage(db, address, *token, blockStartN, blockEndN, paginationEnd, reverse)
+	} else {
+		transfers, err = getAllTokenTransfers(db, address, token, blockStartN, blockEndN, reverse)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if paginationStart > len(transfers) {
+		paginationStart = len(transfers)
+	}
+	if paginationEnd <= 0 || paginationEnd > len(transfers) {
+		paginationEnd = len(transfers)
+	}
+	return transfers[paginationStart:paginationEnd], nil
+}
+
+// getAllTokenTransfers returns all the token transfers of address in the block
+// range, newest first unless reverse is set.
+func getAllTokenTransfers(db ethdb.Database, address common.Address, token *common.Address, blockStartN, blockEndN uint64, reverse bool) ([]*TokenTransfer, error) {
+	var transfers []*TokenTransfer
+	seen := make(map[string]bool) // transfers to self have both a t and an f key
+
+	it := db.NewIteratorWithPrefix(formatTokenTransferIterator(address, token))
+	for it.Next() {
+		key := it.Key()
+		if len(key) != 89 {
+			continue
+		}
+		_, _, blockNum, _, txh, li := resolveTokenTransferBytes(key)
+
+		bn := binary.BigEndian.Uint64(blockNum)
+		if blockStartN > 0 && bn < blockStartN {
+			continue
+		}
+		if blockEndN > 0 && bn > blockEndN {
+			continue
+		}
+		id := string(append(common.CopyBytes(txh), li...))
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		transfer, err := decodeTokenTransfer(key, it.Value())
+		if err != nil {
+			it.Release()
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	// Newest transfers first, in reverse order of emission within a block
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber > transfers[j].BlockNumber
+		}
+		return transfers[i].LogIndex > transfers[j].LogIndex
+	})
+	if reverse {
+		for i, j := 0, len(transfers)-1; i < j; i, j = i+1, j-1 {
+			transfers[i], transfers[j] = transfers[j], transfers[i]
+		}
+	}
+	return transfers, nil
+}
+
+// getTokenTransfersPage returns the first limit token transfers of address and
+// token in the block range, newest first unless reverse is set. As the keys of
+// a token are ordered by block, it only iterates the block range, and holds no
+// more than limit transfers besides those of the block being read.
+func getTokenTransfersPage(db ethdb.Database, address, token common.Address, blockStartN, blockEndN uint64, limit int, reverse bool) ([]*TokenTransfer, error) {
+	prefix := formatTokenTransferIterator(address, &token)
+	bn := make([]byte, 8)
+	binary.BigEndian.PutUint64(bn, blockStartN)
+	start := append(common.CopyBytes(prefix), bn...)
+
+	var end []byte
+	if blockEndN > 0 && blockEndN < math.MaxUint64 {
+		binary.BigEndian.PutUint64(bn, blockEndN+1)
+		end = append(common.CopyBytes(prefix), bn...)
+	}
+
+	var (
+		transfers []*TokenTransfer // oldest first
+		block     []*TokenTransfer // transfers of the block being read
+		seen      = make(map[string]bool)
+	)
+	// flush moves the transfers of a block over, and reports whether enough
+	// transfers were found
+	flush := func() bool {
+		sort.Slice(block, func(i, j int) bool { return block[i].LogIndex < block[j].LogIndex })
+		transfers = append(transfers, block...)
+		block, seen = block[:0], make(map[string]bool)
+		if len(transfers) < limit {
+			return false
+		}
+		if reverse {
+			transfers = transfers[:limit]
+			return true
+		}
+		transfers = transfers[len(transfers)-limit:]
+		return false
+	}
+
+	it := db.NewIteratorWithRange(start, end)
+	for it.Next() {
+		key := it.Key()
+		if !bytes.HasPrefix(key, prefix) {
+			break
+		}
+		if len(key) != 89 {
+			continue
+		}
+		_, _, _, _, txh, li := resolveTokenTransferBytes(key)
+		id := string(append(common.CopyBytes(txh), li...))
+		if seen[id] {
+			continue
+		}
+		transfer, err := decodeTokenTransfer(key, it.Value())
+		if err != nil {
+			it.Release()
+			return nil, err
+		}
+		if len(block) > 0 && block[0].BlockNumber != transfer.BlockNumber && flush() {
+			break
+		}
+		seen[id] = true
+		block = append(block, transfer)
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if len(block) > 0 {
+		flush()
+	}
+	if !reverse {
+		for i, j := 0, len(transfers)-1; i < j; i, j = i+1, j-1 {
+			transfers[i], transfers[j] = transfers[j], transfers[i]
+		}
+	}
+	return transfers, nil
+}
+
+// decodeTokenTransfer decodes a token transfer index key and its value.
+func decodeTokenTransfer(key, value []byte) (*TokenTransfer, error) {
+	var v tokenTransferValue
+	if err := rlp.DecodeBytes(value, &v); err != nil {
+		return nil, err
+	}
+	_, tok, blockNum, _, txh, li := resolveTokenTransferBytes(key)
+	return &TokenTransfer{
+		Token:       common.BytesToAddress(tok),
+		From:        v.From,
+		To:          v.To,
+		Value:       (*hexutil.Big)(v.Value),
+		BlockNumber: hexutil.Uint64(binary.BigEndian.Uint64(blockNum)),
+		TxHash:      common.BytesToHash(txh),
+		LogIndex:    hexutil.Uint(binary.LittleEndian.Uint32(li)),
+	}, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestTokenTransferIndex(t *testing.T) {
+	var (
+		erc20   = common.Address{0x20}
+		erc721  = common.Address{0x72}
+		a, b, c = common.Address{0xa}, common.Address{0xb}, common.Address{0xc}
+	)
+	topic := func(addr common.Address) common.Hash { return common.BytesToHash(addr.Bytes()) }
+	amount := common.BigToHash(big.NewInt(1000)).Bytes()
+
+	txs := []*types.Transaction{
+		types.NewTransaction(0, erc20, new(big.Int), big.NewInt(100000), new(big.Int), nil),
+		types.NewTransaction(1, erc721, new(big.Int), big.NewInt(100000), new(big.Int), nil),
+	}
+	receipts := types.Receipts{
+		types.NewReceipt(nil, new(big.Int)),
+		types.NewReceipt(nil, new(big.Int)),
+	}
+	receipts[0].Logs = vm.Logs{
+		{Address: erc20, Topics: []common.Hash{TransferEventTopic, topic(a), topic(b)}, Data: amount},
+		{Address: erc20, Topics: []common.Hash{{0x01}, topic(a), topic(b)}, Data: amount}, // another event
+		{Address: erc20, Topics: []common.Hash{TransferEventTopic, topic(b), topic(b)}, Data: amount},
+	}
+	receipts[1].Logs = vm.Logs{
+		{Address: erc721, Topics: []common.Hash{TransferEventTopic, topic(a), topic(c), common.BigToHash(big.NewInt(7))}},
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(5)}, txs, nil, nil)
+
+	db, _ := ethdb.NewMemDatabase()
+	if err := WriteBlockTokenTransferIndexes(db, block, receipts); err != nil {
+		t.Fatalf("failed to write token transfer indexes: %v", err)
+	}
+
+	transfers, err := GetTokenTransfers(db, a, nil, 0, 0, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 2 {
+		t.Fatalf("transfers of a: have %d, want 2", len(transfers))
+	}
+	// Newest first: the ERC721 transfer is the last log of the block
+	if tr := transfers[0]; tr.Token != erc721 || tr.From != a || tr.To != c || tr.Value.ToInt().Int64() != 7 || tr.LogIndex != 3 || tr.TxHash != txs[1].Hash() {
+		t.Errorf("ERC721 transfer mismatch: %+v", tr)
+	}
+	if tr := transfers[1]; tr.Token != erc20 || tr.To != b || tr.Value.ToInt().Int64() != 1000 || tr.LogIndex != 0 || uint64(tr.BlockNumber) != 5 {
+		t.Errorf("ERC20 transfer mismatch: %+v", tr)
+	}
+	// Filter by token, reverse and paginate
+	if transfers, _ := GetTokenTransfers(db, a, &erc20, 0, 0, 0, 0, false); len(transfers) != 1 || transfers[0].Token != erc20 {
+		t.Errorf("token filter mismatch: %v", transfers)
+	}
+	if transfers, _ := GetTokenTransfers(db, a, nil, 0, 0, 0, 1, true); len(transfers) != 1 || transfers[0].Token != erc20 {
+		t.Errorf("reverse pagination mismatch: %v", transfers)
+	}
+	if transfers, _ := GetTokenTransfers(db, a, nil, 6, 0, 0, 0, false); len(transfers) != 0 {
+		t.Errorf("block range mismatch: %v", transfers)
+	}
+	// A transfer to self is listed once
+	if transfers, _ := GetTokenTransfers(db, b, nil, 0, 0, 0, 0, false); len(transfers) != 2 {
+		t.Errorf("transfers of b: have %d, want 2", len(transfers))
+	}
+
+	if err := rmBlockTokenTransfers(db, block, receipts); err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []common.Address{a, b, c} {
+		if transfers, _ := GetTokenTransfers(db, addr, nil, 0, 0, 0, 0, false); len(transfers) != 0 {
+			t.Errorf("%x: transfers left after removal: %v", addr, transfers)
+		}
+	}
+}
+
+// Tests that the transfers of a token are paged the same way as all transfers
+// are, across block numbers whose little endian encodings are out of order.
+func TestTokenTransferPages(t *testing.T) {
+	var (
+		token = common.Address{0x20}
+		a, b  = common.Address{0xa}, common.Address{0xb}
+	)
+	topic := func(addr common.Address) common.Hash { return common.BytesToHash(addr.Bytes()) }
+	amount := common.BigToHash(big.NewInt(1000)).Bytes()
+
+	db, _ := ethdb.NewMemDatabase()
+	for n := int64(1); n <= 300; n += 7 {
+		tx := types.NewTransaction(uint64(n), token, new(big.Int), big.NewInt(100000), new(big.Int), nil)
+		receipt := types.NewReceipt(nil, new(big.Int))
+		receipt.Logs = vm.Logs{
+			{Address: token, Topics: []common.Hash{TransferEventTopic, topic(a), topic(b)}, Data: amount},
+			{Address: token, Topics: []common.Hash{TransferEventTopic, topic(b), topic(a)}, Data: amount},
+		}
+		block := types.NewBlock(&types.Header{Number: big.NewInt(n)}, []*types.Transaction{tx}, nil, nil)
+		if err := WriteBlockTokenTransferIndexes(db, block, types.Receipts{receipt}); err != nil {
+			t.Fatalf("block %d: failed to write token transfer indexes: %v", n, err)
+		}
+	}
+	for _, reverse := range []bool{false, true} {
+		for _, r := range []struct{ start, end uint64 }{{0, 0}, {100, 0}, {0, 200}, {36, 36}} {
+			for _, p := range []struct{ start, end int }{{0, 1}, {0, 3}, {2, 9}, {5, 1000}} {
+				want, err := GetTokenTransfers(db, a, nil, r.start, r.end, p.start, p.end, reverse)
+				if err != nil {
+					t.Fatal(err)
+				}
+				have, err := GetTokenTransfers(db, a, &token, r.start, r.end, p.start, p.end, reverse)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(have) != len(want) {
+					t.Fatalf("reverse %v, blocks %v, page %v: have %d transfers, want %d", reverse, r, p, len(have), len(want))
+				}
+				for i := range want {
+					if have[i].BlockNumber != want[i].BlockNumber || have[i].LogIndex != want[i].LogIndex {
+						t.Errorf("reverse %v, blocks %v, page %v: transfer %d mismatch: have #%d/%d, want #%d/%d", reverse, r, p, i, have[i].BlockNumber, have[i].LogIndex, want[i].BlockNumber, want[i].LogIndex)
+					}
+				}
+			}
+		}
+	}
+	if transfers, _ := GetTokenTransfers(db, a, &token, 0, 0, 0, 3, false); len(transfers) != 3 || transfers[0].BlockNumber != 295 || transfers[0].LogIndex != 1 {
+		t.Errorf("newest transfers mismatch: %+v", transfers)
+	}
+}
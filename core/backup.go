@@ -0,0 +1,225 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+// BackupManifestFile is the name of the manifest written into a backup once
+// all of its data is in place.
+const BackupManifestFile = "manifest.json"
+
+// BackupManifest describes a backup of the chain database. A backup directory
+// has the layout of the chain data directory of a node: restoring it is
+// copying its contents over an empty one.
+type BackupManifest struct {
+	Time       time.Time         `json:"time"`
+	HeadNumber uint64            `json:"headNumber"` // head block of the copied chain database
+	HeadHash   common.Hash       `json:"headHash"`
+	StateRoot  common.Hash       `json:"stateRoot"`
+	Keys       map[string]uint64 `json:"keys"`    // number of keys copied per database
+	Ancient    uint64            `json:"ancient"` // number of blocks copied from the ancient block freezer
+}
+
+// Backup writes a consistent copy of the chain database, and of the index
+// database if it isn't nil, into the directory dir, which must not exist or
+// be empty. The databases may be in use; every one of them is copied from a
+// LevelDB snapshot. The blocks of the ancient block freezer which the
+// snapshot relies on are copied too. progress, if not nil, is called with the
+// name of the database being copied and the number of keys copied so far,
+// every 1M keys and once the database is done.
+func Backup(dir string, chainDb, indexDb ethdb.Database, progress func(name string, keys uint64)) (*BackupManifest, error) {
+	return backup(dir, chainDb, indexDb, nil, progress)
+}
+
+// Backup backs up the chain database of bc like the Backup function. The head
+// state of a pruning chain is flushed first, and no block is inserted until
+// the snapshots are taken, so that the copy holds the state of its head block.
+func (bc *BlockChain) Backup(dir string, indexDb ethdb.Database, progress func(name string, keys uint64)) (*BackupManifest, error) {
+	hold := func() func() {
+		bc.chainmu.Lock()
+		bc.FlushState()
+		return bc.chainmu.Unlock
+	}
+	return backup(dir, bc.chainDb, indexDb, hold, progress)
+}
+
+// backup implements Backup. If hold isn't nil, the snapshots are taken between
+// a call to hold and a call to the function it returns.
+func backup(dir string, chainDb, indexDb ethdb.Database, hold func() func(), progress func(name string, keys uint64)) (*BackupManifest, error) {
+	if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("backup directory %s is not empty", dir)
+	}
+	// Take the snapshots first, so that they are as close in time as possible
+	names := []string{"chaindata"}
+	dbs := []ethdb.Database{chainDb}
+	if indexDb != nil {
+		names, dbs = append(names, "indexes"), append(dbs, indexDb)
+	}
+	var release func()
+	if hold != nil {
+		release = hold()
+	}
+	snaps, err := snapshotDatabases(dbs)
+	if release != nil {
+		release()
+	}
+	defer func() {
+		for _, snap := range snaps {
+			snap.Release()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+	manifest := &BackupManifest{
+		Time: time.Now().UTC(),
+		Keys: make(map[string]uint64),
+	}
+	// The snapshot needs the blocks frozen before it was taken; later ones are
+	// still in it
+	var freezer *Freezer
+	if fdb, ok := chainDb.(*FreezerDatabase); ok {
+		freezer = fdb.Freezer()
+		manifest.Ancient = freezer.Frozen()
+	}
+	for i, snap := range snaps {
+		keys, err := backupSnapshot(filepath.Join(dir, names[i]), snap, func(keys uint64) {
+			if progress != nil {
+				progress(names[i], keys)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		manifest.Keys[names[i]] = keys
+	}
+	if freezer != nil && manifest.Ancient > 0 {
+		if err := backupFreezer(filepath.Join(dir, "ancient"), freezer, manifest.Ancient); err != nil {
+			return nil, err
+		}
+	}
+	// Describe the head of the copy and mark the backup complete
+	backupDb, err := ethdb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	manifest.HeadHash = GetHeadBlockHash(backupDb)
+	if header := GetHeader(backupDb, manifest.HeadHash); header != nil {
+		manifest.HeadNumber = header.Number.Uint64()
+		manifest.StateRoot = header.Root
+	}
+	backupDb.Close()
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, BackupManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// snapshotDatabases takes a LevelDB snapshot of every one of dbs. On failure
+// the snapshots taken so far are returned along with the error.
+func snapshotDatabases(dbs []ethdb.Database) ([]*leveldb.Snapshot, error) {
+	var snaps []*leveldb.Snapshot
+	for _, db := range dbs {
+		ldb, err := DatabaseLDB(db)
+		if err != nil {
+			return snaps, err
+		}
+		snap, err := ldb.GetSnapshot()
+		if err != nil {
+			return snaps, err
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// backupSnapshot copies all keys of snap into a new LevelDB database at path,
+// and returns the number of keys copied.
+func backupSnapshot(path string, snap *leveldb.Snapshot, progress func(keys uint64)) (uint64, error) {
+	db, err := ethdb.NewLDBDatabase(path, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	it := snap.NewIterator(nil, nil)
+	defer it.Release()
+
+	var keys uint64
+	batch := db.NewBatch()
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return keys, err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return keys, err
+			}
+			batch = db.NewBatch()
+		}
+		if keys++; keys%(1024*1024) == 0 {
+			progress(keys)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return keys, err
+	}
+	if err := batch.Write(); err != nil {
+		return keys, err
+	}
+	progress(keys)
+	return keys, nil
+}
+
+// backupFreezer copies the first blocks of freezer into a new freezer in dir.
+func backupFreezer(dir string, freezer *Freezer, blocks uint64) error {
+	backup, err := NewFreezer(dir)
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	for number := uint64(0); number < blocks; number++ {
+		items := make(map[string][]byte, len(freezerTables))
+		for _, kind := range freezerTables {
+			item, err := freezer.Ancient(kind, number)
+			if err != nil {
+				return fmt.Errorf("ancient block #%d: %v", number, err)
+			}
+			items[kind] = item
+		}
+		if err := backup.appendBlock(number, items); err != nil {
+			return err
+		}
+	}
+	return backup.sync()
+}
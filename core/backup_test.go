@@ -0,0 +1,151 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+func TestBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ldb, err := ethdb.NewLDBDatabase(filepath.Join(dir, "node", "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := writeFreezerTestChain(t, ldb, 10)
+	db, err := NewFreezerDatabase(ldb, filepath.Join(dir, "node", "ancient"), 0)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Freeze(6, nil, nil); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	index, err := ethdb.NewLDBDatabase(filepath.Join(dir, "node", "indexes"), 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	index.Put([]byte("index-key"), []byte("value"))
+
+	backupDir := filepath.Join(dir, "backup")
+	manifest, err := Backup(backupDir, db, index, nil)
+	if err != nil {
+		t.Fatalf("failed to back up: %v", err)
+	}
+	head := blocks[len(blocks)-1]
+	if manifest.HeadNumber != 9 || manifest.HeadHash != head.Hash() || manifest.StateRoot != head.Root() {
+		t.Errorf("manifest head mismatch: have #%d [%x], want #9 [%x]", manifest.HeadNumber, manifest.HeadHash, head.Hash())
+	}
+	if manifest.Ancient != 6 || manifest.Keys["indexes"] != 1 || manifest.Keys["chaindata"] == 0 {
+		t.Errorf("manifest counts mismatch: ancient %d, keys %v", manifest.Ancient, manifest.Keys)
+	}
+	// The manifest is written last and must match the returned one
+	data, err := ioutil.ReadFile(filepath.Join(backupDir, BackupManifestFile))
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	var written BackupManifest
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if written.HeadHash != manifest.HeadHash || written.Ancient != manifest.Ancient {
+		t.Errorf("written manifest mismatch: have %+v, want %+v", written, manifest)
+	}
+	// The copy must hold the whole chain, frozen blocks included
+	copied, err := ethdb.NewLDBDatabase(filepath.Join(backupDir, "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cdb, err := NewFreezerDatabase(copied, filepath.Join(backupDir, "ancient"), 0)
+	if err != nil {
+		t.Fatalf("failed to open copied freezer: %v", err)
+	}
+	checkFreezerTestChain(t, cdb, blocks)
+	cdb.Close()
+
+	// Backing up over an existing backup must fail
+	if _, err := Backup(backupDir, db, nil, nil); err == nil {
+		t.Errorf("backup into a non-empty directory succeeded")
+	}
+}
+
+// Tests that backing up a pruning chain copies the state of its head block,
+// which is only kept in memory until it is flushed.
+func TestBlockChainBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	genesis := WriteGenesisBlockForTesting(db)
+
+	gendb, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(gendb)
+	blocks, _ := GenerateChain(DefaultConfigMorden.ChainConfig, genesis, gendb, 5, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	bc, err := NewBlockChainWithStateGC(db, DefaultConfigMorden.ChainConfig, FakePow{}, new(event.TypeMux), MinStateGCDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop()
+	if res := bc.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert block %d: %v", res.Index, res.Error)
+	}
+	head := blocks[len(blocks)-1]
+	if ok, _ := db.Has(head.Root().Bytes()); ok {
+		t.Fatalf("head state on disk before backup")
+	}
+
+	backupDir := filepath.Join(dir, "backup")
+	manifest, err := bc.Backup(backupDir, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to back up: %v", err)
+	}
+	if manifest.HeadHash != head.Hash() || manifest.StateRoot != head.Root() {
+		t.Errorf("manifest head mismatch: have #%d [%x], want #%d [%x]", manifest.HeadNumber, manifest.HeadHash, head.NumberU64(), head.Hash())
+	}
+	copied, err := ethdb.NewLDBDatabase(filepath.Join(backupDir, "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copied.Close()
+	if _, err := state.New(head.Root(), state.NewDatabase(copied)); err != nil {
+		t.Errorf("head state not in backup: %v", err)
+	}
+}
@@ -41,13 +41,13 @@ func testChainConfig() *ChainConfig {
 					{
 						ID: "gastable",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "eip160",
 						},
 					},
 					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "defused",
 						},
 					},
 				},
@@ -62,17 +62,16 @@ func testChainConfig() *ChainConfig {
 							"chainID": 62,
 						},
 					},
-					{ // ecip1010 bomb delay
+					{
 						ID: "gastable",
 						Options: ChainFeatureConfigOptions{
 							"type": "eip160",
 						},
 					},
-					{ // ecip1010 bomb delay
+					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type":   "ecip1010",
-							"length": 2000000,
+							"type": "defused",
 						},
 					},
 				},
@@ -91,7 +90,7 @@ func proc(t testing.TB) (Validator, *BlockChain) {
 		t.Fatal(err)
 	}
 
-	pow, err := cryptonight.NewForTesting()
+	pow, err := cryptonight.NewForTesting(DefaultConfigMorden.ChainConfig.GetLYRA2Block(), DefaultConfigMorden.ChainConfig.GetLYRA2v2Block())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,30 +155,32 @@ func TestPutReceipt(t *testing.T) {
 	}
 }
 
+// Webchain has no difficulty bomb, so the bomb tests check that the difficulty
+// only follows the block time, whatever the block number.
 func TestDifficultyBombFreeze(t *testing.T) {
-	diehardBlock := big.NewInt(3000000)
+	num := big.NewInt(3000000)
 	var parentTime uint64
 
 	// 20 seconds, parent diff 7654414978364
 	parentTime = 1452838500
-	act := calcDifficultyDiehard(parentTime+20, parentTime, big.NewInt(7654414978364), diehardBlock)
-	exp := big.NewInt(7650945906507)
+	act := calcDifficultyDefused(parentTime+20, parentTime, num, big.NewInt(7654414978364))
+	exp := big.NewInt(7616142903473)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
 	}
 
 	// 5 seconds, parent diff 7654414978364
-	act = calcDifficultyDiehard(parentTime+5, parentTime, big.NewInt(7654414978364), diehardBlock)
-	exp = big.NewInt(7658420921133)
+	act = calcDifficultyDefused(parentTime+5, parentTime, num, big.NewInt(7654414978364))
+	exp = big.NewInt(7692687053255)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
 	}
 
 	// 80 seconds, parent diff 7654414978364
-	act = calcDifficultyDiehard(parentTime+80, parentTime, big.NewInt(7654414978364), diehardBlock)
-	exp = big.NewInt(7628520862629)
+	act = calcDifficultyDefused(parentTime+80, parentTime, num, big.NewInt(7654414978364))
+	exp = big.NewInt(7386510454127)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -187,8 +188,8 @@ func TestDifficultyBombFreeze(t *testing.T) {
 
 	// 76 seconds, parent diff 12657404717367
 	parentTime = 1469081721
-	act = calcDifficultyDiehard(parentTime+76, parentTime, big.NewInt(12657404717367), diehardBlock)
-	exp = big.NewInt(12620590912441)
+	act = calcDifficultyDefused(parentTime+76, parentTime, num, big.NewInt(12657404717367))
+	exp = big.NewInt(12277682575851)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -196,8 +197,8 @@ func TestDifficultyBombFreeze(t *testing.T) {
 
 	// 1 second, parent diff 12620590912441
 	parentTime = 1469164521
-	act = calcDifficultyDiehard(parentTime+1, parentTime, big.NewInt(12620590912441), diehardBlock)
-	exp = big.NewInt(12627021745803)
+	act = calcDifficultyDefused(parentTime+1, parentTime, num, big.NewInt(12620590912441))
+	exp = big.NewInt(12683693867003)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -205,8 +206,8 @@ func TestDifficultyBombFreeze(t *testing.T) {
 
 	// 10 seconds, parent diff 12627021745803
 	parentTime = 1469164522
-	act = calcDifficultyDiehard(parentTime+10, parentTime, big.NewInt(12627021745803), diehardBlock)
-	exp = big.NewInt(12627290181259)
+	act = calcDifficultyDefused(parentTime+10, parentTime, num, big.NewInt(12627021745803))
+	exp = big.NewInt(12627021745803)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -215,10 +216,10 @@ func TestDifficultyBombFreeze(t *testing.T) {
 }
 
 func TestDifficultyBombFreezeTestnet(t *testing.T) {
-	diehardBlock := big.NewInt(1915000)
+	num := big.NewInt(1915000)
 
-	act := calcDifficultyDiehard(1481895639, 1481850947, big.NewInt(28670444), diehardBlock)
-	exp := big.NewInt(27415615)
+	act := calcDifficultyDefused(1481895639, 1481850947, num, big.NewInt(28670444))
+	exp := big.NewInt(25803404)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -226,16 +227,13 @@ func TestDifficultyBombFreezeTestnet(t *testing.T) {
 }
 
 func TestDifficultyBombExplode(t *testing.T) {
-	diehardBlock := big.NewInt(3000000)
-	explosionBlock := big.NewInt(5000000)
-
 	var parentTime uint64
 
 	// 6 seconds, blocks in 5m
 	num := big.NewInt(5000102)
 	parentTime = 1513175023
-	act := calcDifficultyExplosion(parentTime+6, parentTime, num, big.NewInt(22627021745803), diehardBlock, explosionBlock)
-	exp := big.NewInt(22638338531720)
+	act := calcDifficultyDefused(parentTime+6, parentTime, num, big.NewInt(22627021745803))
+	exp := big.NewInt(22740156854532)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -243,8 +241,8 @@ func TestDifficultyBombExplode(t *testing.T) {
 
 	num = big.NewInt(5001105)
 	parentTime = 1513189406
-	act = calcDifficultyExplosion(parentTime+29, parentTime, num, big.NewInt(22727021745803), diehardBlock, explosionBlock)
-	exp = big.NewInt(22716193002673)
+	act = calcDifficultyDefused(parentTime+29, parentTime, num, big.NewInt(22727021745803))
+	exp = big.NewInt(22613386637074)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -252,8 +250,8 @@ func TestDifficultyBombExplode(t *testing.T) {
 
 	num = big.NewInt(5100123)
 	parentTime = 1514609324
-	act = calcDifficultyExplosion(parentTime+41, parentTime, num, big.NewInt(22893437765583), diehardBlock, explosionBlock)
-	exp = big.NewInt(22860439327271)
+	act = calcDifficultyDefused(parentTime+41, parentTime, num, big.NewInt(22893437765583))
+	exp = big.NewInt(22550036199102)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -261,8 +259,8 @@ func TestDifficultyBombExplode(t *testing.T) {
 
 	num = big.NewInt(6150001)
 	parentTime = 1529664575
-	act = calcDifficultyExplosion(parentTime+105, parentTime, num, big.NewInt(53134780363303), diehardBlock, explosionBlock)
-	exp = big.NewInt(53451033724425)
+	act = calcDifficultyDefused(parentTime+105, parentTime, num, big.NewInt(53134780363303))
+	exp = big.NewInt(50743715246959)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -270,8 +268,8 @@ func TestDifficultyBombExplode(t *testing.T) {
 
 	num = big.NewInt(6550001)
 	parentTime = 1535431724
-	act = calcDifficultyExplosion(parentTime+60, parentTime, num, big.NewInt(82893437765583), diehardBlock, explosionBlock)
-	exp = big.NewInt(91487154230751)
+	act = calcDifficultyDefused(parentTime+60, parentTime, num, big.NewInt(82893437765583))
+	exp = big.NewInt(80821101821448)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -279,8 +277,8 @@ func TestDifficultyBombExplode(t *testing.T) {
 
 	num = big.NewInt(7000000)
 	parentTime = 1535431724
-	act = calcDifficultyExplosion(parentTime+180, parentTime, num, big.NewInt(304334879167015), diehardBlock, explosionBlock)
-	exp = big.NewInt(583283638618965)
+	act = calcDifficultyDefused(parentTime+180, parentTime, num, big.NewInt(304334879167015))
+	exp = big.NewInt(278466414437820)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -288,8 +286,8 @@ func TestDifficultyBombExplode(t *testing.T) {
 
 	num = big.NewInt(8000000)
 	parentTime = 1535431724
-	act = calcDifficultyExplosion(parentTime+420, parentTime, num, big.NewInt(78825323605416810), diehardBlock, explosionBlock)
-	exp = big.NewInt(365477653727918567)
+	act = calcDifficultyDefused(parentTime+420, parentTime, num, big.NewInt(78825323605416810))
+	exp = big.NewInt(70942791244875130)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -297,9 +295,9 @@ func TestDifficultyBombExplode(t *testing.T) {
 
 	num = big.NewInt(9000000)
 	parentTime = 1535431724
-	act = calcDifficultyExplosion(parentTime+2040, parentTime, num, big.NewInt(288253236054168103), diehardBlock, explosionBlock)
+	act = calcDifficultyDefused(parentTime+2040, parentTime, num, big.NewInt(288253236054168103))
 	exp = big.NewInt(0)
-	exp.SetString("295422224299015703633", 10)
+	exp.SetString("259427912448751303", 10)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -307,13 +305,10 @@ func TestDifficultyBombExplode(t *testing.T) {
 }
 
 func TestDifficultyBombExplodeTestnet(t *testing.T) {
-	diehardBlock := big.NewInt(1915000)
-	explosionBlock := big.NewInt(3415000)
-
 	var parentTime uint64
 	parentTime = 1513175023
-	act := calcDifficultyExplosion(parentTime+20, parentTime, big.NewInt(5200000), big.NewInt(28670444), diehardBlock, explosionBlock)
-	exp := big.NewInt(34388394813)
+	act := calcDifficultyDefused(parentTime+20, parentTime, big.NewInt(5200000), big.NewInt(28670444))
+	exp := big.NewInt(28527092)
 	if exp.Cmp(act) != 0 {
 		t.Errorf("Expected to have %d difficulty, got %d (difference: %d)",
 			exp, act, big.NewInt(0).Sub(act, exp))
@@ -333,64 +328,40 @@ func TestCalcDifficulty1Mainnet(t *testing.T) {
 		t.Error("missing Diehard fork block")
 	}
 
-	feat, dhFork, configured := config.GetFeature(dhB, "difficulty")
-	if !configured {
-		t.Errorf("difficulty not configured for diehard block: %v", dhB)
-	}
-	if val, ok := feat.GetString("type"); !ok || val != "ecip1010" {
-		t.Errorf("ecip1010 not configured as difficulty for diehard block: %v", dhB)
-	}
-	delay, ok := feat.GetBigInt("length")
-	if !ok {
-		t.Error("ecip1010 bomb delay length not configured")
-	}
-
-	// Paradise
-	defuseBlock := config.ForkByName("Defuse Difficulty Bomb").Block
-	if defuseBlock == nil {
-		t.Error("missing Defuse Difficulty Bomb fork block")
-	}
-
-	defuseFeat, _, defuseConfigured := config.GetFeature(defuseBlock, "difficulty")
-	if !defuseConfigured {
-		t.Errorf("difficulty not configured for Defuse Difficulty Bomb block: %v", dhB)
-	}
-	if val, ok := defuseFeat.GetString("type"); !ok || val != "defused" {
-		t.Errorf("diffusion not configured as difficulty for Defuse Difficulty Bomb block: %v", dhB)
+	// The difficulty is defused from the start, whether or not it is configured.
+	if feat, _, configured := config.GetFeature(dhB, "difficulty"); configured {
+		if val, ok := feat.GetString("type"); !ok || val != "defused" {
+			t.Errorf("unexpected difficulty configured for diehard block: %v", dhB)
+		}
 	}
 
-	explosionBlock := big.NewInt(0).Add(dhB, delay)
-
 	//parentBlocks to compare with expected equality
 	table := map[*big.Int]*big.Int{
 
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-2)): calcDifficultyFrontier(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-2)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-1)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-1)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(0)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(0)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(1)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(1)), parentDiff),
-
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-2)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-2)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-1)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-1)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(0)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(0)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(1)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(1)), parentDiff),
 
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-2)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-2)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-1)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-1)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(0)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(0)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(1)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(1)), parentDiff),
 
-		big.NewInt(0).Add(dhB, big.NewInt(-1)): calcDifficultyDiehard(time, parentTime, parentDiff, dhFork.Block), // 2999999
-		big.NewInt(0).Add(dhB, big.NewInt(0)):  calcDifficultyDiehard(time, parentTime, parentDiff, dhFork.Block), // 3000000
-		big.NewInt(0).Add(dhB, big.NewInt(1)):  calcDifficultyDiehard(time, parentTime, parentDiff, dhFork.Block), // 3000001
-		big.NewInt(-2).Add(dhB, delay):         calcDifficultyDiehard(time, parentTime, parentDiff, dhFork.Block), // 4999998
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(1)), parentDiff),
 
-		big.NewInt(-1).Add(dhB, delay): calcDifficultyExplosion(time, parentTime, big.NewInt(-1).Add(dhB, delay), parentDiff, dhFork.Block, explosionBlock), // 4999999
-		big.NewInt(0).Add(dhB, delay):  calcDifficultyExplosion(time, parentTime, big.NewInt(0).Add(dhB, delay), parentDiff, dhFork.Block, explosionBlock),  // 5000000
-		big.NewInt(1).Add(dhB, delay):  calcDifficultyExplosion(time, parentTime, big.NewInt(1).Add(dhB, delay), parentDiff, dhFork.Block, explosionBlock),  // 5000001
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(1)), parentDiff),
 
-		new(big.Int).Add(defuseBlock, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, new(big.Int).Add(defuseBlock, big.NewInt(-1)), parentDiff),
-		new(big.Int).Add(defuseBlock, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, new(big.Int).Add(defuseBlock, big.NewInt(0)), parentDiff),
-		new(big.Int).Add(defuseBlock, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, new(big.Int).Add(defuseBlock, big.NewInt(1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(1)), parentDiff),
 
 		big.NewInt(10000000): calcDifficultyDefused(time, parentTime, big.NewInt(10000000), parentDiff),
 	}
@@ -405,7 +376,7 @@ func TestCalcDifficulty1Mainnet(t *testing.T) {
 
 // Compare expected difficulties on edges of forks.
 func TestCalcDifficulty1Morden(t *testing.T) {
-	config := DefaultConfigMainnet.ChainConfig
+	config := DefaultConfigMorden.ChainConfig
 
 	parentTime := uint64(1513175023)
 	time := parentTime + 20
@@ -416,53 +387,40 @@ func TestCalcDifficulty1Morden(t *testing.T) {
 		t.Error("missing Diehard fork block")
 	}
 
-	feat, dhFork, configured := config.GetFeature(dhB, "difficulty")
-	if !configured {
-		t.Errorf("difficulty not configured for diehard block: %v", dhB)
-	}
-	if val, ok := feat.GetString("type"); !ok || val != "ecip1010" {
-		t.Errorf("ecip1010 not configured as difficulty for diehard block: %v", dhB)
-	}
-
-	// Paradise
-	defuseBlock := config.ForkByName("Defuse Difficulty Bomb").Block
-	if defuseBlock == nil {
-		t.Error("missing Defuse Difficulty Bomb fork block")
-	}
-
-	defuseFeat, _, defuseConfigured := config.GetFeature(defuseBlock, "difficulty")
-	if !defuseConfigured {
-		t.Errorf("difficulty not configured for Defuse Difficulty Bomb block: %v", dhB)
-	}
-	if val, ok := defuseFeat.GetString("type"); !ok || val != "defused" {
-		t.Errorf("diffusion not configured as difficulty for Defuse Difficulty Bomb block: %v", dhB)
+	// The difficulty is defused from the start, whether or not it is configured.
+	if feat, _, configured := config.GetFeature(dhB, "difficulty"); configured {
+		if val, ok := feat.GetString("type"); !ok || val != "defused" {
+			t.Errorf("unexpected difficulty configured for diehard block: %v", dhB)
+		}
 	}
 
 	//parentBlocks to compare with expected equality
 	table := map[*big.Int]*big.Int{
 
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-2)): calcDifficultyFrontier(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-2)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-1)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(-1)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(0)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(0)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(1)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("Homestead").Block, big.NewInt(1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Diehard").Block, big.NewInt(1)), parentDiff),
 
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-2)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-2)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-1)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(-1)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(0)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(0)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(1)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("The DAO Hard Fork").Block, big.NewInt(1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork1").Block, big.NewInt(1)), parentDiff),
 
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-2)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-2)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-1)): calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(-1)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(0)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(0)), parentDiff),
-		big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(1)):  calcDifficultyHomestead(time, parentTime, big.NewInt(0).Add(config.ForkByName("GasReprice").Block, big.NewInt(1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2").Block, big.NewInt(1)), parentDiff),
 
-		big.NewInt(0).Add(dhB, big.NewInt(-1)): calcDifficultyDiehard(time, parentTime, parentDiff, dhFork.Block), // 2999999
-		big.NewInt(0).Add(dhB, big.NewInt(0)):  calcDifficultyDiehard(time, parentTime, parentDiff, dhFork.Block), // 3000000
-		big.NewInt(0).Add(dhB, big.NewInt(1)):  calcDifficultyDiehard(time, parentTime, parentDiff, dhFork.Block), // 3000001
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("Hardfork2").Block, big.NewInt(1)), parentDiff),
 
-		new(big.Int).Add(defuseBlock, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, new(big.Int).Add(defuseBlock, big.NewInt(-1)), parentDiff),
-		new(big.Int).Add(defuseBlock, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, new(big.Int).Add(defuseBlock, big.NewInt(0)), parentDiff),
-		new(big.Int).Add(defuseBlock, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, new(big.Int).Add(defuseBlock, big.NewInt(1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-2)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-2)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-1)): calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(-1)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(0)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(0)), parentDiff),
+		big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(1)):  calcDifficultyDefused(time, parentTime, big.NewInt(0).Add(config.ForkByName("LYRA2v2").Block, big.NewInt(1)), parentDiff),
 
 		big.NewInt(10000000): calcDifficultyDefused(time, parentTime, big.NewInt(10000000), parentDiff),
 	}
@@ -470,7 +428,7 @@ func TestCalcDifficulty1Morden(t *testing.T) {
 	for parentNum, expected := range table {
 		difficulty := CalcDifficulty(config, time, parentTime, parentNum, parentDiff)
 		if difficulty.Cmp(expected) != 0 {
-			t.Errorf("config: %v, got: %v, want: %v, with parentBlock: %v", "mainnet", difficulty, expected, parentNum)
+			t.Errorf("config: %v, got: %v, want: %v, with parentBlock: %v", "morden", difficulty, expected, parentNum)
 		}
 	}
 }
@@ -86,6 +86,8 @@ type BlockChain struct {
 
 	hc           *HeaderChain
 	chainDb      ethdb.Database
+	stateDb      ethdb.Database // database states are committed to, the trie node cache of a pruning chain
+	gc           *stateGC       // state garbage collection of a pruning chain, nil for an archive chain
 	eventMux     *event.TypeMux
 	genesisBlock *types.Block
 
@@ -145,6 +147,13 @@ func (bc *BlockChain) GetBlockByHash(h common.Hash) *types.Block {
 // available in the database. It initialises the default Ethereum Validator and
 // Processor.
 func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
+	return NewBlockChainWithStateGC(chainDb, config, pow, mux, 0)
+}
+
+// NewBlockChainWithStateGC returns a block chain which only keeps the states of
+// the gcDepth most recent blocks, and of a block every few thousand blocks.
+// A gcDepth of 0 keeps all states, like NewBlockChain.
+func NewBlockChainWithStateGC(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux *event.TypeMux, gcDepth uint64) (*BlockChain, error) {
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -153,6 +162,7 @@ func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux
 	bc := &BlockChain{
 		config:       config,
 		chainDb:      chainDb,
+		stateDb:      chainDb,
 		eventMux:     mux,
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
@@ -161,6 +171,15 @@ func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux
 		futureBlocks: futureBlocks,
 		pow:          pow,
 	}
+	if gcDepth > 0 {
+		bc.gc = &stateGC{
+			cache:    trie.NewNodeCache(chainDb, accountReferences),
+			depth:    gcDepth,
+			interval: stateFlushInterval,
+			limit:    stateCacheLimit,
+		}
+		bc.stateDb = bc.gc.cache
+	}
 	bc.SetValidator(NewBlockValidator(config, bc, pow))
 	bc.SetProcessor(NewStateProcessor(config, bc))
 
@@ -201,6 +220,7 @@ func NewBlockChainDryrun(chainDb ethdb.Database, config *ChainConfig, pow pow.Po
 	bc := &BlockChain{
 		config:       config,
 		chainDb:      chainDb,
+		stateDb:      chainDb,
 		eventMux:     mux,
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
@@ -248,6 +268,11 @@ func (bc *BlockChain) GetEventMux() *event.TypeMux {
 // SetAtxi sets the db and in-use var for atx indexing.
 func (bc *BlockChain) SetAtxi(a *AtxiT) {
 	bc.atxi = a
+	if a != nil && a.Internal {
+		if err := bc.initInternalRange(); err != nil {
+			glog.V(logger.Error).Infof("Failed to store the range of internally indexed blocks: %v", err)
+		}
+	}
 }
 
 // GetAtxi return indexes db and if atx index in use.
@@ -608,6 +633,18 @@ func (bc *BlockChain) LoadLastState(dryrun bool) error {
 		return errors.New("nil currentBlock")
 	}
 
+	// A pruning chain which was not stopped cleanly may miss the state of its
+	// head block; roll back to the last block whose state was flushed.
+	if bc.gc != nil && !bc.HasBlockAndState(currentBlock.Hash()) {
+		if block := bc.lastStateBlock(currentBlock); block != nil {
+			glog.V(logger.Warn).Warnf("Head state missing, rolling back head block from #%d to #%d", currentBlock.NumberU64(), block.NumberU64())
+			currentBlock = block
+			if err := WriteHeadBlockHash(bc.chainDb, currentBlock.Hash()); err != nil {
+				return err
+			}
+		}
+	}
+
 	// If currentBlock (fullblock) is not genesis, check that it is valid
 	// and that it has a state associated with it.
 	if currentBlock.Number().Cmp(new(big.Int)) > 0 {
@@ -725,7 +762,7 @@ func (bc *BlockChain) LoadLastState(dryrun bool) error {
 	}
 
 	// Initialize a statedb cache to ensure singleton account bloom filter generation
-	statedb, err := state.New(bc.currentBlock.Root(), state.NewDatabase(bc.chainDb))
+	statedb, err := state.New(bc.currentBlock.Root(), state.NewDatabase(bc.stateDb))
 	if err != nil {
 		return err
 	}
@@ -783,6 +820,14 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
 
+	// Drop the frozen blocks above the new head along with the rest
+	if fdb, ok := bc.chainDb.(*FreezerDatabase); ok {
+		if err := fdb.Truncate(currentHeader.Number.Uint64() + 1); err != nil {
+			bc.mu.Unlock()
+			return err
+		}
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -794,9 +839,14 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		bc.currentBlock = bc.GetBlock(currentHeader.Hash())
 	}
 	if bc.currentBlock != nil {
-		if _, err := state.New(bc.currentBlock.Root(), state.NewDatabase(bc.chainDb)); err != nil {
-			// Rewound state missing, rolled back to before pivot, reset to genesis
-			bc.currentBlock = nil
+		if _, err := state.New(bc.currentBlock.Root(), state.NewDatabase(bc.stateDb)); err != nil {
+			if bc.gc != nil {
+				// Rewound state pruned, roll back to the last kept one
+				bc.currentBlock = bc.lastStateBlock(bc.currentBlock)
+			} else {
+				// Rewound state missing, rolled back to before pivot, reset to genesis
+				bc.currentBlock = nil
+			}
 		}
 	}
 	// Rewind the fast block in a simpleton way to the target head
@@ -819,22 +869,16 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	}
 
 	if bc.atxi != nil && bc.atxi.AutoMode {
-		ldb, ok := bc.atxi.Db.(*ethdb.LDBDatabase)
-		if !ok {
-			glog.Fatal("could not cast indexes db to level db")
-		}
-
 		var removals [][]byte
 		deleteRemovalsFn := func(rs [][]byte) {
 			for _, r := range rs {
-				if e := ldb.Delete(r); e != nil {
+				if e := bc.atxi.Db.Delete(r); e != nil {
 					glog.Fatal(e)
 				}
 			}
 		}
 
-		pre := ethdb.NewBytesPrefix(txAddressIndexPrefix)
-		it := ldb.NewIteratorRange(pre)
+		it := bc.atxi.Db.NewIteratorWithPrefix(txAddressIndexPrefix)
 
 		for it.Next() {
 			key := it.Key()
@@ -856,6 +900,34 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		}
 		deleteRemovalsFn(removals)
 
+		// The same goes for the token transfer indexes
+		if bc.atxi.Tokens {
+			removals = [][]byte{}
+			it := bc.atxi.Db.NewIteratorWithPrefix(tokenTransferIndexPrefix)
+			for it.Next() {
+				key := it.Key()
+				_, _, bn, _, _, _ := resolveTokenTransferBytes(key)
+				if n := binary.BigEndian.Uint64(bn); n > head {
+					removals = append(removals, key)
+					if len(removals) > 100000 {
+						deleteRemovalsFn(removals)
+						removals = [][]byte{}
+					}
+				}
+			}
+			it.Release()
+			if e := it.Error(); e != nil {
+				return e
+			}
+			deleteRemovalsFn(removals)
+
+			if i := dbGetTokenTransferBookmark(bc.atxi.Db); i > head {
+				if err := dbSetTokenTransferBookmark(bc.atxi.Db, head); err != nil {
+					return err
+				}
+			}
+		}
+
 		// update atxi bookmark to lower head in the case that its progress was higher than the new head
 		if bc.atxi != nil && bc.atxi.AutoMode {
 			if i := bc.atxi.GetATXIBookmark(); i > head {
@@ -969,8 +1041,21 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 }
 
 // StateAt returns a new mutable state based on a particular point in time.
+// On a pruning chain it returns a *PrunedStateError for a garbage collected
+// state.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, state.NewDatabase(bc.chainDb))
+	statedb, err := state.New(root, state.NewDatabase(bc.stateDb))
+	if _, missing := err.(*trie.MissingNodeError); missing && bc.gc != nil {
+		return nil, &PrunedStateError{Root: root}
+	}
+	return statedb, err
+}
+
+// StateDatabase returns the database states are read from and committed to.
+// On a pruning chain it holds the recent states which are not flushed to the
+// chain database yet.
+func (bc *BlockChain) StateDatabase() ethdb.Database {
+	return bc.stateDb
 }
 
 // Reset purges the entire blockchain, restoring it to its genesis state.
@@ -1121,7 +1206,7 @@ func (bc *BlockChain) HasBlockAndState(hash common.Hash) bool {
 		return false
 	}
 	// Ensure the associated state is also present
-	_, err := state.New(block.Root(), state.NewDatabase(bc.chainDb))
+	_, err := state.New(block.Root(), state.NewDatabase(bc.stateDb))
 	return err == nil
 }
 
@@ -1186,6 +1271,12 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	// Blocks committed without being imported, such as mined ones, aren't
+	// waited for above
+	bc.chainmu.Lock()
+	bc.FlushState()
+	bc.chainmu.Unlock()
+
 	glog.V(logger.Info).Infoln("Chain manager stopped")
 }
 
@@ -1329,7 +1420,10 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			// Store the addr-tx indexes if enabled
 			if bc.atxi != nil {
 				if err := WriteBlockAddTxIndexes(bc.atxi.Db, block); err != nil {
-					glog.Fatalf("failed to write block add-tx indexes", err)
+					glog.Fatalf("failed to write block add-tx indexes: %v", err)
+				}
+				if err := bc.writeTokenTransferIndexes(block, receipts); err != nil {
+					glog.Fatalf("failed to write block token transfer indexes: %v", err)
 				}
 				// if buildATXI has been in use (via RPC) and is NOT finished, current < stop
 				// if buildATXI has been in use (via RPC) and IS finished, current == stop
@@ -1417,7 +1511,11 @@ func (bc *BlockChain) WriteBlockAddrTxIndexesBatch(indexDb ethdb.Database, start
 	}
 
 	for block != nil && blockProcessedHead() <= stopBlockN {
-		txP, err := putBlockAddrTxsToBatch(batch, block)
+		internal, err := bc.blockInternalTransfers(indexDb, block)
+		if err != nil {
+			return txsCount, err
+		}
+		txP, err := putBlockAddrTxsToBatch(batch, block, internal)
 		if err != nil {
 			return txsCount, err
 		}
@@ -1531,6 +1629,15 @@ func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err er
 	return
 }
 
+// reportBlock posts a BadBlockEvent for a block which failed validation or
+// processing. It is posted asynchronously, as the chain lock is held.
+func (bc *BlockChain) reportBlock(block *types.Block, err error) {
+	if bc.eventMux == nil {
+		return
+	}
+	go bc.eventMux.Post(BadBlockEvent{Block: block, Err: err})
+}
+
 // InsertChain inserts the given chain into the canonical chain or, otherwise, create a fork.
 // If the err return is not nil then chainIndex points to the cause in chain.
 func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
@@ -1539,8 +1646,8 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 	for i := 1; i < len(chain); i++ {
 		if chain[i].NumberU64() != chain[i-1].NumberU64()+1 || chain[i].ParentHash() != chain[i-1].Hash() {
 			// Chain broke ancestry, log a messge (programming error) and skip insertion
-			glog.V(logger.Error).Infof("Non contiguous block insert", "number", chain[i].Number(), "hash", chain[i].Hash(),
-				"parent", chain[i].ParentHash(), "prevnumber", chain[i-1].Number(), "prevhash", chain[i-1].Hash())
+			glog.V(logger.Error).Infof("Non contiguous block insert: number %v, hash %x, parent %x, prevnumber %v, prevhash %x",
+				chain[i].Number(), chain[i].Hash(), chain[i].ParentHash(), chain[i-1].Number(), chain[i-1].Hash())
 
 			res.Error = fmt.Errorf("non contiguous insert: item %d is #%d [%x…], item %d is #%d [%x…] (parent [%x…])", i-1, chain[i-1].NumberU64(),
 				chain[i-1].Hash().Bytes()[:4], i, chain[i].NumberU64(), chain[i].Hash().Bytes()[:4], chain[i].ParentHash().Bytes()[:4])
@@ -1588,12 +1695,14 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 				block := chain[r.index]
 				res.Index = r.index
 				res.Error = &BlockNonceErr{Hash: block.Hash(), Number: block.Number(), Nonce: block.Nonce()}
+				bc.reportBlock(block, res.Error)
 				return
 			}
 		}
 
 		if err := bc.config.HeaderCheck(block.Header()); err != nil {
 			res.Error = err
+			bc.reportBlock(block, err)
 			return
 		}
 
@@ -1627,6 +1736,9 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 			}
 
 			res.Error = err
+			if !IsParentErr(err) {
+				bc.reportBlock(block, err)
+			}
 			return
 		}
 
@@ -1643,19 +1755,21 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 			return
 		}
 		// Process block using the parent state as reference point.
-		receipts, logs, usedGas, err := bc.processor.Process(block, bc.stateCache)
+		receipts, logs, usedGas, internal, err := bc.process(block, bc.stateCache)
 		if err != nil {
 			res.Error = err
+			bc.reportBlock(block, err)
 			return
 		}
 		// Validate the state using the default validator
 		err = bc.Validator().ValidateState(block, bc.GetBlock(block.ParentHash()), bc.stateCache, receipts, usedGas)
 		if err != nil {
 			res.Error = err
+			bc.reportBlock(block, err)
 			return
 		}
 		// Write state changes to database
-		_, err = bc.stateCache.CommitTo(bc.chainDb, false)
+		err = bc.commitState(block, bc.stateCache)
 		if err != nil {
 			res.Error = err
 			return
@@ -1664,6 +1778,15 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 		// coalesce logs for later processing
 		coalescedLogs = append(coalescedLogs, logs...)
 
+		// Keep the internal transfers for the addr-tx indexes of the block, whether
+		// it becomes canonical now or in a later reorg
+		if internal != nil {
+			if err := writeImportedInternalTransfers(bc.atxi.Db, block, internal); err != nil {
+				res.Error = err
+				return
+			}
+		}
+
 		if err := WriteBlockReceipts(bc.chainDb, block.Hash(), receipts); err != nil {
 			res.Error = err
 			return
@@ -1705,6 +1828,10 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 					res.Error = fmt.Errorf("failed to write block add-tx indexes: %v", err)
 					return
 				}
+				if err := bc.writeTokenTransferIndexes(block, receipts); err != nil {
+					res.Error = fmt.Errorf("failed to write block token transfer indexes: %v", err)
+					return
+				}
 				// if buildATXI has been in use (via RPC) and is NOT finished, current < stop
 				// if buildATXI has been in use (via RPC) and IS finished, current == stop
 				// else if builtATXI has not been in use (via RPC), then current == stop == 0
@@ -1863,6 +1990,14 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 					return err
 				}
 			}
+			if err := rmBlockInternalAddrTxs(bc.atxi.Db, block); err != nil {
+				return err
+			}
+			if bc.atxi.Tokens {
+				if err := rmBlockTokenTransfers(bc.atxi.Db, block, GetBlockReceipts(bc.chainDb, block.Hash())); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -1875,11 +2010,15 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		if err := WriteTransactions(bc.chainDb, block); err != nil {
 			return err
 		}
+		receipts := GetBlockReceipts(bc.chainDb, block.Hash())
 		// Store the addr-tx indexes if enabled
 		if bc.atxi != nil {
 			if err := WriteBlockAddTxIndexes(bc.atxi.Db, block); err != nil {
 				return err
 			}
+			if err := bc.writeTokenTransferIndexes(block, receipts); err != nil {
+				return err
+			}
 			// if buildATXI has been in use (via RPC) and is NOT finished, current < stop
 			// if buildATXI has been in use (via RPC) and IS finished, current == stop
 			// else if builtATXI has not been in use (via RPC), then current == stop == 0
@@ -1889,7 +2028,6 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 				}
 			}
 		}
-		receipts := GetBlockReceipts(bc.chainDb, block.Hash())
 		// write receipts
 		if err := WriteReceipts(bc.chainDb, receipts); err != nil {
 			return err
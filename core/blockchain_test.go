@@ -69,8 +69,35 @@ func GenesisBlockForTesting(db ethdb.Database, addr common.Address, balance *big
 	}, nil, nil, nil)
 }
 
+// MakeDiehardChainConfig returns a chain configuration with the Diehard fork,
+// and with it replay protection for chain id 63, from the genesis block.
+func MakeDiehardChainConfig() *ChainConfig {
+	return &ChainConfig{
+		Forks: []*Fork{
+			{
+				Name:  "Diehard",
+				Block: big.NewInt(0),
+				Features: []*ForkFeature{
+					{
+						ID: "eip155",
+						Options: ChainFeatureConfigOptions{
+							"chainID": 63,
+						},
+					},
+					{
+						ID: "gastable",
+						Options: ChainFeatureConfigOptions{
+							"type": "eip160",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func theBlockChain(db ethdb.Database, t *testing.T) *BlockChain {
-	pow, err := cryptonight.NewForTesting()
+	pow, err := cryptonight.NewForTesting(DefaultConfigMorden.ChainConfig.GetLYRA2Block(), DefaultConfigMorden.ChainConfig.GetLYRA2v2Block())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -498,6 +525,7 @@ func chm(t testing.TB, genesis *types.Block, db ethdb.Database) *BlockChain {
 	config := testChainConfig()
 	bc := &BlockChain{
 		chainDb:      db,
+		stateDb:      db,
 		genesisBlock: genesis,
 		eventMux:     &eventMux,
 		pow:          FakePow{},
@@ -1641,13 +1669,13 @@ func TestEIP155Transition(t *testing.T) {
 						{
 							ID: "difficulty",
 							Options: ChainFeatureConfigOptions{
-								"type": "homestead",
+								"type": "defused",
 							},
 						},
 						{
 							ID: "gastable",
 							Options: ChainFeatureConfigOptions{
-								"type": "homestead",
+								"type": "eip160",
 							},
 						},
 					},
@@ -1662,17 +1690,16 @@ func TestEIP155Transition(t *testing.T) {
 								"chainID": 1,
 							},
 						},
-						{ // ecip1010 bomb delay
+						{
 							ID: "gastable",
 							Options: ChainFeatureConfigOptions{
 								"type": "eip160",
 							},
 						},
-						{ // ecip1010 bomb delay
+						{
 							ID: "difficulty",
 							Options: ChainFeatureConfigOptions{
-								"type":   "ecip1010",
-								"length": 2000000,
+								"type": "defused",
 							},
 						},
 					},
@@ -1710,7 +1737,7 @@ func TestEIP155Transition(t *testing.T) {
 			}
 			block.AddTx(tx)
 
-			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID()))
+			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID(nil)))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1722,7 +1749,7 @@ func TestEIP155Transition(t *testing.T) {
 			}
 			block.AddTx(tx)
 
-			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID()))
+			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID(nil)))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1759,13 +1786,13 @@ func TestEIP155Transition(t *testing.T) {
 					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "defused",
 						},
 					},
 					{
 						ID: "gastable",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "eip160",
 						},
 					},
 				},
@@ -1780,17 +1807,16 @@ func TestEIP155Transition(t *testing.T) {
 							"chainID": 2,
 						},
 					},
-					{ // ecip1010 bomb delay
+					{
 						ID: "gastable",
 						Options: ChainFeatureConfigOptions{
 							"type": "eip160",
 						},
 					},
-					{ // ecip1010 bomb delay
+					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type":   "ecip1010",
-							"length": 2000000,
+							"type": "defused",
 						},
 					},
 				},
@@ -1809,7 +1835,7 @@ func TestEIP155Transition(t *testing.T) {
 		)
 		switch i {
 		case 0:
-			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID()))
+			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID(nil)))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -44,7 +44,7 @@ func MakeChainConfig() *ChainConfig {
 					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "defused",
 						},
 					},
 				},
@@ -34,8 +34,8 @@ type failPow struct {
 	failing uint64
 }
 
-func (pow failPow) Search(pow.Block, <-chan struct{}, int) (uint64, []byte) {
-	return 0, nil
+func (pow failPow) Search(pow.Block, <-chan struct{}, int) uint64 {
+	return 0
 }
 func (pow failPow) Verify(block pow.Block) bool { return block.NumberU64() != pow.failing }
 func (pow failPow) GetHashrate() int64          { return 0 }
@@ -47,8 +47,8 @@ type delayedPow struct {
 	delay time.Duration
 }
 
-func (pow delayedPow) Search(pow.Block, <-chan struct{}, int) (uint64, []byte) {
-	return 0, nil
+func (pow delayedPow) Search(pow.Block, <-chan struct{}, int) uint64 {
+	return 0
 }
 func (pow delayedPow) Verify(block pow.Block) bool { time.Sleep(pow.delay); return true }
 func (pow delayedPow) GetHashrate() int64          { return 0 }
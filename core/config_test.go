@@ -24,95 +24,40 @@ func TestConfigErrorProperties(t *testing.T) {
 	}
 }
 
+// The default configurations have no Homestead fork; the rules it brought in
+// are part of the Diehard fork.
 func TestChainConfig_IsHomestead(t *testing.T) {
-	config := DefaultConfigMainnet.ChainConfig
-
-	if config.IsHomestead(big.NewInt(10000)) {
-		t.Errorf("Unexpected for %d", 10000)
-	}
-
-	if !config.IsHomestead(big.NewInt(1920000)) {
-		t.Errorf("Expected for %d", 1920000)
-	}
-	if !config.IsHomestead(big.NewInt(2325166)) {
-		t.Errorf("Expected for %d", 2325166)
-	}
-	if !config.IsHomestead(big.NewInt(3000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-	if !config.IsHomestead(big.NewInt(3000001)) {
-		t.Errorf("Expected for %d", 3000001)
-	}
-	if !config.IsHomestead(big.NewInt(4000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-	if !config.IsHomestead(big.NewInt(5000000)) {
-		t.Errorf("Expected for %d", 5000000)
-	}
-	if !config.IsHomestead(big.NewInt(5000001)) {
-		t.Errorf("Expected for %d", 5000001)
+	for _, config := range []*ChainConfig{DefaultConfigMainnet.ChainConfig, DefaultConfigMorden.ChainConfig} {
+		for _, n := range []int64{0, 1, 2619000, 5000000} {
+			if config.IsHomestead(big.NewInt(n)) {
+				t.Errorf("Unexpected for %d", n)
+			}
+		}
 	}
 }
 
 func TestChainConfig_IsDiehard(t *testing.T) {
 	config := DefaultConfigMainnet.ChainConfig
 
-	if config.IsDiehard(big.NewInt(1920000)) {
-		t.Errorf("Unexpected for %d", 1920000)
-	}
-
-	if config.IsDiehard(big.NewInt(2325166)) {
-		t.Errorf("Unexpected for %d", 2325166)
-	}
-
-	if !config.IsDiehard(big.NewInt(3000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-	if !config.IsDiehard(big.NewInt(3000001)) {
-		t.Errorf("Expected for %d", 3000001)
-	}
-	if !config.IsDiehard(big.NewInt(4000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-
-	if !config.IsDiehard(big.NewInt(5000000)) {
-		t.Errorf("Expected for %d", 5000000)
+	if config.IsDiehard(big.NewInt(0)) {
+		t.Errorf("Unexpected for %d", 0)
 	}
-	if !config.IsDiehard(big.NewInt(5000001)) {
-		t.Errorf("Expected for %d", 5000001)
+	for _, n := range []int64{1, 2, 2022222, 2619000, 5000000} {
+		if !config.IsDiehard(big.NewInt(n)) {
+			t.Errorf("Expected for %d", n)
+		}
 	}
 }
 
+// The default configurations have no difficulty bomb.
 func TestChainConfig_IsExplosion(t *testing.T) {
 	config := DefaultConfigMainnet.ChainConfig
 
-	if config.IsExplosion(big.NewInt(1920000)) {
-		t.Errorf("Unexpected for %d", 1920000)
-	}
-
-	if config.IsExplosion(big.NewInt(2325166)) {
-		t.Errorf("Unexpected for %d", 2325166)
-	}
-
-	// Default Diehard block is 3000000
-	if config.IsExplosion(big.NewInt(3000000)) {
-		t.Errorf("Unxpected for %d", 3000000)
-	}
-	if config.IsExplosion(big.NewInt(3000001)) {
-		t.Errorf("Unxpected for %d", 3000001)
-	}
-	if config.IsExplosion(big.NewInt(4000000)) {
-		t.Errorf("Unxpected for %d", 3000000)
-	}
-
-	// Default BombDelay length is 2000000.
-	if !config.IsExplosion(big.NewInt(5000000)) {
-		t.Errorf("Expected for %d", 5000000)
-	}
-	if !config.IsExplosion(big.NewInt(5000001)) {
-		t.Errorf("Expected for %d", 5000001)
+	for _, n := range []int64{1, 3000000, 5000000, 5000001} {
+		if config.IsExplosion(big.NewInt(n)) {
+			t.Errorf("Unexpected for %d", n)
+		}
 	}
-
 }
 
 func sameGenesisDumpAllocationsBalances(gd1, gd2 *GenesisDump) bool {
@@ -288,15 +233,10 @@ func TestChainConfig_GetFeature(t *testing.T) {
 }
 
 var allAvailableDefaultConfigKeys = []string{
-	"difficulty",
-	"gastable",
 	"eip155",
 }
 var allAvailableTestnetConfigKeys = []string{
-	"difficulty",
-	"gastable",
 	"eip155",
-	"reward",
 }
 var unavailableConfigKeys = []string{
 	"foo",
@@ -331,23 +271,23 @@ func TestChainConfig_GetFeature3_NeverGetNonexistantFeatures(t *testing.T) {
 func TestChainConfig_GetFeature4_WorkForHighNumbers(t *testing.T) {
 	c := getDefaultChainConfigSorted()
 	ultraHighBlock := big.NewInt(99999999999999999)
-	if _, _, ok := c.GetFeature(ultraHighBlock, "difficulty"); !ok {
-		t.Errorf("unexpected unfound difficulty feature for far-future block: %v", ultraHighBlock)
+	if _, _, ok := c.GetFeature(ultraHighBlock, "eip155"); !ok {
+		t.Errorf("unexpected unfound eip155 feature for far-future block: %v", ultraHighBlock)
 	}
 }
 
 func TestChainConfig_GetChainID(t *testing.T) {
 	// Test default hardcoded configs.
-	if DefaultConfigMainnet.ChainConfig.GetChainID().Cmp(DefaultConfigMainnet.ChainConfig.GetChainID()) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(), DefaultConfigMainnet.ChainConfig.GetChainID())
+	if DefaultConfigMainnet.ChainConfig.GetChainID(nil).Cmp(DefaultConfigMainnet.ChainConfig.GetChainID(nil)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(nil), DefaultConfigMainnet.ChainConfig.GetChainID(nil))
 	}
-	if DefaultConfigMorden.ChainConfig.GetChainID().Cmp(DefaultConfigMorden.ChainConfig.GetChainID()) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(), DefaultConfigMorden.ChainConfig.GetChainID())
+	if DefaultConfigMorden.ChainConfig.GetChainID(nil).Cmp(DefaultConfigMorden.ChainConfig.GetChainID(nil)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(nil), DefaultConfigMorden.ChainConfig.GetChainID(nil))
 	}
 
 	// If no chainID (config is empty) returns 0.
 	c := &ChainConfig{}
-	cid := c.GetChainID()
+	cid := c.GetChainID(nil)
 	// check is zero
 	if cid.Cmp(new(big.Int)) != 0 {
 		t.Errorf("got: %v, want: %v", cid, new(big.Int))
@@ -355,8 +295,8 @@ func TestChainConfig_GetChainID(t *testing.T) {
 
 	// Test parsing default external mainnet config.
 	cases := map[string]*big.Int{
-		"../core/config/mainnet.json": DefaultConfigMainnet.ChainConfig.GetChainID(),
-		"../core/config/morden.json":  DefaultConfigMorden.ChainConfig.GetChainID(),
+		"../core/config/mainnet.json": DefaultConfigMainnet.ChainConfig.GetChainID(nil),
+		"../core/config/morden.json":  DefaultConfigMorden.ChainConfig.GetChainID(nil),
 	}
 	for extConfigPath, wantInt := range cases {
 		p, e := filepath.Abs(extConfigPath)
@@ -367,8 +307,8 @@ func TestChainConfig_GetChainID(t *testing.T) {
 		if err != nil {
 			t.Fatalf("could not decode file: %v", err)
 		}
-		if extConfig.ChainConfig.GetChainID().Cmp(wantInt) != 0 {
-			t.Errorf("got: %v, want: %v", extConfig.ChainConfig.GetChainID(), wantInt)
+		if extConfig.ChainConfig.GetChainID(nil).Cmp(wantInt) != 0 {
+			t.Errorf("got: %v, want: %v", extConfig.ChainConfig.GetChainID(nil), wantInt)
 		}
 	}
 }
@@ -381,27 +321,27 @@ func TestChainConfig_GetChainID(t *testing.T) {
 func TestChainConfig_GetFeature5_DefaultEIP155(t *testing.T) {
 	c := getDefaultChainConfigSorted()
 	var tables = map[*big.Int]*big.Int{
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): nil,
-		DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block:                                   nil,
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): nil,
+		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): nil,
+		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   big.NewInt(101),
+		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): big.NewInt(101),
 
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): nil,
-		DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block:                                   nil,
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): nil,
+		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Hardfork1").Block, big.NewInt(1)): big.NewInt(101),
+		DefaultConfigMainnet.ChainConfig.ForkByName("Hardfork1").Block:                                   big.NewInt(24484),
+		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Hardfork1").Block, big.NewInt(1)): big.NewInt(24484),
 
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): nil,
-		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   big.NewInt(61),
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): big.NewInt(61),
+		DefaultConfigMainnet.ChainConfig.ForkByName("Hardfork2").Block: big.NewInt(24484),
 	}
 	for block, expected := range tables {
 		feat, fork, ok := c.GetFeature(block, "eip155")
 		if expected != nil {
 			if !ok {
 				t.Errorf("Expected eip155 feature to exist. feat: %v, fork: %v, block: %v", feat, fork, block)
+				continue
 			}
 			val, ok := feat.GetBigInt("chainID")
 			if !ok {
 				t.Errorf("failed to get value for eip155 feature. feat: %v, fork: %v, block: %v", feat, fork, block)
+				continue
 			}
 			if val.Cmp(expected) != 0 {
 				t.Errorf("want: %v, got: %v", expected, val)
@@ -414,74 +354,29 @@ func TestChainConfig_GetFeature5_DefaultEIP155(t *testing.T) {
 	}
 }
 
-// TestChainConfig_GetFeature_DefaultGasTables sets that GetFeatures gets expected feature values for default fork configs.
+// TestChainConfig_GetFeature_DefaultGasTables checks that the default fork configs
+// configure no gas table, so that the Diehard gas table is used at every block.
 func TestChainConfig_GetFeature6_DefaultGasTables(t *testing.T) {
 	c := getDefaultChainConfigSorted()
-	var tables = map[*big.Int]string{
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block:                                   "homestead",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "homestead",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "homestead",
-		DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block:                                   "eip150",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "eip150",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "eip150",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   "eip160",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "eip160",
-	}
-	for block, expected := range tables {
-		feat, fork, ok := c.GetFeature(block, "gastable")
-		if expected != "" {
-			if !ok {
-				t.Errorf("Expected gastable feature to exist. feat: %v, fork: %v, block: %v", feat, fork, block)
-			}
-			val, ok := feat.GetString("type")
-			if !ok {
-				t.Errorf("failed to get value for gastable feature. feat: %v, fork: %v, block: %v", feat, fork, block)
-			}
-			if val != expected {
-				t.Errorf("want: %v, got: %v", expected, val)
-			}
-		} else {
-			if ok {
+	for _, fork := range c.Forks {
+		for _, block := range []*big.Int{new(big.Int).Sub(fork.Block, big.NewInt(1)), fork.Block, new(big.Int).Add(fork.Block, big.NewInt(1))} {
+			if feat, fork, ok := c.GetFeature(block, "gastable"); ok {
 				t.Errorf("Unexpected gastable feature exists. feat: %v, fork: %v, block: %v", feat, fork, block)
 			}
+			if table := c.GasTable(block); table != DefaultDiehardGasTable {
+				t.Errorf("unexpected gas table at block %v: %v", block, table)
+			}
 		}
 	}
 }
 
-// TestChainConfig_GetFeature_DefaultGasTables sets that GetFeatures gets expected feature values for default fork configs.
+// TestChainConfig_GetFeature_DefaultDifficulty checks that the default fork configs
+// configure no difficulty algorithm, so that the defused one is used at every block.
 func TestChainConfig_GetFeature7_DefaultDifficulty(t *testing.T) {
 	c := getDefaultChainConfigSorted()
-	var tables = map[*big.Int]string{
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block:                                   "homestead",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "homestead",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "homestead",
-		DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block:                                   "homestead",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "homestead",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "homestead",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   "ecip1010",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "ecip1010",
-	}
-	for block, expected := range tables {
-		feat, fork, ok := c.GetFeature(block, "difficulty")
-		if expected != "" {
-			if !ok {
-				t.Errorf("Expected difficulty feature to exist. feat: %v, fork: %v, block: %v", feat, fork, block)
-			}
-			val, ok := feat.GetString("type")
-			if !ok {
-				t.Errorf("failed to get value for difficulty feature. feat: %v, fork: %v, block: %v", feat, fork, block)
-			}
-			if val != expected {
-				t.Errorf("want: %v, got: %v", expected, val)
-			}
-		} else {
-			if ok {
+	for _, fork := range c.Forks {
+		for _, block := range []*big.Int{new(big.Int).Sub(fork.Block, big.NewInt(1)), fork.Block, new(big.Int).Add(fork.Block, big.NewInt(1))} {
+			if feat, fork, ok := c.GetFeature(block, "difficulty"); ok {
 				t.Errorf("Unexpected difficulty feature exists. feat: %v, fork: %v, block: %v", feat, fork, block)
 			}
 		}
@@ -549,42 +444,34 @@ func TestChainConfigGetSet(t *testing.T) {
 func TestChainConfig_GetLastRequiredHashFork(t *testing.T) {
 	c := getDefaultChainConfigSorted()
 
-	daoFork := c.ForkByName("The DAO Hard Fork")
-	got, want := c.GetLatestRequiredHashFork(big.NewInt(1920000)), daoFork
-
-	// sanity check
-	if want == nil {
-		t.Fatal("nil want hard fork")
-	}
-
-	if got == nil {
-		t.Fatalf("got: %v, want: %s", got, want.Name)
-	}
-	if got.RequiredHash.Hex() != "0x94365e3a8c0b35089c1d1195081fe7489b528a84b22199c916180db8b28ade7f" {
-		t.Errorf("got: %v, want: %s", got, got.RequiredHash.Hex())
+	// create new "checkpoint" forks for testing
+	checkpoint1 := &Fork{
+		Name:         "checkpoint1",
+		Block:        big.NewInt(1000),
+		RequiredHash: common.HexToHash("0x94365e3a8c0b35089c1d1195081fe7489b528a84b22199c916180db8b28ade7f"),
 	}
-	if got.Block.Cmp(big.NewInt(1920000)) != 0 {
-		t.Errorf("got: %d, want: %d", got.Block, 1920000)
-	}
-
-	// create new "checkpoint" fork for testing
-	checkpoint := &Fork{
-		Name:         "checkpoint",
-		Block:        big.NewInt(1930000),
+	checkpoint2 := &Fork{
+		Name:         "checkpoint2",
+		Block:        big.NewInt(2000),
 		RequiredHash: common.HexToHash("0xabc65e3a8c0b35089c1d1195081fe7489b528a84b22199c916180db8b28ad123"),
 	}
-	c.Forks = append(c.Forks, checkpoint)
+	c.Forks = append(c.Forks, checkpoint2, checkpoint1)
 
 	// Noting that config forks do not have to be sorted for this function to work.
 	//c.SortForks()
 
-	got, want = c.GetLatestRequiredHashFork(big.NewInt(1930000)), checkpoint
+	// none of the default forks require a hash
+	if got := c.GetLatestRequiredHashFork(big.NewInt(999)); got != nil {
+		t.Errorf("got: %v, want: %v", got, nil)
+	}
+
+	got, want := c.GetLatestRequiredHashFork(big.NewInt(2000)), checkpoint2
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("got: %v, want: %v", got, want)
 	}
 
-	// should use dao fork, not checkpoint since block n has not reached checkpoint
-	got, want = c.GetLatestRequiredHashFork(big.NewInt(1920001)), daoFork
+	// should use checkpoint1, not checkpoint2 since block n has not reached checkpoint2
+	got, want = c.GetLatestRequiredHashFork(big.NewInt(1999)), checkpoint1
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("got: %v, want: %v", got, want)
 	}
@@ -0,0 +1,145 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// ErrNotLevelDB is returned by the LevelDB specific database operations for a
+// database with another backend.
+var ErrNotLevelDB = errors.New("database is not backed by LevelDB")
+
+// DatabaseStat holds the number of keys of one kind of data in a database, and
+// the size of their keys and values.
+type DatabaseStat struct {
+	Name  string             `json:"name"`
+	Count uint64             `json:"count"`
+	Size  common.StorageSize `json:"size"`
+}
+
+// databaseKinds classifies the keys of the chain and index databases, in
+// matching order.
+var databaseKinds = []struct {
+	name  string
+	match func(key, value []byte) bool
+}{
+	{"Headers", func(key, _ []byte) bool { return isBlockKey(key, headerSuffix) }},
+	{"Bodies", func(key, _ []byte) bool { return isBlockKey(key, bodySuffix) }},
+	{"Total difficulties", func(key, _ []byte) bool { return isBlockKey(key, tdSuffix) }},
+	{"Canonical hashes", func(key, _ []byte) bool { return bytes.HasPrefix(key, blockNumPrefix) }},
+	{"Legacy blocks", func(key, _ []byte) bool { return bytes.HasPrefix(key, blockHashPrefix) }},
+	{"Block receipts", func(key, _ []byte) bool { return bytes.HasPrefix(key, blockReceiptsPrefix) }},
+	{"Transaction receipts", func(key, _ []byte) bool { return bytes.HasPrefix(key, receiptsPrefix) }},
+	{"Transaction lookups", func(key, _ []byte) bool {
+		return len(key) == common.HashLength+1 && (bytes.HasSuffix(key, txMetaSuffix) || bytes.HasPrefix(key, lookupPrefix))
+	}},
+	{"Transactions", func(key, value []byte) bool {
+		return len(key) == common.HashLength && rlp.DecodeBytes(value, new(types.Transaction)) == nil
+	}},
+	{"Trie nodes and code", func(key, _ []byte) bool { return len(key) == common.HashLength }},
+	{"Address transaction index", func(key, _ []byte) bool { return bytes.HasPrefix(key, txAddressIndexPrefix) }},
+	{"Address internal transfers", func(key, _ []byte) bool { return bytes.HasPrefix(key, txAddressInternalPrefix) }},
+	{"Token transfer index", func(key, _ []byte) bool { return bytes.HasPrefix(key, tokenTransferIndexPrefix) }},
+	{"Mipmap log blooms", func(key, _ []byte) bool { return bytes.HasPrefix(key, mipmapPre) }},
+	{"Preimages", func(key, _ []byte) bool { return bytes.HasPrefix(key, []byte(preimagePrefix)) }},
+	{"Ancient block numbers", func(key, _ []byte) bool { return bytes.HasPrefix(key, ancientNumberPrefix) }},
+}
+
+// isBlockKey reports whether key is a block key with the given suffix.
+func isBlockKey(key, suffix []byte) bool {
+	return len(key) == len(blockPrefix)+common.HashLength+len(suffix) && bytes.HasPrefix(key, blockPrefix) && bytes.HasSuffix(key, suffix)
+}
+
+// InspectDatabase counts the keys of db by kind of data. Keys of no known kind
+// are counted as "Other". progress, if not nil, is called with the number of
+// keys seen every 1M keys. The iteration stops early if abort is closed.
+func InspectDatabase(db ethdb.Database, abort <-chan struct{}, progress func(keys uint64)) ([]DatabaseStat, error) {
+	stats := make([]DatabaseStat, len(databaseKinds)+1)
+	for i, kind := range databaseKinds {
+		stats[i].Name = kind.name
+	}
+	stats[len(databaseKinds)].Name = "Other"
+
+	it := db.NewIteratorWithRange(nil, nil)
+	defer it.Release()
+
+	var keys uint64
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+
+		i := 0
+		for ; i < len(databaseKinds); i++ {
+			if databaseKinds[i].match(key, value) {
+				break
+			}
+		}
+		stats[i].Count++
+		stats[i].Size += common.StorageSize(len(key) + len(value))
+
+		if keys++; keys%(1024*1024) == 0 {
+			if progress != nil {
+				progress(keys)
+			}
+			select {
+			case <-abort:
+				return stats, errors.New("inspection aborted")
+			default:
+			}
+		}
+	}
+	return stats, it.Error()
+}
+
+// DatabaseLDB returns the LevelDB database backing db.
+func DatabaseLDB(db ethdb.Database) (*leveldb.DB, error) {
+	switch db := db.(type) {
+	case *ethdb.LDBDatabase:
+		return db.LDB(), nil
+	case *FreezerDatabase:
+		return DatabaseLDB(db.Database)
+	}
+	return nil, ErrNotLevelDB
+}
+
+// DatabaseStats returns the LevelDB statistics of db: the number, size and
+// compaction activity of the tables of every level.
+func DatabaseStats(db ethdb.Database) (string, error) {
+	ldb, err := DatabaseLDB(db)
+	if err != nil {
+		return "", err
+	}
+	return ldb.GetProperty("leveldb.stats")
+}
+
+// CompactDatabase compacts the keys in [start, limit) of db, a nil start or
+// limit extending the range to the first or last key.
+func CompactDatabase(db ethdb.Database, start, limit []byte) error {
+	ldb, err := DatabaseLDB(db)
+	if err != nil {
+		return err
+	}
+	return ldb.CompactRange(util.Range{Start: start, Limit: limit})
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestInspectDatabase(t *testing.T) {
+	mem, _ := ethdb.NewMemDatabase()
+	writeFreezerTestChain(t, mem, 10)
+	mem.Put([]byte("some-unknown-key"), []byte("value"))
+
+	stats, err := InspectDatabase(mem, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	want := map[string]uint64{
+		"Headers":            10,
+		"Bodies":             10,
+		"Total difficulties": 10,
+		"Canonical hashes":   10,
+		"Block receipts":     10,
+		"Other":              2, // head block hash and the unknown key
+	}
+	for _, stat := range stats {
+		if stat.Count != want[stat.Name] {
+			t.Errorf("%s: key count mismatch: have %d, want %d", stat.Name, stat.Count, want[stat.Name])
+		}
+		if (stat.Size > 0) != (stat.Count > 0) {
+			t.Errorf("%s: size %v for %d keys", stat.Name, stat.Size, stat.Count)
+		}
+	}
+	if _, err := DatabaseStats(mem); err != ErrNotLevelDB {
+		t.Errorf("memory database stats error mismatch: have %v, want %v", err, ErrNotLevelDB)
+	}
+}
+
+func TestDatabaseStatsAndCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 16, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	writeFreezerTestChain(t, db, 10)
+
+	if err := CompactDatabase(db, nil, nil); err != nil {
+		t.Fatalf("failed to compact database: %v", err)
+	}
+	stats, err := DatabaseStats(db)
+	if err != nil {
+		t.Fatalf("failed to read database stats: %v", err)
+	}
+	if !strings.Contains(stats, "Level") {
+		t.Errorf("unexpected database stats: %q", stats)
+	}
+}
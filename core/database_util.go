@@ -52,6 +52,8 @@ var (
 
 	preimagePrefix = "secure-key-" // preimagePrefix + hash -> preimage
 	lookupPrefix   = []byte("l")   // lookupPrefix + hash -> transaction/receipt lookup metadata
+
+	ancientNumberPrefix = []byte("ancient-") // ancientNumberPrefix + hash -> number of a block moved to the freezer
 )
 
 // TxLookupEntry is a positional metadata to help looking up the data content of
@@ -106,9 +108,12 @@ func GetHeadFastBlockHash(db ethdb.Database) common.Hash {
 }
 
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
-// if the header's not found.
+// if the header's not found. Headers of frozen blocks are read from the freezer.
 func GetHeaderRLP(db ethdb.Database, hash common.Hash) rlp.RawValue {
 	data, _ := db.Get(append(append(blockPrefix, hash[:]...), headerSuffix...))
+	if len(data) == 0 {
+		data = readAncient(db, freezerHeaderTable, hash)
+	}
 	return data
 }
 
@@ -128,8 +133,12 @@ func GetHeader(db ethdb.Database, hash common.Hash) *types.Header {
 }
 
 // GetBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
+// Bodies of frozen blocks are read from the freezer.
 func GetBodyRLP(db ethdb.Database, hash common.Hash) rlp.RawValue {
 	data, _ := db.Get(append(append(blockPrefix, hash[:]...), bodySuffix...))
+	if len(data) == 0 {
+		data = readAncient(db, freezerBodiesTable, hash)
+	}
 	return data
 }
 
@@ -152,6 +161,9 @@ func GetBody(db ethdb.Database, hash common.Hash) *types.Body {
 // none found.
 func GetTd(db ethdb.Database, hash common.Hash) *big.Int {
 	data, _ := db.Get(append(append(blockPrefix, hash.Bytes()...), tdSuffix...))
+	if len(data) == 0 {
+		data = readAncient(db, freezerDifficultyTable, hash)
+	}
 	if len(data) == 0 {
 		return nil
 	}
@@ -187,6 +199,9 @@ func GetBlock(db ethdb.Database, hash common.Hash) *types.Block {
 // in a block given by its hash.
 func GetBlockReceipts(db ethdb.Database, hash common.Hash) types.Receipts {
 	data, _ := db.Get(append(blockReceiptsPrefix, hash[:]...))
+	if len(data) == 0 {
+		data = readAncient(db, freezerReceiptTable, hash)
+	}
 	if len(data) == 0 {
 		return nil
 	}
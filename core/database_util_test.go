@@ -99,11 +99,26 @@ func TestDifficultyFrontier(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// The expected difficulties of the file follow the Frontier rules, which
+	// Webchain never used, so only its inputs are replayed against the
+	// defused adjustment: parent_diff / 200 * max(1 - (time - parent_time) // 10, -20).
 	for name, test := range tests {
 		number := new(big.Int).Sub(test.CurrentBlocknumber, big.NewInt(1))
-		diff := calcDifficultyFrontier(test.CurrentTimestamp, test.ParentTimestamp, number, test.ParentDifficulty)
-		if diff.Cmp(test.CurrentDifficulty) != 0 {
-			t.Error(name, "failed. Expected", test.CurrentDifficulty, "and calculated", diff)
+		diff := CalcDifficulty(DefaultConfigMainnet.ChainConfig, test.CurrentTimestamp, test.ParentTimestamp, number, test.ParentDifficulty)
+
+		adjust := new(big.Int).Sub(new(big.Int).SetUint64(test.CurrentTimestamp), new(big.Int).SetUint64(test.ParentTimestamp))
+		adjust.Sub(common.Big1, adjust.Div(adjust, big.NewInt(10)))
+		if adjust.Cmp(big.NewInt(-20)) < 0 {
+			adjust.SetInt64(-20)
+		}
+		want := new(big.Int).Div(test.ParentDifficulty, DifficultyBoundDivisor)
+		want.Mul(want, adjust)
+		want.Add(want, test.ParentDifficulty)
+		if want.Cmp(MinimumDifficulty) < 0 {
+			want.Set(MinimumDifficulty)
+		}
+		if diff.Cmp(want) != 0 {
+			t.Error(name, "failed. Expected", want, "and calculated", diff)
 		}
 	}
 }
@@ -20,55 +20,39 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 		t.Errorf("got: %v, want: %v", DefaultConfigMorden.Identity, "mainnet")
 	}
 
-	if DefaultConfigMainnet.Name != "Ethereum Classic Mainnet" {
-		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.Name, "Ethereum Classic Mainnet")
+	if DefaultConfigMainnet.Name != "Webchain Mainnet" {
+		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.Name, "Webchain Mainnet")
 	}
-	if DefaultConfigMorden.Name != "Morden Testnet" {
-		t.Errorf("got: %v, want: %v", DefaultConfigMorden.Name, "Morden Testnet")
+	if DefaultConfigMorden.Name != "Webchain Testnet" {
+		t.Errorf("got: %v, want: %v", DefaultConfigMorden.Name, "Webchain Testnet")
 	}
 
-	if DefaultConfigMainnet.ChainConfig.GetChainID().Cmp(big.NewInt(61)) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(), big.NewInt(61))
+	if DefaultConfigMainnet.ChainConfig.GetChainID(nil).Cmp(big.NewInt(24484)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(nil), big.NewInt(24484))
 	}
-	if DefaultConfigMorden.ChainConfig.GetChainID().Cmp(big.NewInt(62)) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(), big.NewInt(62))
+	if DefaultConfigMorden.ChainConfig.GetChainID(nil).Cmp(big.NewInt(24485)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(nil), big.NewInt(24485))
 	}
 
 	// Test forks existence and block numbers
-	// Homestead
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("Homestead"); fork.Block.Cmp(big.NewInt(1150000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("Homestead"); fork.Block.Cmp(big.NewInt(494000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// The DAO Hard Fork
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("The DAO Hard Fork"); fork.Block.Cmp(big.NewInt(1920000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("The DAO Hard Fork"); fork.Block.Cmp(big.NewInt(1885000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// GasReprice
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice"); fork.Block.Cmp(big.NewInt(2500000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("GasReprice"); fork.Block.Cmp(big.NewInt(1783000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// Diehard
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("Diehard"); fork.Block.Cmp(big.NewInt(3000000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("Diehard"); fork.Block.Cmp(big.NewInt(1915000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// Gotham
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("Gotham"); fork.Block.Cmp(big.NewInt(5000000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("Gotham"); fork.Block.Cmp(big.NewInt(2000000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
+	forks := []struct {
+		Name    string
+		Mainnet int64
+		Morden  int64
+	}{
+		{"Diehard", 1, 1},
+		{"Hardfork1", 2022222, 10},
+		{"LYRA2", 2022222, 10},
+		{"Hardfork2", 2619000, 34},
+		{"LYRA2v2", 2619000, 34},
+	}
+	for _, f := range forks {
+		if fork := DefaultConfigMainnet.ChainConfig.ForkByName(f.Name); fork == nil || fork.Block.Cmp(big.NewInt(f.Mainnet)) != 0 {
+			t.Errorf("Unexpected %s fork: %v", f.Name, fork)
+		}
+		if fork := DefaultConfigMorden.ChainConfig.ForkByName(f.Name); fork == nil || fork.Block.Cmp(big.NewInt(f.Morden)) != 0 {
+			t.Errorf("Unexpected %s fork: %v", f.Name, fork)
+		}
 	}
 
 	checks := []struct {
@@ -79,154 +63,52 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 	}{
 		{
 			Config: DefaultConfigMorden,
-			Block:  big.NewInt(494000),
-			Name:   "Homestead",
-			Features: []*ForkFeature{
-				{
-					ID: "difficulty",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-			},
-		},
-		{
-			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(1150000),
-			Name:   "Homestead",
-			Features: []*ForkFeature{
-				{
-					ID: "difficulty",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-			},
-		},
-		{
-			Config: DefaultConfigMorden,
-			Block:  big.NewInt(1885000),
-			Name:   "The DAO Hard Fork",
-		},
-		{
-			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(1920000),
-			Name:   "The DAO Hard Fork",
-		},
-		{
-			Config: DefaultConfigMorden,
-			Block:  big.NewInt(1783000),
-			Name:   "GasReprice",
-			Features: []*ForkFeature{
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "eip150",
-					},
-				},
-			},
-		},
-		{
-			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(2500000),
-			Name:   "GasReprice",
-			Features: []*ForkFeature{
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "eip150",
-					},
-				},
-			},
-		},
-		{
-			Config: DefaultConfigMorden,
-			Block:  big.NewInt(1915000),
+			Block:  big.NewInt(1),
 			Name:   "Diehard",
 			Features: []*ForkFeature{
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "eip160",
-					},
-				},
 				{
 					ID: "eip155",
 					Options: ChainFeatureConfigOptions{
-						"chainID": big.NewInt(62),
-					},
-				},
-				{
-					ID: "difficulty",
-					Options: ChainFeatureConfigOptions{
-						"length": big.NewInt(2000000),
-						"type":   "ecip1010",
+						"chainID": big.NewInt(111),
 					},
 				},
 			},
 		},
 		{
 			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(3000000),
+			Block:  big.NewInt(1),
 			Name:   "Diehard",
 			Features: []*ForkFeature{
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "eip160",
-					},
-				},
 				{
 					ID: "eip155",
 					Options: ChainFeatureConfigOptions{
-						"chainID": big.NewInt(61),
-					},
-				},
-				{
-					ID: "difficulty",
-					Options: ChainFeatureConfigOptions{
-						"length": big.NewInt(2000000),
-						"type":   "ecip1010",
+						"chainID": big.NewInt(101),
 					},
 				},
 			},
 		},
 		{
 			Config: DefaultConfigMorden,
-			Block:  big.NewInt(2000000),
-			Name:   "Gotham",
+			Block:  big.NewInt(10),
+			Name:   "Hardfork1",
 			Features: []*ForkFeature{
 				{
-					ID: "reward",
+					ID: "eip155",
 					Options: ChainFeatureConfigOptions{
-						"era":  big.NewInt(2000000),
-						"type": "ecip1017",
+						"chainID": big.NewInt(24485),
 					},
 				},
 			},
 		},
 		{
 			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(5000000),
-			Name:   "Gotham",
+			Block:  big.NewInt(2022222),
+			Name:   "Hardfork1",
 			Features: []*ForkFeature{
 				{
-					ID: "reward",
+					ID: "eip155",
 					Options: ChainFeatureConfigOptions{
-						"era":  big.NewInt(5000000),
-						"type": "ecip1017",
+						"chainID": big.NewInt(24484),
 					},
 				},
 			},
@@ -242,18 +124,17 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 			if !ok {
 				t.Errorf("unfound fork feat: %s", feat.ID)
 			}
-			for k, v := range ff.Options {
-				switch v.(type) {
+			for k, want := range feat.Options {
+				switch want := want.(type) {
 				case *big.Int:
-					if big.NewInt(feat.Options[k].(int64)).Cmp(big.NewInt(v.(int64))) != 0 {
-						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, feat.Options[k], k, v)
+					if got, ok := ff.GetBigInt(k); !ok || got.Cmp(want) != 0 {
+						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, got, k, want)
 					}
 				case string:
-					if feat.Options[k] != v {
-						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, feat.Options[k], k, v)
+					if got, ok := ff.GetString(k); !ok || got != want {
+						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, got, k, want)
 					}
 				}
-
 			}
 			if f.Block.Cmp(check.Block) != 0 {
 				t.Errorf("feature fork block wrong: got: %v, want: %v", f.Block, check.Block)
@@ -262,11 +143,11 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 	}
 
 	// Number of bootstrap nodes
-	if l := len(DefaultConfigMainnet.ParsedBootstrap); l != 10 {
-		t.Errorf("got: %v, want: %v", l, 10)
+	if l := len(DefaultConfigMainnet.ParsedBootstrap); l != 2 {
+		t.Errorf("got: %v, want: %v", l, 2)
 	}
-	if l := len(DefaultConfigMorden.ParsedBootstrap); l != 15 {
-		t.Errorf("got: %v, want: %v", l, 7)
+	if l := len(DefaultConfigMorden.ParsedBootstrap); l != 0 {
+		t.Errorf("got: %v, want: %v", l, 0)
 	}
 
 	// Config validity checks.
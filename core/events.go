@@ -29,6 +29,15 @@ import (
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxDroppedEvent is posted when a transaction is rejected on entry to the
+// transaction pool, or evicted from it without being mined.
+type TxDroppedEvent struct {
+	Tx      *types.Transaction
+	Sender  common.Address
+	Reason  error
+	Evicted bool // whether the transaction was evicted, rather than rejected
+}
+
 // TxPostEvent is posted when a transaction has been processed.
 type TxPostEvent struct{ Tx *types.Transaction }
 
@@ -46,6 +55,13 @@ type NewBlockEvent struct{ Block *types.Block }
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct{ Block *types.Block }
 
+// BadBlockEvent is posted when a block fails validation or processing during
+// import.
+type BadBlockEvent struct {
+	Block *types.Block
+	Err   error
+}
+
 // RemovedTransactionEvent is posted when a reorg happens
 type RemovedTransactionEvent struct{ Txs types.Transactions }
 
@@ -35,14 +35,18 @@ var (
 
 // Call executes within the given contract
 func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
+	traceExit := traceEnter(env, vm.CALL, caller.Address(), addr, input, gas, value)
 	ret, _, err = exec(env, caller, &addr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, value, false)
+	traceExit(ret, err)
 	return ret, err
 }
 
 // CallCode executes the given address' code as the given contract address
 func CallCode(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
 	callerAddr := caller.Address()
+	traceExit := traceEnter(env, vm.CALLCODE, callerAddr, addr, input, gas, value)
 	ret, _, err = exec(env, caller, &callerAddr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, value, false)
+	traceExit(ret, err)
 	return ret, err
 }
 
@@ -51,19 +55,30 @@ func DelegateCall(env vm.Environment, caller vm.ContractRef, addr common.Address
 	callerAddr := caller.Address()
 	originAddr := env.Origin()
 	callerValue := caller.Value()
+	traceExit := traceEnter(env, vm.DELEGATECALL, callerAddr, addr, input, gas, nil)
 	ret, _, err = execDelegateCall(env, caller, &originAddr, &callerAddr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, callerValue)
+	traceExit(ret, err)
 	return ret, err
 }
 
 // StaticCall executes within the given contract and throws exception if state is attempted to be changed
 func StaticCall(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice *big.Int) (ret []byte, err error) {
+	traceExit := traceEnter(env, vm.STATICCALL, caller.Address(), addr, input, gas, nil)
 	ret, _, err = exec(env, caller, &addr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, new(big.Int), true)
+	traceExit(ret, err)
 	return ret, err
 }
 
 // Create creates a new contract with the given code
 func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPrice, value *big.Int) (ret []byte, address common.Address, err error) {
+	traceExit := func([]byte, error) {}
+	if tracer(env) != nil || frameTracer(env) != nil {
+		// The address is derived the same way exec does before bumping the nonce
+		to := crypto.CreateAddress(caller.Address(), env.Db().GetNonce(caller.Address()))
+		traceExit = traceEnter(env, vm.CREATE, caller.Address(), to, code, gas, value)
+	}
 	ret, address, err = exec(env, caller, nil, nil, crypto.Keccak256Hash(code), nil, code, gas, gasPrice, value, false)
+	traceExit(ret, err)
 	// Here we get an error if we run into maximum stack depth,
 	// See: https://github.com/ethereum/yellowpaper/pull/131
 	// and YP definitions for CREATE
@@ -75,6 +90,53 @@ func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPric
 	return ret, address, err
 }
 
+// tracer returns the tracer attached to the EVM of the given environment, or
+// nil if tracing is disabled.
+func tracer(env vm.Environment) vm.Tracer {
+	if evm, ok := env.Vm().(*vm.EVM); ok {
+		if cfg := evm.Config(); cfg.Debug {
+			return cfg.Tracer
+		}
+	}
+	return nil
+}
+
+// frameTracer returns the frame tracer attached to the EVM of the given
+// environment, or nil if there is none.
+func frameTracer(env vm.Environment) vm.FrameTracer {
+	if evm, ok := env.Vm().(*vm.EVM); ok {
+		return evm.Config().FrameTracer
+	}
+	return nil
+}
+
+// traceEnter notifies the tracer and frame tracer of the environment, if any,
+// that a new call frame is entered. The returned function must be called with
+// the outcome of the frame once it is left. The gas is read again on exit as
+// it holds the gas left over by the frame.
+func traceEnter(env vm.Environment, typ vm.OpCode, from, to common.Address, input []byte, gas, value *big.Int) func(ret []byte, err error) {
+	t, ft := tracer(env), frameTracer(env)
+	if t == nil && ft == nil {
+		return func([]byte, error) {}
+	}
+	initialGas := new(big.Int).Set(gas)
+	if t != nil {
+		t.CaptureEnter(env, typ, from, to, input, initialGas, value)
+	}
+	if ft != nil {
+		ft.CaptureEnter(env, typ, from, to, input, initialGas, value)
+	}
+	return func(ret []byte, err error) {
+		gasUsed := new(big.Int).Sub(initialGas, gas)
+		if t != nil {
+			t.CaptureExit(ret, gasUsed, err)
+		}
+		if ft != nil {
+			ft.CaptureExit(ret, gasUsed, err)
+		}
+	}
+}
+
 func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.Address, codeHash common.Hash, input, code []byte, gas, gasPrice, value *big.Int, readOnly bool) (ret []byte, addr common.Address, err error) {
 	evm := env.Vm()
 	// Depth check execution. Fail if we're trying to execute above the
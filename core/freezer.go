@@ -0,0 +1,557 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+const (
+	// DefaultAncientDepth is the default number of recent blocks kept out of
+	// the freezer.
+	DefaultAncientDepth = 90000
+
+	// MinAncientDepth is the least number of recent blocks kept out of the
+	// freezer, so that only blocks which can't be reorganised away are frozen.
+	MinAncientDepth = 1024
+
+	freezerRecheckInterval = time.Minute // interval between checks for blocks to freeze
+	freezerBatchLimit      = 2048        // maximum number of blocks moved before syncing the freezer
+)
+
+// Tables of the freezer, holding one item per frozen block.
+const (
+	freezerHashTable       = "hashes"
+	freezerHeaderTable     = "headers"
+	freezerBodiesTable     = "bodies"
+	freezerReceiptTable    = "receipts"
+	freezerDifficultyTable = "diffs"
+)
+
+var freezerTables = []string{freezerHashTable, freezerHeaderTable, freezerBodiesTable, freezerReceiptTable, freezerDifficultyTable}
+
+var (
+	errFreezerOutOfBounds = errors.New("out of bounds")
+	errFreezerOutOfOrder  = errors.New("freezer items must be appended in order")
+)
+
+// freezerTable is an append-only flat file of items, with an index file of the
+// end offset of every item in it.
+type freezerTable struct {
+	name  string
+	index *os.File // 8 byte big endian end offsets of the items in data
+	data  *os.File // concatenated items
+	items uint64   // number of items in the table
+	size  uint64   // number of bytes of data used by the items
+}
+
+// openFreezerTable opens or creates the table with the given name in dir,
+// dropping any item left incomplete by a crash.
+func openFreezerTable(dir, name string) (*freezerTable, error) {
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	t := &freezerTable{name: name, index: index, data: data}
+
+	indexStat, err := index.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	dataStat, err := data.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	// Drop the items whose data didn't make it to disk
+	items := uint64(indexStat.Size()) / 8
+	for ; items > 0; items-- {
+		end, err := t.offset(items)
+		if err != nil {
+			t.close()
+			return nil, err
+		}
+		if end <= uint64(dataStat.Size()) {
+			break
+		}
+	}
+	if err := t.truncate(items); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// offset returns the end offset of the data of the first n items.
+func (t *freezerTable) offset(n uint64) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	var buf [8]byte
+	if _, err := t.index.ReadAt(buf[:], int64((n-1)*8)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// truncate drops all but the first n items of the table.
+func (t *freezerTable) truncate(n uint64) error {
+	size, err := t.offset(n)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64(n * 8)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = n, size
+	return nil
+}
+
+// append adds the item with the given number, which must be the next one.
+func (t *freezerTable) append(item uint64, blob []byte) error {
+	if item != t.items {
+		return errFreezerOutOfOrder
+	}
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(buf[:], int64(t.items*8)); err != nil {
+		return err
+	}
+	t.items++
+	t.size += uint64(len(blob))
+	return nil
+}
+
+// retrieve returns the item with the given number.
+func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
+	if item >= t.items {
+		return nil, errFreezerOutOfBounds
+	}
+	start, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.offset(item + 1)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// sync flushes the table to disk, data first.
+func (t *freezerTable) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *freezerTable) close() error {
+	errIndex, errData := t.index.Close(), t.data.Close()
+	if errIndex != nil {
+		return errIndex
+	}
+	return errData
+}
+
+// Freezer is an append-only store of the finalized blocks of the canonical
+// chain, indexed by block number. Each kind of block data lives in its own
+// freezerTable.
+type Freezer struct {
+	lock   sync.RWMutex
+	dir    string
+	tables map[string]*freezerTable
+	frozen uint64 // number of blocks in all tables
+}
+
+// NewFreezer opens or creates the freezer in dir. Blocks left incomplete by a
+// crash are dropped.
+func NewFreezer(dir string) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f := &Freezer{
+		dir:    dir,
+		tables: make(map[string]*freezerTable),
+	}
+	for i, name := range freezerTables {
+		table, err := openFreezerTable(dir, name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = table
+		if i == 0 || table.items < f.frozen {
+			f.frozen = table.items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(f.frozen); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// HasFreezer reports whether dir holds a freezer.
+func HasFreezer(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, freezerHashTable+".idx"))
+	return err == nil
+}
+
+// Frozen returns the number of frozen blocks.
+func (f *Freezer) Frozen() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.frozen
+}
+
+// Ancient returns an item of the frozen block with the given number.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	table := f.tables[kind]
+	if table == nil {
+		return nil, fmt.Errorf("unknown freezer table %q", kind)
+	}
+	if number >= f.frozen {
+		return nil, errFreezerOutOfBounds
+	}
+	return table.retrieve(number)
+}
+
+// appendBlock adds the items of the next block to the freezer. The freezer
+// must be synced before the frozen data is deleted elsewhere.
+func (f *Freezer) appendBlock(number uint64, items map[string][]byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if number != f.frozen {
+		return errFreezerOutOfOrder
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].append(number, items[name]); err != nil {
+			// Drop whatever part of the block made it in
+			for _, table := range f.tables {
+				table.truncate(f.frozen)
+			}
+			return err
+		}
+	}
+	f.frozen++
+	return nil
+}
+
+// truncate drops the frozen blocks from number n on.
+func (f *Freezer) truncate(n uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if n >= f.frozen {
+		return nil
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(n); err != nil {
+			return err
+		}
+	}
+	f.frozen = n
+	return nil
+}
+
+// sync flushes all tables to disk.
+func (f *Freezer) sync() error {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	for _, table := range f.tables {
+		if err := table.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the files of the freezer.
+func (f *Freezer) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var err error
+	for _, table := range f.tables {
+		if e := table.close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	f.tables = make(map[string]*freezerTable)
+	return err
+}
+
+// FreezerDatabase is a chain database whose finalized blocks are moved to a
+// Freezer. The block getters of this package fall back to the freezer for
+// data missing from the key-value store.
+type FreezerDatabase struct {
+	ethdb.Database
+	freezer *Freezer
+	depth   uint64
+	lock    sync.Mutex // serializes moving blocks to the freezer and truncating it
+
+	start sync.Once
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewFreezerDatabase wraps db with the freezer in dir. If depth is not zero,
+// the blocks of the canonical chain older than depth are moved to the freezer
+// in the background once the database is started.
+func NewFreezerDatabase(db ethdb.Database, dir string, depth uint64) (*FreezerDatabase, error) {
+	freezer, err := NewFreezer(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &FreezerDatabase{
+		Database: db,
+		freezer:  freezer,
+		depth:    depth,
+		quit:     make(chan struct{}),
+	}, nil
+}
+
+// Start starts moving old blocks to the freezer in the background, if the
+// database has a freezing depth. Close stops it.
+func (db *FreezerDatabase) Start() {
+	if db.depth == 0 {
+		return
+	}
+	db.start.Do(func() {
+		db.wg.Add(1)
+		go db.loop()
+	})
+}
+
+// Freezer returns the freezer of the database.
+func (db *FreezerDatabase) Freezer() *Freezer {
+	return db.freezer
+}
+
+// Close stops freezing blocks, and closes the freezer and the key-value store.
+func (db *FreezerDatabase) Close() {
+	close(db.quit)
+	db.wg.Wait()
+
+	if err := db.freezer.Close(); err != nil {
+		glog.Errorf("eth: freezer %s: %s", db.freezer.dir, err)
+	}
+	db.Database.Close()
+}
+
+// loop periodically moves the blocks which have become old enough to the
+// freezer.
+func (db *FreezerDatabase) loop() {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(freezerRecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		head := GetHeader(db, GetHeadBlockHash(db))
+		if head != nil && head.Number.Uint64() > db.depth {
+			limit := head.Number.Uint64() - db.depth
+			if frozen, err := db.Freeze(limit, db.quit, nil); err != nil {
+				glog.V(logger.Error).Errorf("Failed to freeze ancient blocks: %v", err)
+			} else if frozen > 0 {
+				glog.V(logger.Info).Infof("Moved %d ancient blocks to the freezer, %d frozen", frozen, db.freezer.Frozen())
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-db.quit:
+			return
+		}
+	}
+}
+
+// Freeze moves the blocks of the canonical chain below limit to the freezer,
+// starting right after the last frozen block. It stops early at a block which
+// is not fully available, or once abort is closed, and returns the number of
+// blocks moved. progress, if not nil, is called with the number of frozen
+// blocks after each batch.
+func (db *FreezerDatabase) Freeze(limit uint64, abort <-chan struct{}, progress func(frozen uint64)) (int, error) {
+	moved := 0
+	for {
+		first := db.freezer.Frozen()
+		if first >= limit {
+			return moved, nil
+		}
+		last := first + freezerBatchLimit
+		if last > limit {
+			last = limit
+		}
+		blocks, err := db.freezeBatch(first, last)
+		moved += blocks
+		if err != nil {
+			return moved, err
+		}
+		if progress != nil {
+			progress(db.freezer.Frozen())
+		}
+		if blocks < int(last-first) {
+			return moved, nil
+		}
+		select {
+		case <-abort:
+			return moved, nil
+		default:
+		}
+	}
+}
+
+// Truncate drops the frozen blocks from number n on, so that the blocks
+// imported at their numbers after the chain is rewound are frozen in turn.
+//
+// The number lookups are deleted first, so that a crash at any point leaves
+// no lookup pointing at a frozen block of another hash.
+func (db *FreezerDatabase) Truncate(n uint64) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	frozen := db.freezer.Frozen()
+	for number := n; number < frozen; number++ {
+		hash, err := db.freezer.Ancient(freezerHashTable, number)
+		if err != nil {
+			return err
+		}
+		if err := db.Database.Delete(append(ancientNumberPrefix, hash...)); err != nil {
+			return err
+		}
+	}
+	if err := db.freezer.truncate(n); err != nil {
+		return err
+	}
+	return db.freezer.sync()
+}
+
+// freezeBatch moves the canonical blocks in [first, last) to the freezer.
+//
+// The number lookups are written first and the key-value data is deleted only
+// once the freezer is synced, so that a crash at any point leaves every block
+// readable, and the move is simply resumed.
+func (db *FreezerDatabase) freezeBatch(first, last uint64) (int, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// The freezer may have been truncated since first was read
+	if first != db.freezer.Frozen() {
+		return 0, nil
+	}
+	type frozenBlock struct {
+		hash  common.Hash
+		items map[string][]byte
+	}
+	var blocks []frozenBlock
+	for number := first; number < last; number++ {
+		hash := GetCanonicalHash(db.Database, number)
+		if (hash == common.Hash{}) {
+			break
+		}
+		header, body := GetHeaderRLP(db.Database, hash), GetBodyRLP(db.Database, hash)
+		if len(header) == 0 || len(body) == 0 {
+			break
+		}
+		td, _ := db.Database.Get(append(append(blockPrefix, hash.Bytes()...), tdSuffix...))
+		receipts, _ := db.Database.Get(append(blockReceiptsPrefix, hash.Bytes()...))
+
+		blocks = append(blocks, frozenBlock{hash: hash, items: map[string][]byte{
+			freezerHashTable:       hash.Bytes(),
+			freezerHeaderTable:     header,
+			freezerBodiesTable:     body,
+			freezerReceiptTable:    receipts,
+			freezerDifficultyTable: td,
+		}})
+	}
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+	batch := db.Database.NewBatch()
+	for i, block := range blocks {
+		enc := make([]byte, 8)
+		binary.BigEndian.PutUint64(enc, first+uint64(i))
+		if err := batch.Put(append(ancientNumberPrefix, block.hash.Bytes()...), enc); err != nil {
+			return 0, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	for i, block := range blocks {
+		if err := db.freezer.appendBlock(first+uint64(i), block.items); err != nil {
+			return i, err
+		}
+	}
+	if err := db.freezer.sync(); err != nil {
+		return 0, err
+	}
+	for _, block := range blocks {
+		DeleteBlock(db.Database, block.hash)
+	}
+	return len(blocks), nil
+}
+
+// readAncient retrieves an item of the frozen block with the given hash, if db
+// is a FreezerDatabase which holds it.
+func readAncient(db ethdb.Database, kind string, hash common.Hash) []byte {
+	fdb, ok := db.(*FreezerDatabase)
+	if !ok {
+		return nil
+	}
+	enc, _ := fdb.Database.Get(append(ancientNumberPrefix, hash.Bytes()...))
+	if len(enc) != 8 {
+		return nil
+	}
+	blob, _ := fdb.freezer.Ancient(kind, binary.BigEndian.Uint64(enc))
+	return blob
+}
@@ -0,0 +1,260 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+// writeFreezerTestChain writes a canonical chain of n blocks, with receipts
+// and total difficulties, and returns the blocks.
+func writeFreezerTestChain(t *testing.T, db ethdb.Database, n int) []*types.Block {
+	var blocks []*types.Block
+	parent := common.Hash{}
+	for i := 0; i < n; i++ {
+		block := types.NewBlockWithHeader(&types.Header{
+			ParentHash:  parent,
+			Number:      big.NewInt(int64(i)),
+			Difficulty:  big.NewInt(int64(i + 1)),
+			Extra:       []byte("freezer test block"),
+			UncleHash:   types.EmptyUncleHash,
+			TxHash:      types.EmptyRootHash,
+			ReceiptHash: types.EmptyRootHash,
+		})
+		receipts := types.Receipts{types.NewReceipt(nil, big.NewInt(int64(i)))}
+		if err := WriteBlock(db, block); err != nil {
+			t.Fatalf("failed to write block %d: %v", i, err)
+		}
+		if err := WriteTd(db, block.Hash(), big.NewInt(int64(i*10))); err != nil {
+			t.Fatalf("failed to write td %d: %v", i, err)
+		}
+		if err := WriteBlockReceipts(db, block.Hash(), receipts); err != nil {
+			t.Fatalf("failed to write receipts %d: %v", i, err)
+		}
+		if err := WriteCanonicalHash(db, block.Hash(), uint64(i)); err != nil {
+			t.Fatalf("failed to write canonical hash %d: %v", i, err)
+		}
+		blocks = append(blocks, block)
+		parent = block.Hash()
+	}
+	if err := WriteHeadBlockHash(db, parent); err != nil {
+		t.Fatalf("failed to write head block hash: %v", err)
+	}
+	return blocks
+}
+
+// checkFreezerTestChain checks that all blocks can be read back from db.
+func checkFreezerTestChain(t *testing.T, db ethdb.Database, blocks []*types.Block) {
+	for i, block := range blocks {
+		if header := GetHeader(db, block.Hash()); header == nil || header.Hash() != block.Hash() {
+			t.Fatalf("block %d: header mismatch: have %v", i, header)
+		}
+		if body := GetBody(db, block.Hash()); body == nil {
+			t.Fatalf("block %d: body missing", i)
+		}
+		if td := GetTd(db, block.Hash()); td == nil || td.Int64() != int64(i*10) {
+			t.Fatalf("block %d: td mismatch: have %v, want %d", i, td, i*10)
+		}
+		if receipts := GetBlockReceipts(db, block.Hash()); len(receipts) != 1 || receipts[0].CumulativeGasUsed.Int64() != int64(i) {
+			t.Fatalf("block %d: receipts mismatch: have %v", i, receipts)
+		}
+	}
+}
+
+func TestFreezerDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mem, _ := ethdb.NewMemDatabase()
+	blocks := writeFreezerTestChain(t, mem, 10)
+
+	db, err := NewFreezerDatabase(mem, dir, 0)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	moved, err := db.Freeze(6, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	if moved != 6 || db.Freezer().Frozen() != 6 {
+		t.Fatalf("frozen blocks mismatch: moved %d, frozen %d, want 6", moved, db.Freezer().Frozen())
+	}
+	// Frozen blocks must be gone from the key-value store, the rest must stay
+	for i, block := range blocks {
+		if header := GetHeader(mem, block.Hash()); (header == nil) != (i < 6) {
+			t.Errorf("block %d: key-value store header presence mismatch: have %v, want %v", i, header != nil, i >= 6)
+		}
+	}
+	checkFreezerTestChain(t, db, blocks)
+
+	// Freezing up to the same limit is a no-op
+	if moved, err := db.Freeze(6, nil, nil); moved != 0 || err != nil {
+		t.Fatalf("repeated freeze mismatch: moved %d, err %v", moved, err)
+	}
+	db.Freezer().Close()
+
+	// Simulate a crash in the middle of appending a block and reopen
+	data, err := os.OpenFile(filepath.Join(dir, freezerHeaderTable+".dat"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Write([]byte("garbage"))
+	data.Close()
+	index, err := os.OpenFile(filepath.Join(dir, freezerBodiesTable+".idx"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.Write([]byte{0xff, 0xff, 0xff})
+	index.Close()
+
+	db, err = NewFreezerDatabase(mem, dir, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	if frozen := db.Freezer().Frozen(); frozen != 6 {
+		t.Fatalf("reopened freezer block count mismatch: have %d, want 6", frozen)
+	}
+	if moved, err := db.Freeze(10, nil, nil); moved != 4 || err != nil {
+		t.Fatalf("resumed freeze mismatch: moved %d, err %v", moved, err)
+	}
+	checkFreezerTestChain(t, db, blocks)
+	db.Close()
+}
+
+// Tests that blocks are only frozen in the background once the database is
+// started, and that closing it stops freezing.
+func TestFreezerDatabaseStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mem, _ := ethdb.NewMemDatabase()
+	blocks := writeFreezerTestChain(t, mem, 10)
+
+	db, err := NewFreezerDatabase(mem, dir, 4)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if frozen := db.Freezer().Frozen(); frozen != 0 {
+		t.Fatalf("blocks frozen before start: %d", frozen)
+	}
+	db.Start()
+	db.Start()
+	for deadline := time.Now().Add(time.Second); db.Freezer().Frozen() < 5 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if frozen := db.Freezer().Frozen(); frozen != 5 {
+		t.Fatalf("frozen blocks mismatch: have %d, want 5", frozen)
+	}
+	checkFreezerTestChain(t, db, blocks)
+	db.Close()
+}
+
+// Tests that rewinding the head of a chain below its frozen blocks drops them
+// from the freezer, and that the blocks imported in their place are frozen in
+// turn.
+func TestBlockChainSetHeadFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mem, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(mem)
+	db, err := NewFreezerDatabase(mem, dir, 0)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	defer db.Close()
+
+	gendb, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(gendb)
+	blocks, _ := GenerateChain(DefaultConfigMorden.ChainConfig, genesis, gendb, 10, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	forks, _ := GenerateChain(DefaultConfigMorden.ChainConfig, blocks[3], gendb, 6, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{0xff, byte(i + 1)})
+	})
+	bc, err := NewBlockChain(db, DefaultConfigMorden.ChainConfig, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop()
+	if res := bc.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert block %d: %v", res.Index, res.Error)
+	}
+	if _, err := db.Freeze(8, nil, nil); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	// Rewind to block #4, which is frozen along with the three after it
+	if err := bc.SetHead(4); err != nil {
+		t.Fatalf("failed to set head: %v", err)
+	}
+	if frozen := db.Freezer().Frozen(); frozen != 5 {
+		t.Fatalf("frozen blocks mismatch after rewind: have %d, want 5", frozen)
+	}
+	for _, block := range blocks[4:] {
+		if header := GetHeader(db, block.Hash()); header != nil {
+			t.Errorf("block #%d: header still readable after rewind", block.NumberU64())
+		}
+	}
+	checkFreezerTestHeaders(t, db, blocks[:4])
+
+	// The blocks imported in place of the dropped ones are frozen again
+	if res := bc.InsertChain(forks); res.Error != nil {
+		t.Fatalf("failed to insert fork block %d: %v", res.Index, res.Error)
+	}
+	if moved, err := db.Freeze(8, nil, nil); moved != 3 || err != nil {
+		t.Fatalf("refreeze mismatch: moved %d, err %v", moved, err)
+	}
+	checkFreezerTestHeaders(t, db, append(blocks[:4:4], forks...))
+	for _, block := range blocks[4:] {
+		if header := GetHeader(db, block.Hash()); header != nil {
+			t.Errorf("block #%d: dropped header readable after refreeze", block.NumberU64())
+		}
+	}
+}
+
+// checkFreezerTestHeaders checks that the headers of blocks can be read back
+// from db, and that they are canonical.
+func checkFreezerTestHeaders(t *testing.T, db ethdb.Database, blocks []*types.Block) {
+	for _, block := range blocks {
+		if header := GetHeader(db, block.Hash()); header == nil || header.Hash() != block.Hash() {
+			t.Errorf("block #%d: header mismatch: have %v", block.NumberU64(), header)
+		}
+		if hash := GetCanonicalHash(db, block.NumberU64()); hash != block.Hash() {
+			t.Errorf("block #%d: canonical hash mismatch: have %x, want %x", block.NumberU64(), hash, block.Hash())
+		}
+	}
+}
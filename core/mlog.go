@@ -24,6 +24,7 @@ var mLogLinesHeaderchain = []*logger.MLogT{
 var mLogLinesTxPool = []*logger.MLogT{
 	mlogTxPoolAddTx,
 	mlogTxPoolValidateTx,
+	mlogTxPoolDropTx,
 }
 
 // Collect and document available mlog lines.
@@ -147,3 +148,17 @@ If transaction is invalid, TX.ERROR will be non-nil, otherwise it will be nil.`,
 		{Owner: "TX", Key: "ERROR", Value: "STRING_OR_NULL"},
 	},
 }
+
+var mlogTxPoolDropTx = &logger.MLogT{
+	Description: `Called once when a transaction is evicted from the tx pool without being mined.
+DROP.REASON tells why, e.g. it was replaced, the pool limits were exceeded or it was queued for longer than the pool lifetime.`,
+	Receiver: "TXPOOL",
+	Verb:     "DROP",
+	Subject:  "TX",
+	Details: []logger.MLogDetailT{
+		{Owner: "TX", Key: "HASH", Value: "STRING"},
+		{Owner: "TX", Key: "FROM", Value: "STRING"},
+		{Owner: "TX", Key: "NONCE", Value: "INT"},
+		{Owner: "DROP", Key: "REASON", Value: "STRING"},
+	},
+}
@@ -98,6 +98,41 @@ func (self *StateDB) RawDump(addresses []common.Address) Dump {
 	return dump
 }
 
+// RangeAccount is an account listed by AccountRange.
+type RangeAccount struct {
+	Hash    common.Hash     // key of the account in the trie, the hash of its address
+	Address *common.Address // nil if the preimage of the hash is not known
+	Account
+}
+
+// AccountRange lists at most limit accounts of the state trie in the order of
+// the trie, starting at the account with the given hashed address. The hash of
+// the account following the last listed one is returned too, or nil once the
+// end of the trie was reached. Changes not yet written to the trie are not
+// included.
+func (self *StateDB) AccountRange(start common.Hash, limit int) ([]RangeAccount, *common.Hash, error) {
+	var accounts []RangeAccount
+
+	it := trie.NewIterator(self.trie.NodeIterator(start[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if len(accounts) >= limit {
+			return accounts, &hash, nil
+		}
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, nil, err
+		}
+		account := RangeAccount{Hash: hash, Account: data}
+		if preimage := self.trie.GetKey(it.Key); preimage != nil {
+			addr := common.BytesToAddress(preimage)
+			account.Address = &addr
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil, it.Err
+}
+
 const ZipperBlockLength = 1 * 1024 * 1024
 const ZipperPieceLength = 64 * 1024
 
@@ -138,3 +138,117 @@ func (ch addLogChange) undo(s *StateDB) {
 func (ch addPreimageChange) undo(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
+
+// accountOf returns the account an entry of the journal modifies, or nil for
+// entries which don't concern a single account.
+func accountOf(entry journalEntry) *common.Address {
+	switch ch := entry.(type) {
+	case createObjectChange:
+		return ch.account
+	case resetObjectChange:
+		return &ch.prev.address
+	case suicideChange:
+		return ch.account
+	case balanceChange:
+		return ch.account
+	case nonceChange:
+		return ch.account
+	case storageChange:
+		return ch.account
+	case codeChange:
+		return ch.account
+	}
+	return nil
+}
+
+// PrestateAccount is an account as it was before the changes recorded in the
+// journal were made.
+type PrestateAccount struct {
+	Exists  bool
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[common.Hash]common.Hash
+}
+
+// JournalDirties returns the accounts, along with their storage slots, which
+// were modified since the journal was last cleared. As the journal is cleared
+// when the state is finalised, these are the changes made by the current
+// transaction.
+func (self *StateDB) JournalDirties() map[common.Address]map[common.Hash]struct{} {
+	dirties := make(map[common.Address]map[common.Hash]struct{})
+	for _, entry := range self.journal {
+		addr := accountOf(entry)
+		if addr == nil {
+			continue
+		}
+		if dirties[*addr] == nil {
+			dirties[*addr] = make(map[common.Hash]struct{})
+		}
+		if ch, ok := entry.(storageChange); ok {
+			dirties[*addr][ch.key] = struct{}{}
+		}
+	}
+	return dirties
+}
+
+// Prestate returns the account with the given address, and the given slots of
+// its storage, as they were before the changes recorded in the journal. The
+// state itself is left untouched.
+func (self *StateDB) Prestate(addr common.Address, keys []common.Hash) *PrestateAccount {
+	account := &PrestateAccount{
+		Balance: new(big.Int),
+		Storage: make(map[common.Hash]common.Hash, len(keys)),
+	}
+	for _, key := range keys {
+		account.Storage[key] = common.Hash{}
+	}
+	if obj := self.getStateObject(addr); obj != nil {
+		account.Exists = true
+		account.Balance.Set(obj.Balance())
+		account.Nonce = obj.Nonce()
+		account.Code = obj.Code(self.db)
+		for _, key := range keys {
+			account.Storage[key] = obj.GetState(self.db, key)
+		}
+	}
+	// Undo the changes to the account, latest first, so that the value of the
+	// earliest change is what remains.
+	for i := len(self.journal) - 1; i >= 0; i-- {
+		entry := self.journal[i]
+		if a := accountOf(entry); a == nil || *a != addr {
+			continue
+		}
+		switch ch := entry.(type) {
+		case createObjectChange:
+			account.Exists = false
+			account.Balance = new(big.Int)
+			account.Nonce = 0
+			account.Code = nil
+			for _, key := range keys {
+				account.Storage[key] = common.Hash{}
+			}
+		case resetObjectChange:
+			account.Exists = true
+			account.Balance = new(big.Int).Set(ch.prev.Balance())
+			account.Nonce = ch.prev.Nonce()
+			account.Code = ch.prev.Code(self.db)
+			for _, key := range keys {
+				account.Storage[key] = ch.prev.GetState(self.db, key)
+			}
+		case suicideChange:
+			account.Balance = new(big.Int).Set(ch.prevbalance)
+		case balanceChange:
+			account.Balance = new(big.Int).Set(ch.prev)
+		case nonceChange:
+			account.Nonce = ch.prev
+		case codeChange:
+			account.Code = ch.prevcode
+		case storageChange:
+			if _, ok := account.Storage[ch.key]; ok {
+				account.Storage[ch.key] = ch.prevalue
+			}
+		}
+	}
+	return account
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/rlp"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+// VerifyAccountProof checks the merkle proof of the account with the given
+// address, as returned by GetProof or eth_getProof, against a state root. It
+// returns the proven account, or nil if the proof shows that the account does
+// not exist.
+func VerifyAccountProof(root common.Hash, addr common.Address, proof [][]byte) (*Account, error) {
+	value, err := verifyProof(root, crypto.Keccak256(addr[:]), proof)
+	if value == nil || err != nil {
+		return nil, err
+	}
+	var account Account
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return nil, fmt.Errorf("invalid account in proof: %v", err)
+	}
+	return &account, nil
+}
+
+// VerifyStorageProof checks the merkle proof of a storage slot, as returned by
+// GetStorageProof or eth_getProof, against the storage root of its account.
+// It returns the proven value, which is zero for a slot not in the storage.
+func VerifyStorageProof(storageRoot common.Hash, key common.Hash, proof [][]byte) (common.Hash, error) {
+	value, err := verifyProof(storageRoot, crypto.Keccak256(key[:]), proof)
+	if value == nil || err != nil {
+		return common.Hash{}, err
+	}
+	_, content, _, err := rlp.Split(value)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid storage value in proof: %v", err)
+	}
+	return common.BytesToHash(content), nil
+}
+
+// verifyProof checks a proof of the hashed key against the root of a secure
+// trie, accepting the empty proof of an empty trie, which has no nodes.
+func verifyProof(root common.Hash, hashedKey []byte, proof [][]byte) ([]byte, error) {
+	if root == emptyRoot && len(proof) == 0 {
+		return nil, nil
+	}
+	value, err, _ := trie.VerifyProof(root, hashedKey, trie.ProofList(proof))
+	return value, err
+}
@@ -32,6 +32,9 @@ import (
 
 var emptyCodeHash = crypto.Keccak256(nil)
 
+// emptyRoot is the root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
 type Code []byte
 
 func (self Code) String() string {
@@ -206,6 +209,24 @@ func (self *StateObject) SetState(db Database, key, value common.Hash) {
 	self.setState(key, value)
 }
 
+// SetStorage replaces the entire storage of the object. The change is not
+// journaled and cannot be reverted, so it must only be used on states which
+// are thrown away afterwards, like those calls are simulated on.
+func (self *StateObject) SetStorage(storage map[common.Hash]common.Hash) {
+	self.data.Root = emptyRoot
+	self.trie = nil
+	self.cachedStorage = make(Storage)
+	self.dirtyStorage = make(Storage)
+	for key, value := range storage {
+		self.cachedStorage[key] = value
+		self.dirtyStorage[key] = value
+	}
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
 func (self *StateObject) setState(key, value common.Hash) {
 	self.cachedStorage[key] = value
 	self.dirtyStorage[key] = value
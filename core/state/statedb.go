@@ -311,6 +311,15 @@ func (self *StateDB) SetState(addr common.Address, key common.Hash, value common
 	}
 }
 
+// SetStorage replaces the entire storage of the account. It must only be used
+// on states which are thrown away afterwards, see StateObject.SetStorage.
+func (self *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common.Hash) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetStorage(storage)
+	}
+}
+
 // Suicide marks the given account as suicided.
 // This clears the account balance.
 //
@@ -605,6 +614,40 @@ func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (ro
 	return root, err
 }
 
+// StorageTrie returns the storage trie of an account, including the changes
+// not yet committed. The returned trie is a copy, so it can be iterated and
+// modified without affecting the state. Nil is returned if the account does
+// not exist.
+func (self *StateDB) StorageTrie(addr common.Address) Trie {
+	stateObject := self.getStateObject(addr)
+	if stateObject == nil {
+		return nil
+	}
+	cpy := stateObject.deepCopy(self, nil)
+	return cpy.updateTrie(self.db)
+}
+
+// GetProof returns the merkle proof of the account with the given address,
+// from the root of the state trie down. The state trie must be up to date,
+// as changes not yet written to it are not reflected.
+func (self *StateDB) GetProof(addr common.Address) ([][]byte, error) {
+	var proof trie.ProofList
+	err := self.trie.Prove(addr[:], 0, &proof)
+	return proof, err
+}
+
+// GetStorageProof returns the merkle proof of a storage slot of the account
+// with the given address, from the root of its storage trie down.
+func (self *StateDB) GetStorageProof(addr common.Address, key common.Hash) ([][]byte, error) {
+	st := self.StorageTrie(addr)
+	if st == nil {
+		return nil, fmt.Errorf("account %x doesn't exist", addr)
+	}
+	var proof trie.ProofList
+	err := st.Prove(key[:], 0, &proof)
+	return proof, err
+}
+
 func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common.Hash) bool) {
 	so := db.getStateObject(addr)
 	if so == nil {
@@ -422,3 +422,51 @@ func (s *StateSuite) TestTouchDelete(c *check.C) {
 		c.Fatal("expected no dirty state object")
 	}
 }
+
+// Tests that the prestate of an account is rebuilt from the journal, and that
+// doing so leaves the state untouched.
+func TestJournalPrestate(t *testing.T) {
+	mem, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(mem))
+
+	var (
+		existing = common.BytesToAddress([]byte{0x01})
+		created  = common.BytesToAddress([]byte{0x02})
+		key      = common.BytesToHash([]byte{0x03})
+	)
+	state.SetBalance(existing, big.NewInt(10))
+	state.SetNonce(existing, 1)
+	state.SetState(existing, key, common.BytesToHash([]byte{0x04}))
+	state.Finalise(false)
+
+	state.AddBalance(existing, big.NewInt(5))
+	state.SetNonce(existing, 2)
+	state.SetState(existing, key, common.BytesToHash([]byte{0x05}))
+	state.SetState(existing, key, common.BytesToHash([]byte{0x06}))
+	state.SetBalance(created, big.NewInt(7))
+
+	dirties := state.JournalDirties()
+	if len(dirties) != 2 {
+		t.Fatalf("expected 2 dirty accounts, got %d", len(dirties))
+	}
+	if _, ok := dirties[existing][key]; !ok || len(dirties[existing]) != 1 {
+		t.Errorf("expected slot %x of %x to be dirty, got %v", key, existing, dirties[existing])
+	}
+
+	pre := state.Prestate(existing, []common.Hash{key})
+	if !pre.Exists || pre.Balance.Cmp(big.NewInt(10)) != 0 || pre.Nonce != 1 {
+		t.Errorf("unexpected prestate of %x: %+v", existing, pre)
+	}
+	if pre.Storage[key] != common.BytesToHash([]byte{0x04}) {
+		t.Errorf("expected slot value 0x04 before the changes, got %x", pre.Storage[key])
+	}
+	if pre := state.Prestate(created, nil); pre.Exists || pre.Balance.Sign() != 0 {
+		t.Errorf("expected %x not to exist before the changes, got %+v", created, pre)
+	}
+	if balance := state.GetBalance(existing); balance.Cmp(big.NewInt(15)) != 0 {
+		t.Errorf("expected the state to be left untouched, got balance %v", balance)
+	}
+	if value := state.GetState(existing, key); value != common.BytesToHash([]byte{0x06}) {
+		t.Errorf("expected the state to be left untouched, got slot value %x", value)
+	}
+}
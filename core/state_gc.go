@@ -0,0 +1,163 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/rlp"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+const (
+	// DefaultStateGCDepth is the default number of recent block states kept
+	// by a pruning node.
+	DefaultStateGCDepth = 128
+	// MinStateGCDepth is the lowest number of recent block states a pruning
+	// node may keep, so that short reorganisations find their parent state.
+	MinStateGCDepth = 16
+
+	// stateFlushInterval is the number of blocks between two states flushed to
+	// disk by a pruning node.
+	stateFlushInterval = 4096
+	// stateCacheLimit is the size of the trie node cache above which a
+	// pruning node flushes a state before its flush interval.
+	stateCacheLimit = 256 * 1024 * 1024
+)
+
+// PrunedStateError is returned for the state of a block which was garbage
+// collected by a pruning node.
+type PrunedStateError struct {
+	Root common.Hash
+}
+
+func (e *PrunedStateError) Error() string {
+	return fmt.Sprintf("state %x has been pruned, historical states are only kept in archive mode", e.Root)
+}
+
+// stateGC tracks the states a pruning block chain keeps in its trie node
+// cache.
+type stateGC struct {
+	cache     *trie.NodeCache
+	depth     uint64 // number of recent block states kept
+	interval  uint64 // number of blocks between two flushed states
+	limit     int    // cache size forcing an early flush
+	roots     []gcRoot
+	lastFlush uint64 // number of the last block whose state was flushed
+}
+
+type gcRoot struct {
+	number uint64
+	root   common.Hash
+}
+
+// accountReferences returns the storage root and code hash of an account leaf
+// of the state trie, so that the node cache keeps them alive as long as the
+// account. Leaves of storage tries don't decode and reference nothing.
+func accountReferences(leaf []byte) []common.Hash {
+	var account state.Account
+	if err := rlp.DecodeBytes(leaf, &account); err != nil {
+		return nil
+	}
+	return []common.Hash{account.Root, common.BytesToHash(account.CodeHash)}
+}
+
+// commitState writes the state of an imported block. On a pruning chain the
+// state goes to the trie node cache, and the states which fell out of the kept
+// depth are garbage collected.
+func (bc *BlockChain) commitState(block *types.Block, statedb *state.StateDB) error {
+	root, err := statedb.CommitTo(bc.stateDb, false)
+	if err != nil || bc.gc == nil {
+		return err
+	}
+	gc := bc.gc
+	gc.cache.Reference(root)
+	gc.roots = append(gc.roots, gcRoot{block.NumberU64(), root})
+
+	number := block.NumberU64()
+	if number <= gc.depth {
+		return nil
+	}
+	edge := number - gc.depth
+
+	// Flush the state at the edge of the kept depth if it is due, or if the
+	// cache grew too large
+	if size := gc.cache.Size(); edge >= gc.lastFlush+gc.interval || size > gc.limit {
+		if header := bc.GetHeaderByNumber(edge); header != nil {
+			if err := gc.cache.Commit(header.Root); err != nil {
+				return err
+			}
+			glog.V(logger.Debug).Infof("Flushed state of block #%d [%x…], trie node cache %v", edge, header.Hash().Bytes()[:4], common.StorageSize(size))
+			gc.lastFlush = edge
+		}
+	}
+	// Drop the states which fell out of the kept depth
+	kept := gc.roots[:0]
+	for _, r := range gc.roots {
+		if r.number > edge {
+			kept = append(kept, r)
+		} else {
+			gc.cache.Dereference(r.root)
+		}
+	}
+	gc.roots = kept
+
+	return nil
+}
+
+// CommitState writes the state of a block which is added to the chain without
+// being imported, such as a block mined locally.
+func (bc *BlockChain) CommitState(block *types.Block, statedb *state.StateDB) error {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	return bc.commitState(block, statedb)
+}
+
+// FlushState writes the state of the head block of a pruning chain to disk,
+// so that the chain can resume from it. It is a no-op for an archive chain.
+// The caller must hold the chain insertion lock.
+func (bc *BlockChain) FlushState() {
+	if bc.gc == nil {
+		return
+	}
+	head := bc.CurrentBlock()
+	if err := bc.gc.cache.Commit(head.Root()); err != nil {
+		glog.V(logger.Error).Errorf("Failed to flush state of head block #%d: %v", head.NumberU64(), err)
+		return
+	}
+	glog.V(logger.Info).Infof("Flushed state of head block #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4])
+}
+
+// lastStateBlock walks back from block to the latest block whose state is
+// available. It gives up beyond the blocks a pruning node may be missing the
+// state of, returning nil.
+func (bc *BlockChain) lastStateBlock(block *types.Block) *types.Block {
+	limit := bc.gc.depth + bc.gc.interval
+	for i := uint64(0); block != nil && i <= limit; i++ {
+		if _, err := state.New(block.Root(), state.NewDatabase(bc.stateDb)); err == nil {
+			return block
+		}
+		block = bc.GetBlock(block.ParentHash())
+	}
+	return nil
+}
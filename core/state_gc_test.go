@@ -0,0 +1,88 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+func TestStateGC(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(db)
+
+	// Reward a different coinbase in every block, so every block has its own state
+	gendb, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(gendb)
+	blocks, _ := GenerateChain(DefaultConfigMorden.ChainConfig, genesis, gendb, 60, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{byte(i + 1)})
+	})
+
+	bc, err := NewBlockChainWithStateGC(db, DefaultConfigMorden.ChainConfig, FakePow{}, new(event.TypeMux), MinStateGCDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc.gc.interval = 10
+
+	if res := bc.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert block %d: %v", res.Index, res.Error)
+	}
+	// The recent states and the flushed ones must be available, the others pruned
+	head := uint64(len(blocks))
+	for _, block := range blocks {
+		number := block.NumberU64()
+		kept := number > head-MinStateGCDepth || number%10 == 0
+		_, err := bc.StateAt(block.Root())
+		if kept && err != nil {
+			t.Errorf("block #%d: state not available: %v", number, err)
+		}
+		if !kept {
+			if _, pruned := err.(*PrunedStateError); !pruned {
+				t.Errorf("block #%d: state not pruned: err %v", number, err)
+			}
+		}
+	}
+	// Only the flushed states may be in the chain database
+	for _, block := range blocks {
+		number := block.NumberU64()
+		if ok, _ := db.Has(block.Root().Bytes()); ok != (number%10 == 0 && number <= head-MinStateGCDepth) {
+			t.Errorf("block #%d: state root on disk: have %v", number, ok)
+		}
+	}
+	// Stopping must flush the head state, so that the chain resumes from it
+	bc.Stop()
+
+	bc, err = NewBlockChainWithStateGC(db, DefaultConfigMorden.ChainConfig, FakePow{}, new(event.TypeMux), MinStateGCDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop()
+
+	if have := bc.CurrentBlock().NumberU64(); have != head {
+		t.Fatalf("head block mismatch after restart: have #%d, want #%d", have, head)
+	}
+	statedb, err := bc.State()
+	if err != nil {
+		t.Fatalf("head state not available after restart: %v", err)
+	}
+	if balance := statedb.GetBalance(common.Address{byte(head)}); balance.Sign() == 0 {
+		t.Errorf("head coinbase not rewarded in head state")
+	}
+}
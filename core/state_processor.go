@@ -60,6 +60,27 @@ func NewStateProcessor(config *ChainConfig, bc *BlockChain) *StateProcessor {
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
 func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (types.Receipts, vm.Logs, *big.Int, error) {
+	receipts, logs, usedGas, err := p.ProcessWithConfig(block, statedb, nil)
+	if err != nil {
+		return nil, nil, usedGas, err
+	}
+	return receipts, logs, usedGas, nil
+}
+
+// ProcessWithConfig processes the block like Process, but runs each transaction
+// with an EVM set up with the configuration vmConfig returns for it. It is used
+// to replay and trace the transactions of a block. A nil vmConfig makes it
+// equivalent to Process.
+//
+// Unlike Process, on error it returns the receipts of the transactions which
+// were applied before the failing one.
+//
+// SputnikVM offers no tracing hooks, so transactions which are to be traced,
+// with Debug or a FrameTracer, always run on the native EVM, even with
+// UseSputnikVM set. Both VMs produce
+// the same state transitions, so traces are the same whichever VM the node
+// uses.
+func (p *StateProcessor) ProcessWithConfig(block *types.Block, statedb *state.StateDB, vmConfig func(i int, tx *types.Transaction) vm.Config) (types.Receipts, vm.Logs, *big.Int, error) {
 	var (
 		receipts     types.Receipts
 		totalUsedGas = big.NewInt(0)
@@ -73,17 +94,21 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 		if tx.Protected() {
 			chainId := p.config.GetChainID(block.Number())
 			if chainId.Cmp(new(big.Int)) == 0 {
-				return nil, nil, nil, fmt.Errorf("ChainID is not set for EIP-155 in chain configuration at block number: %v. \n  Tx ChainID: %v", block.Number(), tx.ChainId())
+				return receipts, nil, totalUsedGas, fmt.Errorf("ChainID is not set for EIP-155 in chain configuration at block number: %v. \n  Tx ChainID: %v", block.Number(), tx.ChainId())
 			}
 			if tx.ChainId() == nil || tx.ChainId().Cmp(chainId) != 0 {
-				return nil, nil, nil, fmt.Errorf("Invalid transaction chain id. Current chain id: %v tx chain id: %v", chainId, tx.ChainId())
+				return receipts, nil, totalUsedGas, fmt.Errorf("Invalid transaction chain id. Current chain id: %v tx chain id: %v", chainId, tx.ChainId())
 			}
 		}
 		statedb.StartRecord(tx.Hash(), block.Hash(), i)
-		if !UseSputnikVM {
-			receipt, logs, _, err := ApplyTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas)
+		var cfg vm.Config
+		if vmConfig != nil {
+			cfg = vmConfig(i, tx)
+		}
+		if !UseSputnikVM || cfg.Debug || cfg.FrameTracer != nil {
+			receipt, logs, _, err := ApplyTransactionWithConfig(p.config, p.bc, gp, statedb, header, tx, totalUsedGas, cfg)
 			if err != nil {
-				return nil, nil, totalUsedGas, err
+				return receipts, nil, totalUsedGas, err
 			}
 			receipts = append(receipts, receipt)
 			allLogs = append(allLogs, logs...)
@@ -91,7 +116,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 		}
 		receipt, logs, _, err := ApplyMultiVmTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas)
 		if err != nil {
-			return nil, nil, totalUsedGas, err
+			return receipts, nil, totalUsedGas, err
 		}
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, logs...)
@@ -107,9 +132,15 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 // ApplyTransactions returns the generated receipts and vm logs during the
 // execution of the state transition phase.
 func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int) (*types.Receipt, vm.Logs, *big.Int, error) {
+	return ApplyTransactionWithConfig(config, bc, gp, statedb, header, tx, usedGas, vm.Config{})
+}
+
+// ApplyTransactionWithConfig is like ApplyTransaction, but runs the transaction
+// with an EVM set up with the given configuration.
+func ApplyTransactionWithConfig(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int, cfg vm.Config) (*types.Receipt, vm.Logs, *big.Int, error) {
 	tx.SetSigner(config.GetSigner(header.Number))
 
-	_, gas, err := ApplyMessage(NewEnv(statedb, config, bc, tx, header), tx, gp)
+	_, gas, err := ApplyMessage(NewEnvWithConfig(statedb, config, bc, tx, header, cfg), tx, gp)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -16,6 +16,9 @@ import (
 
 var (
 	defaultEraLength *big.Int = big.NewInt(5000000)
+	rewardEraLength  *big.Int = big.NewInt(100000)
+
+	MaximumBlockReward = new(big.Int).Mul(big.NewInt(5e+18), big.NewInt(10)) // 50 WEB
 )
 
 // Unit tests.
@@ -78,21 +81,21 @@ func TestGetBlockEra2(t *testing.T) {
 func TestGetBlockWinnerRewardByEra(t *testing.T) {
 
 	cases := map[*big.Int]*big.Int{
-		big.NewInt(0):        MaximumBlockReward,
-		big.NewInt(1):        MaximumBlockReward,
-		big.NewInt(4999999):  MaximumBlockReward,
-		big.NewInt(5000000):  MaximumBlockReward,
-		big.NewInt(5000001):  big.NewInt(4e+18),
-		big.NewInt(9999999):  big.NewInt(4e+18),
-		big.NewInt(10000000): big.NewInt(4e+18),
-		big.NewInt(10000001): big.NewInt(3.2e+18),
-		big.NewInt(14999999): big.NewInt(3.2e+18),
-		big.NewInt(15000000): big.NewInt(3.2e+18),
-		big.NewInt(15000001): big.NewInt(2.56e+18),
+		big.NewInt(0):      MaximumBlockReward,
+		big.NewInt(1):      MaximumBlockReward,
+		big.NewInt(99999):  MaximumBlockReward,
+		big.NewInt(100000): MaximumBlockReward,
+		big.NewInt(100001): Era2WinnerReward,
+		big.NewInt(199999): Era2WinnerReward,
+		big.NewInt(200000): Era2WinnerReward,
+		big.NewInt(200001): Era3WinnerReward,
+		big.NewInt(299999): Era3WinnerReward,
+		big.NewInt(300000): Era3WinnerReward,
+		big.NewInt(300001): Era4WinnerReward,
 	}
 
 	for bn, expectedReward := range cases {
-		gotReward := GetBlockWinnerRewardByEra(GetBlockEra(bn, defaultEraLength))
+		gotReward := GetBlockWinnerRewardByEra(GetBlockEra(bn, rewardEraLength))
 		if gotReward.Cmp(expectedReward) != 0 {
 			t.Errorf("@ %v, got: %v, want: %v", bn, gotReward, expectedReward)
 		}
@@ -111,27 +114,28 @@ func TestGetBlockUncleRewardByEra(t *testing.T) {
 	var we1, we2, we3, we4 *big.Int = new(big.Int), new(big.Int), new(big.Int), new(big.Int)
 
 	// manually divide maxblockreward/32 to compare to got
-	we2.Div(GetBlockWinnerRewardByEra(GetBlockEra(big.NewInt(5000001), defaultEraLength)), big.NewInt(32))
-	we3.Div(GetBlockWinnerRewardByEra(GetBlockEra(big.NewInt(10000001), defaultEraLength)), big.NewInt(32))
-	we4.Div(GetBlockWinnerRewardByEra(GetBlockEra(big.NewInt(15000001), defaultEraLength)), big.NewInt(32))
+	we1.Div(MaximumBlockReward, big.NewInt(32))
+	we2.Div(GetBlockWinnerRewardByEra(GetBlockEra(big.NewInt(100001), rewardEraLength)), big.NewInt(32))
+	we3.Div(GetBlockWinnerRewardByEra(GetBlockEra(big.NewInt(200001), rewardEraLength)), big.NewInt(32))
+	we4.Div(GetBlockWinnerRewardByEra(GetBlockEra(big.NewInt(300001), rewardEraLength)), big.NewInt(32))
 
 	cases := map[*big.Int]*big.Int{
-		big.NewInt(0):        nil,
-		big.NewInt(1):        nil,
-		big.NewInt(4999999):  nil,
-		big.NewInt(5000000):  nil,
-		big.NewInt(5000001):  we2,
-		big.NewInt(9999999):  we2,
-		big.NewInt(10000000): we2,
-		big.NewInt(10000001): we3,
-		big.NewInt(14999999): we3,
-		big.NewInt(15000000): we3,
-		big.NewInt(15000001): we4,
+		big.NewInt(0):      we1,
+		big.NewInt(1):      we1,
+		big.NewInt(99999):  we1,
+		big.NewInt(100000): we1,
+		big.NewInt(100001): we2,
+		big.NewInt(199999): we2,
+		big.NewInt(200000): we2,
+		big.NewInt(200001): we3,
+		big.NewInt(299999): we3,
+		big.NewInt(300000): we3,
+		big.NewInt(300001): we4,
 	}
 
 	for bn, want := range cases {
 
-		era := GetBlockEra(bn, defaultEraLength)
+		era := GetBlockEra(bn, rewardEraLength)
 
 		var header, uncle *types.Header = &types.Header{}, &types.Header{}
 		header.Number = bn
@@ -141,20 +145,9 @@ func TestGetBlockUncleRewardByEra(t *testing.T) {
 
 		got := GetBlockUncleRewardByEra(era, header, uncle)
 
-		// "Era 1"
-		if want == nil {
-			we1.Add(uncle.Number, big8)      // 2,534,998 + 8              = 2,535,006
-			we1.Sub(we1, header.Number)      // 2,535,006 - 2,534,999        = 7
-			we1.Mul(we1, MaximumBlockReward) // 7 * 5e+18               = 35e+18
-			we1.Div(we1, big8)               // 35e+18 / 8                            = 7/8 * 5e+18
-
-			if got.Cmp(we1) != 0 {
-				t.Errorf("@ %v, want: %v, got: %v", bn, we1, got)
-			}
-		} else {
-			if got.Cmp(want) != 0 {
-				t.Errorf("@ %v, want: %v, got: %v", bn, want, got)
-			}
+		// Uncles are rewarded 1/32 of the winner reward, whatever their generation.
+		if got.Cmp(want) != 0 {
+			t.Errorf("@ %v, want: %v, got: %v", bn, want, got)
 		}
 	}
 }
@@ -169,30 +162,30 @@ func TestGetBlockWinnerRewardForUnclesByEra(t *testing.T) {
 	we4.Div(GetBlockWinnerRewardByEra(big.NewInt(3)), big.NewInt(32))
 
 	cases := map[*big.Int]*big.Int{
-		big.NewInt(0):        we1,
-		big.NewInt(1):        we1,
-		big.NewInt(4999999):  we1,
-		big.NewInt(5000000):  we1,
-		big.NewInt(5000001):  we2,
-		big.NewInt(9999999):  we2,
-		big.NewInt(10000000): we2,
-		big.NewInt(10000001): we3,
-		big.NewInt(14999999): we3,
-		big.NewInt(15000000): we3,
-		big.NewInt(15000001): we4,
+		big.NewInt(0):      we1,
+		big.NewInt(1):      we1,
+		big.NewInt(99999):  we1,
+		big.NewInt(100000): we1,
+		big.NewInt(100001): we2,
+		big.NewInt(199999): we2,
+		big.NewInt(200000): we2,
+		big.NewInt(200001): we3,
+		big.NewInt(299999): we3,
+		big.NewInt(300000): we3,
+		big.NewInt(300001): we4,
 	}
 
 	var uncleSingle, uncleDouble []*types.Header = []*types.Header{{}}, []*types.Header{{}, {}}
 
 	for bn, want := range cases {
 		// test single uncle
-		got := GetBlockWinnerRewardForUnclesByEra(GetBlockEra(bn, defaultEraLength), uncleSingle)
+		got := GetBlockWinnerRewardForUnclesByEra(GetBlockEra(bn, rewardEraLength), uncleSingle)
 		if got.Cmp(want) != 0 {
 			t.Errorf("@ %v: want: %v, got: %v", bn, want, got)
 		}
 
 		// test double uncle
-		got = GetBlockWinnerRewardForUnclesByEra(GetBlockEra(bn, defaultEraLength), uncleDouble)
+		got = GetBlockWinnerRewardForUnclesByEra(GetBlockEra(bn, rewardEraLength), uncleDouble)
 		dub := new(big.Int)
 		if got.Cmp(dub.Mul(want, big.NewInt(2))) != 0 {
 			t.Errorf("@ %v: want: %v, got: %v", bn, want, got)
@@ -251,6 +244,13 @@ func calculateExpectedEraRewards(era expectedEraForTesting, numUncles int) expec
 		wr = Era4WinnerReward
 		wur = Era4WinnerUncleReward
 		ur = Era4UncleReward
+	default:
+		// MaximumBlockReward * (249/250)**(era-1), as for the 4 eras above.
+		n := big.NewInt(int64(era - 1))
+		wr = new(big.Int).Mul(MaximumBlockReward, new(big.Int).Exp(big.NewInt(249), n, nil))
+		wr.Div(wr, new(big.Int).Exp(big.NewInt(250), n, nil))
+		wur = new(big.Int).Div(wr, big.NewInt(32))
+		ur = wur
 	}
 	return expectedRewards{
 		WinnerCoinbase: new(big.Int).Add(wr, new(big.Int).Mul(wur, big.NewInt(int64(numUncles)))),
@@ -266,8 +266,8 @@ func expectedEraFromBlockNumber(i, eralen *big.Int, t *testing.T) expectedEraFor
 	ePlusOne := new(big.Int).Add(e, big.NewInt(1)) // since expectedEraForTesting is not 0-indexed; iota + 1
 	ei := ePlusOne.Int64()
 	expEra := int(ei)
-	if expEra > 4 || expEra < 1 {
-		t.Fatalf("Unexpected era value, want 1 < e < 5, got: %d", expEra)
+	if expEra < 1 {
+		t.Fatalf("Unexpected era value, want 1 <= e, got: %d", expEra)
 	}
 	return expectedEraForTesting(expEra)
 }
@@ -313,7 +313,7 @@ func makeExpectedRewardCasesForConfig(c *ChainConfig, numUncles int, t *testing.
 	eraLen := new(big.Int)
 	feat, _, configured := c.HasFeature("reward")
 	if !configured {
-		eraLen = defaultEraLength
+		eraLen = rewardEraLength
 	} else {
 		elen, ok := feat.GetBigInt("era")
 		if !ok {
@@ -341,7 +341,8 @@ func makeExpectedRewardCasesForConfig(c *ChainConfig, numUncles int, t *testing.
 		for _, d := range boundaryDiffs {
 			fnb := new(big.Int).Add(fn, big.NewInt(d))
 			if fnb.Sign() < 1 {
-				t.Fatalf("unexpected 0 or neg block number: %d", fnb)
+				// Forks at the first blocks have no preceding boundary to test.
+				continue
 			}
 			expEra := expectedEraFromBlockNumber(fnb, eraLen, t)
 
@@ -395,7 +396,7 @@ func TestAccumulateRewards1(t *testing.T) {
 				ID: "reward",
 				Options: ChainFeatureConfigOptions{
 					"type": "ecip1017",
-					"era":  5000000, // for mainnet will be 5m
+					"era":  100000, // as hardcoded in AccumulateRewards
 				},
 			})
 			feat, _, exists = config.HasFeature("reward")
@@ -496,21 +497,21 @@ var (
 	Uncle1Coinbase = common.StringToAddress("0000000000000000000000000000000000000002")
 	Uncle2Coinbase = common.StringToAddress("0000000000000000000000000000000000000003")
 
-	Era1WinnerReward      = big.NewInt(5e+18)
-	Era1WinnerUncleReward = big.NewInt(156250000000000000)
-	Era1UncleReward       = big.NewInt(4375000000000000000)
+	Era1WinnerReward      = new(big.Int).Set(MaximumBlockReward)        // 50 WEB
+	Era1WinnerUncleReward = new(big.Int).Div(MaximumBlockReward, big32) // 1.5625 WEB
+	Era1UncleReward       = new(big.Int).Div(MaximumBlockReward, big32) // 1.5625 WEB
 
-	Era2WinnerReward      = big.NewInt(4e+18)
-	Era2WinnerUncleReward = new(big.Int).Div(big.NewInt(4e+18), big32)
-	Era2UncleReward       = new(big.Int).Div(big.NewInt(4e+18), big32)
+	Era2WinnerReward      = new(big.Int).Mul(new(big.Int).Div(Era1WinnerReward, big.NewInt(250)), big.NewInt(249))
+	Era2WinnerUncleReward = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era1WinnerReward, big.NewInt(250)), big.NewInt(249)), big32)
+	Era2UncleReward       = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era1WinnerReward, big.NewInt(250)), big.NewInt(249)), big32)
 
-	Era3WinnerReward      = new(big.Int).Mul(new(big.Int).Div(Era2WinnerReward, big.NewInt(5)), big.NewInt(4))
-	Era3WinnerUncleReward = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era2WinnerReward, big.NewInt(5)), big.NewInt(4)), big32)
-	Era3UncleReward       = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era2WinnerReward, big.NewInt(5)), big.NewInt(4)), big32)
+	Era3WinnerReward      = new(big.Int).Mul(new(big.Int).Div(Era2WinnerReward, big.NewInt(250)), big.NewInt(249))
+	Era3WinnerUncleReward = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era2WinnerReward, big.NewInt(250)), big.NewInt(249)), big32)
+	Era3UncleReward       = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era2WinnerReward, big.NewInt(250)), big.NewInt(249)), big32)
 
-	Era4WinnerReward      = new(big.Int).Mul(new(big.Int).Div(Era3WinnerReward, big.NewInt(5)), big.NewInt(4))
-	Era4WinnerUncleReward = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era3WinnerReward, big.NewInt(5)), big.NewInt(4)), big32)
-	Era4UncleReward       = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era3WinnerReward, big.NewInt(5)), big.NewInt(4)), big32)
+	Era4WinnerReward      = new(big.Int).Mul(new(big.Int).Div(Era3WinnerReward, big.NewInt(250)), big.NewInt(249))
+	Era4WinnerUncleReward = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era3WinnerReward, big.NewInt(250)), big.NewInt(249)), big32)
+	Era4UncleReward       = new(big.Int).Div(new(big.Int).Mul(new(big.Int).Div(Era3WinnerReward, big.NewInt(250)), big.NewInt(249)), big32)
 )
 
 // Non-accruing over block cases simulates instance,
@@ -568,7 +569,7 @@ func TestAccumulateRewards2_2Uncles(t *testing.T) {
 			eraLen := new(big.Int)
 			feat, _, configured := config.HasFeature("reward")
 			if !configured {
-				eraLen = defaultEraLength
+				eraLen = rewardEraLength
 			} else {
 				elen, ok := feat.GetBigInt("era")
 				if !ok {
@@ -587,26 +588,14 @@ func TestAccumulateRewards2_2Uncles(t *testing.T) {
 
 			// Check balances.
 			// t.Logf("config=%d block=%d era=%d w:%d u1:%d u2:%d", i, c.block, c.eraNum, gotWinnerBalance, gotUncle1Balance, gotUncle2Balance)
-			if configured {
-				if gotWinnerBalance.Cmp(c.rewards[WinnerCoinbase]) != 0 {
-					t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[WinnerCoinbase], gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
-				}
-				if gotUncle1Balance.Cmp(c.rewards[Uncle1Coinbase]) != 0 {
-					t.Errorf("Config: %v | Era %v: uncle1 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[Uncle1Coinbase], gotUncle1Balance, new(big.Int).Sub(gotUncle1Balance, c.rewards[Uncle1Coinbase]))
-				}
-				if gotUncle2Balance.Cmp(c.rewards[Uncle2Coinbase]) != 0 {
-					t.Errorf("Config: %v | Era %v: uncle2 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[Uncle2Coinbase], gotUncle2Balance, new(big.Int).Sub(gotUncle2Balance, c.rewards[Uncle2Coinbase]))
-				}
-			} else {
-				if gotWinnerBalance.Cmp(new(big.Int).Add(Era1WinnerReward, new(big.Int).Mul(Era1WinnerUncleReward, big.NewInt(2)))) != 0 {
-					t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, new(big.Int).Add(Era1WinnerReward, new(big.Int).Mul(Era1WinnerUncleReward, big.NewInt(2))), gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
-				}
-				if gotUncle1Balance.Cmp(Era1UncleReward) != 0 {
-					t.Errorf("Config: %v | Era %v: uncle1 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, Era1UncleReward, gotUncle1Balance, new(big.Int).Sub(gotUncle1Balance, c.rewards[Uncle1Coinbase]))
-				}
-				if gotUncle2Balance.Cmp(Era1UncleReward) != 0 {
-					t.Errorf("Config: %v | Era %v: uncle2 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, Era1UncleReward, gotUncle2Balance, new(big.Int).Sub(gotUncle2Balance, c.rewards[Uncle2Coinbase]))
-				}
+			if gotWinnerBalance.Cmp(c.rewards[WinnerCoinbase]) != 0 {
+				t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[WinnerCoinbase], gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
+			}
+			if gotUncle1Balance.Cmp(c.rewards[Uncle1Coinbase]) != 0 {
+				t.Errorf("Config: %v | Era %v: uncle1 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[Uncle1Coinbase], gotUncle1Balance, new(big.Int).Sub(gotUncle1Balance, c.rewards[Uncle1Coinbase]))
+			}
+			if gotUncle2Balance.Cmp(c.rewards[Uncle2Coinbase]) != 0 {
+				t.Errorf("Config: %v | Era %v: uncle2 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[Uncle2Coinbase], gotUncle2Balance, new(big.Int).Sub(gotUncle2Balance, c.rewards[Uncle2Coinbase]))
 			}
 			db.Close()
 		}
@@ -659,7 +648,7 @@ func TestAccumulateRewards3_1Uncle(t *testing.T) {
 			eraLen := new(big.Int)
 			feat, _, configured := config.HasFeature("reward")
 			if !configured {
-				eraLen = defaultEraLength
+				eraLen = rewardEraLength
 			} else {
 				elen, ok := feat.GetBigInt("era")
 				if !ok {
@@ -678,20 +667,11 @@ func TestAccumulateRewards3_1Uncle(t *testing.T) {
 
 			// Check balances.
 			// t.Logf("config=%d block=%d era=%d w:%d u1:%d", i, c.block, c.eraNum, gotWinnerBalance, gotUncle1Balance)
-			if configured {
-				if gotWinnerBalance.Cmp(c.rewards[WinnerCoinbase]) != 0 {
-					t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[WinnerCoinbase], gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
-				}
-				if gotUncle1Balance.Cmp(c.rewards[Uncle1Coinbase]) != 0 {
-					t.Errorf("Config: %v | Era %v: uncle1 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[Uncle1Coinbase], gotUncle1Balance, new(big.Int).Sub(gotUncle1Balance, c.rewards[Uncle1Coinbase]))
-				}
-			} else {
-				if gotWinnerBalance.Cmp(new(big.Int).Add(Era1WinnerReward, new(big.Int).Mul(Era1WinnerUncleReward, big.NewInt(1)))) != 0 {
-					t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, new(big.Int).Add(Era1WinnerReward, new(big.Int).Mul(Era1WinnerUncleReward, big.NewInt(1))), gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
-				}
-				if gotUncle1Balance.Cmp(Era1UncleReward) != 0 {
-					t.Errorf("Config: %v | Era %v: uncle1 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, Era1UncleReward, gotUncle1Balance, new(big.Int).Sub(gotUncle1Balance, c.rewards[Uncle1Coinbase]))
-				}
+			if gotWinnerBalance.Cmp(c.rewards[WinnerCoinbase]) != 0 {
+				t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[WinnerCoinbase], gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
+			}
+			if gotUncle1Balance.Cmp(c.rewards[Uncle1Coinbase]) != 0 {
+				t.Errorf("Config: %v | Era %v: uncle1 balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[Uncle1Coinbase], gotUncle1Balance, new(big.Int).Sub(gotUncle1Balance, c.rewards[Uncle1Coinbase]))
 			}
 
 			db.Close()
@@ -738,7 +718,7 @@ func TestAccumulateRewards4_0Uncles(t *testing.T) {
 			eraLen := new(big.Int)
 			feat, _, configured := config.HasFeature("reward")
 			if !configured {
-				eraLen = defaultEraLength
+				eraLen = rewardEraLength
 			} else {
 				elen, ok := feat.GetBigInt("era")
 				if !ok {
@@ -751,14 +731,8 @@ func TestAccumulateRewards4_0Uncles(t *testing.T) {
 
 			// Check balances.
 			// t.Logf("config=%d block=%d era=%d w:%d", i, c.block, c.eraNum, gotWinnerBalance)
-			if configured {
-				if gotWinnerBalance.Cmp(c.rewards[WinnerCoinbase]) != 0 {
-					t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[WinnerCoinbase], gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
-				}
-			} else {
-				if gotWinnerBalance.Cmp(Era1WinnerReward) != 0 {
-					t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, Era1WinnerReward, gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
-				}
+			if gotWinnerBalance.Cmp(c.rewards[WinnerCoinbase]) != 0 {
+				t.Errorf("Config: %v | Era %v: winner balance @ %v, want: %v, got: %v, \n-> diff: %v", i, era, c.block, c.rewards[WinnerCoinbase], gotWinnerBalance, new(big.Int).Sub(gotWinnerBalance, c.rewards[WinnerCoinbase]))
 			}
 
 			db.Close()
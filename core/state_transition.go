@@ -131,6 +131,9 @@ func ApplyMessage(env vm.Environment, msg Message, gp *GasPool) ([]byte, *big.In
 	st := NewStateTransition(env, msg, gp)
 
 	ret, _, gasUsed, err := st.TransitionDb()
+	if t, ok := tracer(env).(vm.TxTracer); ok && err == nil {
+		t.CaptureTxEnd(env)
+	}
 	return ret, gasUsed, err
 }
 
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"container/heap"
 	"errors"
 	"fmt"
 	"math/big"
@@ -30,6 +31,7 @@ import (
 	"github.com/webchain-network/webchaind/event"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/metrics"
 )
 
 var (
@@ -43,12 +45,88 @@ var (
 	ErrIntrinsicGas       = errors.New("Intrinsic gas too low")
 	ErrGasLimit           = errors.New("Exceeds block gas limit")
 	ErrNegativeValue      = errors.New("Negative value")
+	ErrReplaceUnderpriced = errors.New("Replacement transaction underpriced")
+	ErrUnderpriced        = errors.New("Transaction underpriced for a full pool")
+
+	// Transaction Pool eviction reasons
+	ErrTxReplaced  = errors.New("Replaced by a transaction with a higher gas price")
+	ErrTxPoolLimit = errors.New("Transaction pool limit exceeded")
+	ErrTxExpired   = errors.New("Queued for longer than the pool lifetime")
 )
 
 const (
-	maxQueued = 64 // max limit of queued txs per address
+	maxQueued = 64 // default limit of queued txs per address
+
+	// evictionInterval is the time interval to check for expired queued
+	// transactions.
+	evictionInterval = time.Minute
 )
 
+// TxPoolConfig are the configuration parameters of the transaction pool.
+type TxPoolConfig struct {
+	PriceBump uint64 // Minimum price bump percentage to replace a transaction with the same sender and nonce
+
+	AccountSlots uint64 // Number of processable transaction slots guaranteed per account
+	GlobalSlots  uint64 // Maximum number of processable transaction slots for all accounts
+	AccountQueue uint64 // Maximum number of non-processable transaction slots per account
+	GlobalQueue  uint64 // Maximum number of non-processable transaction slots for all accounts
+
+	Lifetime time.Duration // Maximum amount of time non-processable transactions of an inactive account are queued
+
+	Journal   string        // Journal of local transactions to survive node restarts, disabled if empty
+	Rejournal time.Duration // Time interval to regenerate the local transaction journal
+}
+
+// DefaultTxPoolConfig contains the default configuration of the transaction pool.
+var DefaultTxPoolConfig = TxPoolConfig{
+	PriceBump: 10,
+
+	AccountSlots: 16,
+	GlobalSlots:  4096,
+	AccountQueue: maxQueued,
+	GlobalQueue:  1024,
+
+	Lifetime: 3 * time.Hour,
+
+	Journal:   "",
+	Rejournal: time.Hour,
+}
+
+// sanitize replaces the unset or invalid values of the configuration with
+// their defaults.
+func (config TxPoolConfig) sanitize() TxPoolConfig {
+	conf := config
+	if conf.PriceBump < 1 {
+		glog.V(logger.Warn).Warnf("Sanitizing invalid txpool price bump: provided=%d updated=%d", conf.PriceBump, DefaultTxPoolConfig.PriceBump)
+		conf.PriceBump = DefaultTxPoolConfig.PriceBump
+	}
+	if conf.AccountSlots < 1 {
+		glog.V(logger.Warn).Warnf("Sanitizing invalid txpool account slots: provided=%d updated=%d", conf.AccountSlots, DefaultTxPoolConfig.AccountSlots)
+		conf.AccountSlots = DefaultTxPoolConfig.AccountSlots
+	}
+	if conf.GlobalSlots < 1 {
+		glog.V(logger.Warn).Warnf("Sanitizing invalid txpool global slots: provided=%d updated=%d", conf.GlobalSlots, DefaultTxPoolConfig.GlobalSlots)
+		conf.GlobalSlots = DefaultTxPoolConfig.GlobalSlots
+	}
+	if conf.AccountQueue < 1 {
+		glog.V(logger.Warn).Warnf("Sanitizing invalid txpool account queue: provided=%d updated=%d", conf.AccountQueue, DefaultTxPoolConfig.AccountQueue)
+		conf.AccountQueue = DefaultTxPoolConfig.AccountQueue
+	}
+	if conf.GlobalQueue < 1 {
+		glog.V(logger.Warn).Warnf("Sanitizing invalid txpool global queue: provided=%d updated=%d", conf.GlobalQueue, DefaultTxPoolConfig.GlobalQueue)
+		conf.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
+	}
+	if conf.Lifetime < time.Second {
+		glog.V(logger.Warn).Warnf("Sanitizing invalid txpool lifetime: provided=%v updated=%v", conf.Lifetime, DefaultTxPoolConfig.Lifetime)
+		conf.Lifetime = DefaultTxPoolConfig.Lifetime
+	}
+	if conf.Rejournal < time.Second {
+		glog.V(logger.Warn).Warnf("Sanitizing invalid txpool journal time: provided=%v updated=%v", conf.Rejournal, DefaultTxPoolConfig.Rejournal)
+		conf.Rejournal = DefaultTxPoolConfig.Rejournal
+	}
+	return conf
+}
+
 type stateFn func() (*state.StateDB, error)
 
 // TxPool contains all currently known transactions. Transactions
@@ -60,6 +138,7 @@ type stateFn func() (*state.StateDB, error)
 // two states over time as they are received and processed.
 type TxPool struct {
 	config       *ChainConfig
+	poolConfig   TxPoolConfig
 	signer       types.Signer
 	currentState stateFn // The state function which will allow us to do some pre checks
 	pendingState *state.ManagedState
@@ -68,36 +147,132 @@ type TxPool struct {
 	eventMux     *event.TypeMux
 	events       event.Subscription
 	localTx      *txSet
+	localPool    map[common.Hash]common.Address // senders of the local transactions accepted into the pool, kept until they leave it
 	mu           sync.RWMutex
-	pending      map[common.Hash]*types.Transaction // processable transactions
+	pending      map[common.Hash]*types.Transaction        // processable transactions
+	pendingNonce map[common.Address]map[uint64]common.Hash // processable transaction hashes by sender and nonce
 	queue        map[common.Address]map[common.Hash]*types.Transaction
+	queued       int                          // number of transactions in queue
+	pendingPrice *txQueueByPrice              // price heap of the non-local processable transactions, cleaned up lazily
+	queuePrice   *txQueueByPrice              // price heap of the non-local queued transactions, cleaned up lazily
+	beats        map[common.Address]time.Time // Last time a transaction of an account was queued or promoted
+	journal      *txJournal                   // Journal of local transactions to back up to disk
 
-	wg sync.WaitGroup // for shutdown sync
+	wg   sync.WaitGroup // for shutdown sync
+	quit chan struct{}
 
 	homestead bool
 }
 
-func NewTxPool(config *ChainConfig, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
+func NewTxPool(config *ChainConfig, poolConfig TxPoolConfig, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
 	pool := &TxPool{
 		config:       config,
+		poolConfig:   poolConfig.sanitize(),
 		signer:       types.NewChainIdSigner(config.GetChainID(nil)),
 		pending:      make(map[common.Hash]*types.Transaction),
+		pendingNonce: make(map[common.Address]map[uint64]common.Hash),
+		pendingPrice: new(txQueueByPrice),
+		queuePrice:   new(txQueueByPrice),
 		queue:        make(map[common.Address]map[common.Hash]*types.Transaction),
+		beats:        make(map[common.Address]time.Time),
 		eventMux:     eventMux,
 		currentState: currentStateFn,
 		gasLimit:     gasLimitFn,
 		minGasPrice:  new(big.Int),
 		pendingState: nil,
 		localTx:      newTxSet(),
+		localPool:    make(map[common.Hash]common.Address),
 		events:       eventMux.Subscribe(ChainHeadEvent{}, GasPriceChanged{}, RemovedTransactionEvent{}),
+		quit:         make(chan struct{}),
+	}
+
+	// Replay the local transactions of a previous run, dropping the ones
+	// which got mined or invalidated meanwhile from the journal
+	if pool.poolConfig.Journal != "" {
+		pool.journal = newTxJournal(pool.poolConfig.Journal)
+
+		if err := pool.journal.load(pool.addLocal); err != nil {
+			glog.V(logger.Warn).Warnf("Failed to load transaction journal: %v", err)
+		}
+		if err := pool.journal.rotate(pool.locals()); err != nil {
+			glog.V(logger.Warn).Warnf("Failed to rotate transaction journal: %v", err)
+		}
 	}
 
-	pool.wg.Add(1)
+	pool.wg.Add(2)
 	go pool.eventLoop()
+	go pool.loop()
 
 	return pool
 }
 
+// loop periodically drops expired queued transactions and regenerates the
+// journal of local transactions.
+func (pool *TxPool) loop() {
+	defer pool.wg.Done()
+
+	evict := time.NewTicker(evictionInterval)
+	defer evict.Stop()
+
+	var journal <-chan time.Time
+	if pool.journal != nil {
+		ticker := time.NewTicker(pool.poolConfig.Rejournal)
+		defer ticker.Stop()
+		journal = ticker.C
+	}
+
+	for {
+		select {
+		case <-evict.C:
+			pool.mu.Lock()
+			pool.expireQueue(time.Now())
+			pool.pruneLocals()
+			pool.mu.Unlock()
+		case <-journal:
+			pool.mu.Lock()
+			if err := pool.journal.rotate(pool.locals()); err != nil {
+				glog.V(logger.Warn).Warnf("Failed to rotate transaction journal: %v", err)
+			}
+			pool.mu.Unlock()
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
+// expireQueue drops the non-local queued transactions of the accounts which
+// had no transaction queued or promoted within the configured lifetime.
+// (not thread safe, should be called from a locked environment)
+func (pool *TxPool) expireQueue(now time.Time) {
+	for addr := range pool.beats {
+		if _, ok := pool.queue[addr]; !ok {
+			delete(pool.beats, addr)
+		}
+	}
+	for addr, txs := range pool.queue {
+		idle := now.Sub(pool.beats[addr])
+		if idle <= pool.poolConfig.Lifetime {
+			continue
+		}
+		for hash, tx := range txs {
+			if pool.isLocal(hash) {
+				continue
+			}
+			delete(txs, hash)
+			pool.queued--
+			metrics.TxPoolQueuedExpired.Mark(1)
+			pool.dropped(tx, ErrTxExpired, true)
+			if glog.V(logger.Debug) {
+				glog.Infof("Queued tx %s of %s expired after %v of inactivity\n", common.PP(hash[:]), common.PP(addr[:]), idle)
+			}
+		}
+		if len(txs) == 0 {
+			delete(pool.queue, addr)
+			delete(pool.beats, addr)
+		}
+	}
+}
+
 func (pool *TxPool) eventLoop() {
 	defer pool.wg.Done()
 
@@ -123,7 +298,7 @@ func (pool *TxPool) eventLoop() {
 			pool.minGasPrice = ev.Price
 			pool.mu.Unlock()
 		case RemovedTransactionEvent:
-			pool.AddTransactions(ev.Txs)
+			pool.addTransactions(ev.Txs, true)
 		}
 	}
 }
@@ -131,12 +306,12 @@ func (pool *TxPool) eventLoop() {
 func (pool *TxPool) resetState() {
 	currentState, err := pool.currentState()
 	if err != nil {
-		glog.V(logger.Info).Infoln("failed to get current state: %v", err)
+		glog.V(logger.Info).Infof("failed to get current state: %v", err)
 		return
 	}
 	managedState := state.ManageState(currentState)
 	if err != nil {
-		glog.V(logger.Info).Infoln("failed to get managed state: %v", err)
+		glog.V(logger.Info).Infof("failed to get managed state: %v", err)
 		return
 	}
 	pool.pendingState = managedState
@@ -165,7 +340,12 @@ func (pool *TxPool) resetState() {
 
 func (pool *TxPool) Stop() {
 	pool.events.Unsubscribe()
+	close(pool.quit)
 	pool.wg.Wait()
+
+	if pool.journal != nil {
+		pool.journal.close()
+	}
 	glog.V(logger.Info).Infoln("Transaction pool stopped")
 }
 
@@ -176,15 +356,16 @@ func (pool *TxPool) State() *state.ManagedState {
 	return pool.pendingState
 }
 
+// Config returns the configuration of the transaction pool.
+func (pool *TxPool) Config() TxPoolConfig {
+	return pool.poolConfig
+}
+
 func (pool *TxPool) Stats() (pending int, queued int) {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
 
-	pending = len(pool.pending)
-	for _, txs := range pool.queue {
-		queued += len(txs)
-	}
-	return
+	return len(pool.pending), pool.queued
 }
 
 // Content retrieves the data content of the transaction pool, returning all the
@@ -217,6 +398,26 @@ func (pool *TxPool) Content() (map[common.Address]map[uint64][]*types.Transactio
 	return pending, queued
 }
 
+// ContentFrom retrieves the pending and queued transactions of a single
+// account, sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var pending types.Transactions
+	for _, hash := range pool.pendingNonce[addr] {
+		pending = append(pending, pool.pending[hash])
+	}
+	sort.Sort(types.TxByNonce(pending))
+
+	var queued types.Transactions
+	for _, tx := range pool.queue[addr] {
+		queued = append(queued, tx)
+	}
+	sort.Sort(types.TxByNonce(queued))
+	return pending, queued
+}
+
 // SetLocal marks a transaction as local, skipping gas price
 //  check against local miner minimum in the future
 func (pool *TxPool) SetLocal(tx *types.Transaction) {
@@ -225,6 +426,62 @@ func (pool *TxPool) SetLocal(tx *types.Transaction) {
 	pool.localTx.add(tx.Hash())
 }
 
+// addLocal marks a transaction as local and queues it in the pool.
+func (pool *TxPool) addLocal(tx *types.Transaction) error {
+	pool.SetLocal(tx)
+	return pool.Add(tx)
+}
+
+// isLocal reports whether the transaction with the given hash is local. Unlike
+// the marks of SetLocal, which expire, local transactions accepted into the
+// pool stay local for as long as they are in it.
+// (not thread safe, should be called from a locked environment)
+func (pool *TxPool) isLocal(hash common.Hash) bool {
+	if _, ok := pool.localPool[hash]; ok {
+		return true
+	}
+	return pool.localTx.contains(hash)
+}
+
+// pruneLocals forgets the local transactions which left the pool.
+// (not thread safe, should be called from a locked environment)
+func (pool *TxPool) pruneLocals() {
+	for hash, addr := range pool.localPool {
+		if pool.pending[hash] == nil && pool.queue[addr][hash] == nil {
+			delete(pool.localPool, hash)
+		}
+	}
+}
+
+// locals returns the local transactions in the pool, both processable and
+// future ones, sorted by nonce.
+// (not thread safe, should be called from a locked environment)
+func (pool *TxPool) locals() types.Transactions {
+	pool.pruneLocals()
+
+	var txs types.Transactions
+	for hash, addr := range pool.localPool {
+		if tx := pool.pending[hash]; tx != nil {
+			txs = append(txs, tx)
+		} else {
+			txs = append(txs, pool.queue[addr][hash])
+		}
+	}
+	sort.Sort(types.TxByNonce(txs))
+	return txs
+}
+
+// journalTx adds the specified transaction to the local disk journal if it is
+// deemed to have been sent from a local account.
+func (pool *TxPool) journalTx(tx *types.Transaction) {
+	if pool.journal == nil || !pool.isLocal(tx.Hash()) {
+		return
+	}
+	if err := pool.journal.insert(tx); err != nil {
+		glog.V(logger.Warn).Warnf("Failed to journal local transaction %x: %v", tx.Hash().Bytes()[:4], err)
+	}
+}
+
 // validateTx checks whether a transaction is valid according
 // to the consensus rules.
 func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
@@ -302,18 +559,81 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 	return // e=nil
 }
 
-// validate and queue transactions.
-func (self *TxPool) add(tx *types.Transaction) error {
+// dropped notifies the subscribers of a transaction which was rejected on
+// entry to the pool, or evicted from it without being mined. Evictions are
+// logged to mlog as well; rejections already are by validateTx.
+func (pool *TxPool) dropped(tx *types.Transaction, reason error, evicted bool) {
+	sender, _ := types.Sender(pool.signer, tx)
+	if evicted && logger.MlogEnabled() {
+		mlogTxPoolDropTx.AssignDetails(
+			tx.Hash().Hex(),
+			sender.Hex(),
+			tx.Nonce(),
+			reason,
+		).Send(mlogTxPool)
+	}
+	// Posted in a goroutine for the same reason as TxPreEvent in addTx
+	go pool.eventMux.Post(TxDroppedEvent{Tx: tx, Sender: sender, Reason: reason, Evicted: evicted})
+}
+
+// validate and queue transactions. A transaction with the same sender and
+// nonce as one already in the pool replaces it if it pays a high enough gas
+// price. Rejected transactions are reported as dropped, unless they are
+// re-injected from blocks removed by a reorg, as they were never submitted.
+func (self *TxPool) add(tx *types.Transaction, reinject bool) error {
 	hash := tx.Hash()
 
 	if self.pending[hash] != nil {
 		return fmt.Errorf("Known transaction (%x)", hash[:4])
 	}
+	reject := func(err error) error {
+		if !reinject {
+			self.dropped(tx, err, false)
+		}
+		return err
+	}
 	err := self.validateTx(tx)
 	if err != nil {
-		return err
+		return reject(err)
 	}
-	self.queueTx(hash, tx)
+	// validateTx already checked the sender
+	sender, _ := types.Sender(self.signer, tx)
+	if self.queue[sender][hash] != nil {
+		return fmt.Errorf("Known transaction (%x)", hash[:4])
+	}
+	promoted := false
+	if old := self.txByNonce(sender, tx.Nonce()); old != nil {
+		if !self.replaces(tx, old) {
+			return reject(ErrReplaceUnderpriced)
+		}
+		if glog.V(logger.Debug) {
+			glog.Infof("replacing tx %x (price %v) with %x (price %v)\n", old.Hash().Bytes()[:4], old.GasPrice(), hash[:4], tx.GasPrice())
+		}
+		if self.pending[old.Hash()] != nil {
+			// Replace a processable transaction in place, its successors
+			// stay pending
+			self.deletePending(old.Hash())
+			self.addTx(hash, sender, tx)
+			promoted = true
+		} else {
+			self.removeTx(old.Hash())
+		}
+		self.dropped(old, ErrTxReplaced, true)
+	} else if pending := self.processable(sender, tx); !self.isLocal(hash) && self.full(pending) {
+		// Make room only for transactions paying more than the cheapest one
+		// which could be evicted from the pending or queued transactions the
+		// transaction joins
+		if cheapest := self.cheapest(pending); cheapest == nil || cheapest.GasPrice().Cmp(tx.GasPrice()) >= 0 {
+			return reject(ErrUnderpriced)
+		}
+	}
+	if !promoted {
+		self.queueTx(hash, tx)
+	}
+	if self.localTx.contains(hash) {
+		self.localPool[hash] = sender
+	}
+	self.journalTx(tx)
 
 	var toName, toLogName string
 	if to := tx.To(); to != nil {
@@ -337,19 +657,49 @@ func (self *TxPool) add(tx *types.Transaction) error {
 		).Send(mlogTxPool)
 	}
 	if glog.V(logger.Debug) {
-		glog.Infof("(t) %x => %s (%v) %x\n", from, toName, tx.Value, hash)
+		glog.Infof("(t) %x => %s (%v) %x\n", from, toName, tx.Value(), hash)
 	}
 
 	return nil
 }
 
+// txByNonce returns the pending or queued transaction of the given sender with
+// the given nonce, or nil if there is none.
+func (pool *TxPool) txByNonce(sender common.Address, nonce uint64) *types.Transaction {
+	for _, tx := range pool.queue[sender] {
+		if tx.Nonce() == nonce {
+			return tx
+		}
+	}
+	if hash, ok := pool.pendingNonce[sender][nonce]; ok {
+		return pool.pending[hash]
+	}
+	return nil
+}
+
+// replaces reports whether tx pays a high enough gas price to replace old:
+// it has to be higher, by at least the configured price bump percentage.
+func (pool *TxPool) replaces(tx, old *types.Transaction) bool {
+	if tx.GasPrice().Cmp(old.GasPrice()) <= 0 {
+		return false
+	}
+	threshold := new(big.Int).Mul(old.GasPrice(), new(big.Int).SetUint64(100+pool.poolConfig.PriceBump))
+	threshold.Div(threshold, big.NewInt(100))
+	return tx.GasPrice().Cmp(threshold) >= 0
+}
+
 // queueTx will queue an unknown transaction
 func (self *TxPool) queueTx(hash common.Hash, tx *types.Transaction) {
 	from, _ := types.Sender(self.signer, tx) // already validated
 	if self.queue[from] == nil {
 		self.queue[from] = make(map[common.Hash]*types.Transaction)
 	}
+	if _, ok := self.queue[from][hash]; !ok {
+		self.queued++
+		self.price(self.queuePrice, txQueueEntry{hash, from, tx})
+	}
 	self.queue[from][hash] = tx
+	self.beats[from] = time.Now()
 }
 
 // addTx will add a transaction to the pending (processable queue) list of transactions
@@ -360,11 +710,15 @@ func (pool *TxPool) addTx(hash common.Hash, addr common.Address, tx *types.Trans
 	}
 
 	if _, ok := pool.pending[hash]; !ok {
-		pool.pending[hash] = tx
-
-		// Increment the nonce on the pending state. This can only happen if
-		// the nonce is +1 to the previous one.
-		pool.pendingState.SetNonce(addr, tx.Nonce()+1)
+		pool.putPending(hash, addr, tx)
+		pool.price(pool.pendingPrice, txQueueEntry{hash, addr, tx})
+		pool.beats[addr] = time.Now()
+
+		// Raise the nonce on the pending state past the transaction. It is
+		// never lowered, as a replacement is promoted after its successors.
+		if pool.pendingState.GetNonce(addr) <= tx.Nonce() {
+			pool.pendingState.SetNonce(addr, tx.Nonce()+1)
+		}
 		// Notify the subscribers. This event is posted in a goroutine
 		// because it's possible that somewhere during the post "Remove transaction"
 		// gets called which will then wait for the global tx pool lock and deadlock.
@@ -372,12 +726,37 @@ func (pool *TxPool) addTx(hash common.Hash, addr common.Address, tx *types.Trans
 	}
 }
 
+// putPending adds a processable transaction, indexing it by sender and nonce.
+func (pool *TxPool) putPending(hash common.Hash, addr common.Address, tx *types.Transaction) {
+	pool.pending[hash] = tx
+	if pool.pendingNonce[addr] == nil {
+		pool.pendingNonce[addr] = make(map[uint64]common.Hash)
+	}
+	pool.pendingNonce[addr][tx.Nonce()] = hash
+}
+
+// deletePending removes a processable transaction and its index entry.
+func (pool *TxPool) deletePending(hash common.Hash) {
+	tx, ok := pool.pending[hash]
+	if !ok {
+		return
+	}
+	delete(pool.pending, hash)
+	addr, _ := tx.From()
+	if nonces := pool.pendingNonce[addr]; nonces[tx.Nonce()] == hash {
+		delete(nonces, tx.Nonce())
+		if len(nonces) == 0 {
+			delete(pool.pendingNonce, addr)
+		}
+	}
+}
+
 // Add queues a single transaction in the pool if it is valid.
 func (self *TxPool) Add(tx *types.Transaction) error {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
-	if err := self.add(tx); err != nil {
+	if err := self.add(tx, false); err != nil {
 		return err
 	}
 	self.checkQueue()
@@ -386,11 +765,17 @@ func (self *TxPool) Add(tx *types.Transaction) error {
 
 // AddTransactions attempts to queue all valid transactions in txs.
 func (self *TxPool) AddTransactions(txs []*types.Transaction) {
+	self.addTransactions(txs, false)
+}
+
+// addTransactions attempts to queue all valid transactions in txs, which are
+// re-injected from blocks removed by a reorg if reinject is set.
+func (self *TxPool) addTransactions(txs []*types.Transaction, reinject bool) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
 	for _, tx := range txs {
-		if err := self.add(tx); err != nil {
+		if err := self.add(tx, reinject); err != nil {
 			glog.V(logger.Debug).Infoln("tx error:", err)
 		} else {
 			h := tx.Hash()
@@ -474,7 +859,7 @@ func (pool *TxPool) RemoveTx(hash common.Hash) {
 
 func (pool *TxPool) removeTx(hash common.Hash) {
 	// delete from pending pool
-	delete(pool.pending, hash)
+	pool.deletePending(hash)
 	// delete from queue
 	for address, txs := range pool.queue {
 		if _, ok := txs[hash]; ok {
@@ -484,6 +869,7 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 			} else {
 				delete(txs, hash)
 			}
+			pool.queued--
 			break
 		}
 	}
@@ -517,6 +903,11 @@ func (pool *TxPool) checkQueue() {
 					glog.Infof("removed tx (%v) from pool queue: low tx nonce or out of funds\n", tx)
 				}
 				delete(txs, hash)
+				pool.queued--
+				// Low nonce transactions are most likely mined
+				if tx.Nonce() >= trueNonce {
+					pool.dropped(tx, ErrInsufficientFunds, true)
+				}
 				continue
 			}
 			// Collect the remaining transactions for the next pass.
@@ -528,12 +919,14 @@ func (pool *TxPool) checkQueue() {
 		for i, entry := range promote {
 			// If we reached a gap in the nonces, enforce transaction limit and stop
 			if entry.Nonce() > guessedNonce {
-				if len(promote)-i > maxQueued {
+				if limit := int(pool.poolConfig.AccountQueue); len(promote)-i > limit {
 					if glog.V(logger.Debug) {
 						glog.Infof("Queued tx limit exceeded for %s. Tx %s removed\n", common.PP(address[:]), common.PP(entry.hash[:]))
 					}
-					for _, drop := range promote[i+maxQueued:] {
+					for _, drop := range promote[i+limit:] {
 						delete(txs, drop.hash)
+						pool.queued--
+						pool.dropped(drop.Transaction, ErrTxPoolLimit, true)
 					}
 				}
 				break
@@ -541,6 +934,7 @@ func (pool *TxPool) checkQueue() {
 			// Otherwise promote the transaction and move the guess nonce if needed
 			pool.addTx(entry.hash, address, entry.Transaction)
 			delete(txs, entry.hash)
+			pool.queued--
 
 			if entry.Nonce() == guessedNonce {
 				guessedNonce++
@@ -551,6 +945,164 @@ func (pool *TxPool) checkQueue() {
 			delete(pool.queue, address)
 		}
 	}
+	// Enforce the global limits of the pool
+	pool.truncatePending()
+	pool.truncateQueue()
+}
+
+// processable reports whether a new transaction of the given sender would be
+// promoted to the pending transactions, rather than stay queued.
+func (pool *TxPool) processable(sender common.Address, tx *types.Transaction) bool {
+	// init delayed since tx pool could have been started before any state sync
+	if pool.pendingState == nil {
+		pool.resetState()
+	}
+	return tx.Nonce() <= pool.pendingState.GetNonce(sender)
+}
+
+// full reports whether the pending, or else the queued, transactions of the
+// pool reach their global limit.
+func (pool *TxPool) full(pending bool) bool {
+	if pending {
+		return uint64(len(pool.pending)) >= pool.poolConfig.GlobalSlots
+	}
+	return uint64(pool.queued) >= pool.poolConfig.GlobalQueue
+}
+
+// cheapest returns the non-local pending, or else queued, transaction with the
+// lowest gas price, or nil if all of them are local. Entries of the price heap
+// which are no longer pending, or queued, are dropped on the way.
+func (pool *TxPool) cheapest(pending bool) *types.Transaction {
+	priced := pool.queuePrice
+	if pending {
+		priced = pool.pendingPrice
+	}
+	for priced.Len() > 0 {
+		entry := (*priced)[0]
+		if !pool.isLocal(entry.hash) && pool.contains(pending, entry) {
+			return entry.Transaction
+		}
+		heap.Pop(priced)
+	}
+	return nil
+}
+
+// contains reports whether the pending, or else the queued, transactions of
+// the pool hold the transaction of the entry.
+func (pool *TxPool) contains(pending bool, entry txQueueEntry) bool {
+	if pending {
+		return pool.pending[entry.hash] != nil
+	}
+	return pool.queue[entry.addr][entry.hash] != nil
+}
+
+// price adds a non-local transaction which became pending or queued to the
+// price heap of those, rebuilding the heap when stale entries make up more
+// than half of it.
+func (pool *TxPool) price(priced *txQueueByPrice, entry txQueueEntry) {
+	if pool.isLocal(entry.hash) {
+		return
+	}
+	heap.Push(priced, entry)
+
+	pending := priced == pool.pendingPrice
+	size := pool.queued
+	if pending {
+		size = len(pool.pending)
+	}
+	if priced.Len() <= 2*size {
+		return
+	}
+	live, seen := (*priced)[:0], make(map[common.Hash]bool)
+	for _, entry := range *priced {
+		if !seen[entry.hash] && !pool.isLocal(entry.hash) && pool.contains(pending, entry) {
+			live = append(live, entry)
+			seen[entry.hash] = true
+		}
+	}
+	*priced = live
+	heap.Init(priced)
+}
+
+// truncatePending evicts processable transactions while there are more than
+// the global limit allows. Only the highest nonce transaction of an account
+// is evicted, so that no gaps are left behind, and only from accounts over
+// their guaranteed slots, cheapest transaction first: once every account is
+// within them, the global limit is left exceeded. Local transactions are never
+// evicted.
+func (pool *TxPool) truncatePending() {
+	if uint64(len(pool.pending)) <= pool.poolConfig.GlobalSlots {
+		return
+	}
+	// Gather the non-local pending transactions of every account, by nonce
+	accounts := make(map[common.Address]types.Transactions)
+	for _, tx := range pool.pending {
+		if pool.isLocal(tx.Hash()) {
+			continue
+		}
+		from, _ := tx.From()
+		accounts[from] = append(accounts[from], tx)
+	}
+	for _, txs := range accounts {
+		sort.Sort(types.TxByNonce(txs))
+	}
+	for uint64(len(pool.pending)) > pool.poolConfig.GlobalSlots {
+		var (
+			victim common.Address
+			tx     *types.Transaction
+		)
+		for addr, txs := range accounts {
+			if uint64(len(txs)) <= pool.poolConfig.AccountSlots {
+				continue
+			}
+			if tail := txs[len(txs)-1]; tx == nil || tail.GasPrice().Cmp(tx.GasPrice()) < 0 {
+				victim, tx = addr, tail
+			}
+		}
+		if tx == nil {
+			return
+		}
+		txs := accounts[victim]
+		if len(txs) == 1 {
+			delete(accounts, victim)
+		} else {
+			accounts[victim] = txs[:len(txs)-1]
+		}
+		pool.deletePending(tx.Hash())
+		pool.pendingState.SetNonce(victim, tx.Nonce())
+		pool.dropped(tx, ErrTxPoolLimit, true)
+		if glog.V(logger.Debug) {
+			glog.Infof("Pending tx limit exceeded. Tx %s of %s (price %v) evicted\n", common.PP(tx.Hash().Bytes()), common.PP(victim[:]), tx.GasPrice())
+		}
+	}
+}
+
+// truncateQueue evicts the cheapest non-local queued transactions while there
+// are more than the global limit allows. Of equally priced transactions, the
+// ones furthest in the future go first.
+func (pool *TxPool) truncateQueue() {
+	if uint64(pool.queued) <= pool.poolConfig.GlobalQueue {
+		return
+	}
+	var candidates txQueue
+	for addr, txs := range pool.queue {
+		for hash, tx := range txs {
+			if !pool.isLocal(hash) {
+				candidates = append(candidates, txQueueEntry{hash, addr, tx})
+			}
+		}
+	}
+	sort.Sort(txQueueByPrice(candidates))
+	for _, entry := range candidates {
+		if uint64(pool.queued) <= pool.poolConfig.GlobalQueue {
+			break
+		}
+		pool.removeTx(entry.hash)
+		pool.dropped(entry.Transaction, ErrTxPoolLimit, true)
+		if glog.V(logger.Debug) {
+			glog.Infof("Queued tx limit exceeded. Tx %s of %s (price %v) evicted\n", common.PP(entry.hash[:]), common.PP(entry.addr[:]), entry.GasPrice())
+		}
+	}
 }
 
 // validatePool removes invalid and processed transactions from the main pool.
@@ -561,7 +1113,7 @@ func (pool *TxPool) checkQueue() {
 func (pool *TxPool) validatePool() {
 	state, err := pool.currentState()
 	if err != nil {
-		glog.V(logger.Info).Infoln("failed to get current state: %v", err)
+		glog.V(logger.Info).Infof("failed to get current state: %v", err)
 		return
 	}
 	balanceCache := make(map[common.Address]*big.Int)
@@ -583,10 +1135,11 @@ func (pool *TxPool) validatePool() {
 			if glog.V(logger.Core) {
 				glog.Infof("removed tx (%v) from pool: low tx nonce or out of funds\n", tx)
 			}
-			delete(pool.pending, hash)
+			pool.deletePending(hash)
 
 			// Track the smallest invalid nonce to postpone subsequent transactions
 			if !past {
+				pool.dropped(tx, ErrInsufficientFunds, true)
 				if prev, ok := gaps[sender]; !ok || tx.Nonce() < prev {
 					gaps[sender] = tx.Nonce()
 				}
@@ -602,7 +1155,7 @@ func (pool *TxPool) validatePool() {
 					glog.Infof("postponed tx (%v) due to introduced gap\n", tx)
 				}
 				pool.queueTx(hash, tx)
-				delete(pool.pending, hash)
+				pool.deletePending(hash)
 			}
 		}
 	}
@@ -620,6 +1173,30 @@ func (q txQueue) Len() int           { return len(q) }
 func (q txQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
 func (q txQueue) Less(i, j int) bool { return q[i].Nonce() < q[j].Nonce() }
 
+// txQueueByPrice sorts queue entries by gas price, and of equally priced
+// entries puts those with the highest nonce first. It is also used as a heap,
+// with the cheapest entry on top.
+type txQueueByPrice txQueue
+
+func (q txQueueByPrice) Len() int      { return len(q) }
+func (q txQueueByPrice) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q txQueueByPrice) Less(i, j int) bool {
+	if cmp := q[i].GasPrice().Cmp(q[j].GasPrice()); cmp != 0 {
+		return cmp < 0
+	}
+	return q[i].Nonce() > q[j].Nonce()
+}
+
+func (q *txQueueByPrice) Push(x interface{}) { *q = append(*q, x.(txQueueEntry)) }
+
+func (q *txQueueByPrice) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	*q = old[:n-1]
+	return x
+}
+
 // txSet represents a set of transaction hashes in which entries
 //  are automatically dropped after txSetDuration time
 type txSet struct {
@@ -18,8 +18,12 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core/state"
@@ -34,15 +38,36 @@ func transaction(nonce uint64, gaslimit *big.Int, key *ecdsa.PrivateKey) *types.
 	return tx
 }
 
+func pricedTransaction(nonce uint64, gaslimit, gasprice *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, gasprice, nil).SignECDSA(key)
+	return tx
+}
+
+// testTxPoolConfig is a transaction pool configuration without journaling.
+var testTxPoolConfig TxPoolConfig
+
+func init() {
+	testTxPoolConfig = DefaultTxPoolConfig
+	testTxPoolConfig.Journal = ""
+}
+
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
+	return setupTxPoolWithConfig(testTxPoolConfig)
+}
+
+func setupTxPoolWithConfig(config TxPoolConfig) (*TxPool, *ecdsa.PrivateKey) {
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
 
-	var m event.TypeMux
 	key, _ := crypto.GenerateKey()
-	newPool := NewTxPool(testChainConfig(), &m, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	return newTestTxPool(config, statedb), key
+}
+
+func newTestTxPool(config TxPoolConfig, statedb *state.StateDB) *TxPool {
+	var m event.TypeMux
+	newPool := NewTxPool(testChainConfig(), config, &m, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	newPool.resetState()
-	return newPool, key
+	return newPool
 }
 
 func deriveSender(tx *types.Transaction) (common.Address, error) {
@@ -89,6 +114,153 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+func TestTransactionReplacement(t *testing.T) {
+	pool, key := setupTxPool()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000000))
+
+	// Replace a pending transaction
+	pending := pricedTransaction(0, big.NewInt(100000), big.NewInt(100), key)
+	if err := pool.Add(pending); err != nil {
+		t.Fatalf("failed to add original pending transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100001), big.NewInt(100), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("same price replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(109), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("low price replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	replacement := pricedTransaction(0, big.NewInt(100000), big.NewInt(110), key)
+	if err := pool.Add(replacement); err != nil {
+		t.Fatalf("failed to replace pending transaction: %v", err)
+	}
+	if len(pool.pending) != 1 || pool.pending[replacement.Hash()] == nil {
+		t.Fatalf("pending pool mismatch: have %d transactions, replacement included: %v", len(pool.pending), pool.pending[replacement.Hash()] != nil)
+	}
+
+	// Replace a queued transaction
+	queued := pricedTransaction(2, big.NewInt(100000), big.NewInt(200), key)
+	if err := pool.Add(queued); err != nil {
+		t.Fatalf("failed to add original queued transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(2, big.NewInt(100000), big.NewInt(219), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("low price queued replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	replacement = pricedTransaction(2, big.NewInt(100000), big.NewInt(220), key)
+	if err := pool.Add(replacement); err != nil {
+		t.Fatalf("failed to replace queued transaction: %v", err)
+	}
+	if len(pool.queue[from]) != 1 || pool.queue[from][replacement.Hash()] == nil {
+		t.Fatalf("queue mismatch: have %d transactions, replacement included: %v", len(pool.queue[from]), pool.queue[from][replacement.Hash()] != nil)
+	}
+}
+
+// Tests that replacing a pending transaction in the middle of a nonce range
+// neither lowers the pending nonce nor postpones its successors.
+func TestTransactionReplacementMiddleNonce(t *testing.T) {
+	pool, key := setupTxPool()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000000))
+
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if err := pool.Add(pricedTransaction(nonce, big.NewInt(100000), big.NewInt(100), key)); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+	replacement := pricedTransaction(1, big.NewInt(100000), big.NewInt(110), key)
+	if err := pool.Add(replacement); err != nil {
+		t.Fatalf("failed to replace pending transaction: %v", err)
+	}
+	if len(pool.pending) != 3 || pool.pending[replacement.Hash()] == nil {
+		t.Fatalf("pending pool mismatch: have %d transactions, replacement included: %v", len(pool.pending), pool.pending[replacement.Hash()] != nil)
+	}
+	if nonce := pool.pendingState.GetNonce(from); nonce != 3 {
+		t.Fatalf("pending nonce mismatch: have %d, want %d", nonce, 3)
+	}
+	if err := pool.Add(pricedTransaction(3, big.NewInt(100000), big.NewInt(100), key)); err != nil {
+		t.Fatalf("failed to add transaction after replacement: %v", err)
+	}
+	if len(pool.pending) != 4 || len(pool.queue[from]) != 0 {
+		t.Fatalf("pool mismatch: have %d pending and %d queued, want 4 and 0", len(pool.pending), len(pool.queue[from]))
+	}
+	if pending, _ := pool.ContentFrom(from); len(pending) != 4 || pending[1] != replacement {
+		t.Fatalf("pending content mismatch: have %d transactions", len(pending))
+	}
+}
+
+func TestTransactionDroppedEvents(t *testing.T) {
+	pool, key := setupTxPool()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000000))
+
+	sub := pool.eventMux.Subscribe(TxDroppedEvent{})
+	defer sub.Unsubscribe()
+
+	expect := func(tx *types.Transaction, reason error, evicted bool) {
+		select {
+		case ev := <-sub.Chan():
+			dropped := ev.Data.(TxDroppedEvent)
+			if dropped.Tx.Hash() != tx.Hash() {
+				t.Fatalf("dropped transaction mismatch: have %x, want %x", dropped.Tx.Hash(), tx.Hash())
+			}
+			if dropped.Sender != from {
+				t.Errorf("sender mismatch: have %x, want %x", dropped.Sender, from)
+			}
+			if dropped.Reason != reason {
+				t.Errorf("reason mismatch: have %v, want %v", dropped.Reason, reason)
+			}
+			if dropped.Evicted != evicted {
+				t.Errorf("evicted mismatch: have %v, want %v", dropped.Evicted, evicted)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no dropped event for transaction %x", tx.Hash())
+		}
+	}
+
+	original := pricedTransaction(0, big.NewInt(100000), big.NewInt(100), key)
+	if err := pool.Add(original); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	underpriced := pricedTransaction(0, big.NewInt(100000), big.NewInt(105), key)
+	if err := pool.Add(underpriced); err != ErrReplaceUnderpriced {
+		t.Fatalf("replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	expect(underpriced, ErrReplaceUnderpriced, false)
+
+	replacement := pricedTransaction(0, big.NewInt(100000), big.NewInt(110), key)
+	if err := pool.Add(replacement); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	expect(original, ErrTxReplaced, true)
+
+	// Transactions failing validation are rejected, pending ones the sender
+	// can no longer pay for are evicted
+	expensive := pricedTransaction(1, big.NewInt(100000), big.NewInt(1000000), key)
+	if err := pool.Add(expensive); err != ErrInsufficientFunds {
+		t.Fatalf("expensive transaction error mismatch: have %v, want %v", err, ErrInsufficientFunds)
+	}
+	expect(expensive, ErrInsufficientFunds, false)
+
+	if _, ok := pool.pending[replacement.Hash()]; !ok {
+		t.Fatalf("replacement transaction not pending")
+	}
+	currentState.SetBalance(from, big.NewInt(0))
+	pool.validatePool()
+	expect(replacement, ErrInsufficientFunds, true)
+
+	// Transactions re-injected after a reorg were never submitted, so they
+	// are not reported when rejected
+	pool.addTransactions(types.Transactions{expensive}, true)
+	select {
+	case ev := <-sub.Chan():
+		t.Fatalf("unexpected dropped event for re-injected transaction %x", ev.Data.(TxDroppedEvent).Tx.Hash())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	pool, key := setupTxPool()
 	tx := transaction(0, big.NewInt(100), key)
@@ -189,14 +361,14 @@ func TestTransactionChainFork(t *testing.T) {
 	resetState()
 
 	tx := transaction(0, big.NewInt(100000), key)
-	if err := pool.add(tx); err != nil {
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	pool.RemoveTransactions([]*types.Transaction{tx})
 
 	// reset the pool's internal state
 	resetState()
-	if err := pool.add(tx); err != nil {
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 }
@@ -216,16 +388,23 @@ func TestTransactionDoubleNonce(t *testing.T) {
 
 	tx := transaction(0, big.NewInt(100000), key)
 	tx2 := transaction(0, big.NewInt(1000000), key)
-	if err := pool.add(tx); err != nil {
+	tx3 := pricedTransaction(0, big.NewInt(1000000), big.NewInt(2), key)
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
-	if err := pool.add(tx2); err != nil {
+	if err := pool.add(tx2, false); err != ErrReplaceUnderpriced {
+		t.Error("expected", ErrReplaceUnderpriced, "got", err)
+	}
+	if err := pool.add(tx3, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 
 	pool.checkQueue()
-	if len(pool.pending) != 2 {
-		t.Error("expected 2 pending txs. Got", len(pool.pending))
+	if len(pool.pending) != 1 {
+		t.Error("expected 1 pending tx. Got", len(pool.pending))
+	}
+	if pool.pending[tx3.Hash()] == nil {
+		t.Error("expected replacement tx to be pending")
 	}
 }
 
@@ -235,7 +414,7 @@ func TestMissingNonce(t *testing.T) {
 	currentState, _ := pool.currentState()
 	currentState.AddBalance(addr, big.NewInt(100000000000000))
 	tx := transaction(1, big.NewInt(100000), key)
-	if err := pool.add(tx); err != nil {
+	if err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
 	if len(pool.pending) != 0 {
@@ -454,6 +633,413 @@ func TestTransactionPendingLimiting(t *testing.T) {
 	}
 }
 
+// Tests that once the pool holds more processable transactions than the global
+// limit, only transactions of accounts over their guaranteed slots are evicted,
+// and local transactions are kept.
+func TestTransactionPendingGlobalLimiting(t *testing.T) {
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+	config.GlobalSlots = 6
+
+	pool, key := setupTxPoolWithConfig(config)
+	state, _ := pool.currentState()
+
+	keys := []*ecdsa.PrivateKey{key}
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		state.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	// Account 0 is local, account 1 pays the least, account 2 the most
+	for i := uint64(0); i < 4; i++ {
+		tx := pricedTransaction(i, big.NewInt(100000), big.NewInt(1), keys[0])
+		pool.SetLocal(tx)
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("local tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	for i := uint64(0); i < 3; i++ {
+		// The last one finds the pending transactions full, and pays no more
+		// than the cheapest of them
+		var want error
+		if i == 2 {
+			want = ErrUnderpriced
+		}
+		if err := pool.Add(pricedTransaction(i, big.NewInt(100000), big.NewInt(2), keys[1])); err != want {
+			t.Fatalf("cheap tx %d: add error mismatch: have %v, want %v", i, err, want)
+		}
+	}
+	// The last one is queued first, so that account 2 is pushed over its
+	// guaranteed slots by promotion rather than rejected
+	for _, i := range []uint64{2, 0, 1} {
+		if err := pool.Add(pricedTransaction(i, big.NewInt(100000), big.NewInt(3), keys[2])); err != nil {
+			t.Fatalf("expensive tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	// Account 2 only loses the transaction over its guaranteed slots, which
+	// leaves the pool above the global limit
+	if len(pool.pending) != 8 {
+		t.Fatalf("pending pool size mismatch: have %d, want %d", len(pool.pending), 8)
+	}
+	count := make(map[common.Address]int)
+	for _, tx := range pool.pending {
+		from, _ := tx.From()
+		count[from]++
+	}
+	for i, want := range []int{4, 2, 2} {
+		if have := count[crypto.PubkeyToAddress(keys[i].PublicKey)]; have != want {
+			t.Errorf("account %d: pending count mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	// The nonces of evicted transactions must be reusable
+	if nonce := pool.State().GetNonce(crypto.PubkeyToAddress(keys[2].PublicKey)); nonce != 2 {
+		t.Errorf("pending nonce mismatch: have %d, want %d", nonce, 2)
+	}
+}
+
+// Tests that no processable transactions are evicted while every account is
+// within its guaranteed slots, even if the pool holds more than the global
+// limit.
+func TestTransactionPendingGuaranteedSlots(t *testing.T) {
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+	config.GlobalSlots = 4
+
+	pool, _ := setupTxPoolWithConfig(config)
+	state, _ := pool.currentState()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		state.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Every account pays more than the last, so that none is underpriced
+	for i, key := range keys {
+		for j := uint64(0); j < config.AccountSlots; j++ {
+			if err := pool.Add(pricedTransaction(j, big.NewInt(100000), big.NewInt(int64(i+1)), key)); err != nil {
+				t.Fatalf("account %d, tx %d: failed to add transaction: %v", i, j, err)
+			}
+		}
+	}
+	if want := len(keys) * int(config.AccountSlots); len(pool.pending) != want {
+		t.Fatalf("pending pool size mismatch: have %d, want %d", len(pool.pending), want)
+	}
+	// An account going over its guaranteed slots is evicted from again
+	if err := pool.Add(pricedTransaction(config.AccountSlots, big.NewInt(100000), big.NewInt(3), keys[2])); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if want := len(keys) * int(config.AccountSlots); len(pool.pending) != want {
+		t.Fatalf("pending pool size mismatch: have %d, want %d", len(pool.pending), want)
+	}
+	if nonce := pool.State().GetNonce(crypto.PubkeyToAddress(keys[2].PublicKey)); nonce != config.AccountSlots {
+		t.Errorf("pending nonce mismatch: have %d, want %d", nonce, config.AccountSlots)
+	}
+}
+
+// Tests that once the pool holds more non-processable transactions than the
+// global limit, the cheapest ones are evicted.
+func TestTransactionQueueGlobalLimiting(t *testing.T) {
+	config := testTxPoolConfig
+	config.GlobalQueue = 4
+
+	pool, key := setupTxPoolWithConfig(config)
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	for i := uint64(1); i <= 6; i++ {
+		if err := pool.Add(pricedTransaction(i, big.NewInt(100000), big.NewInt(int64(i)), key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if len(pool.queue[account]) != int(config.GlobalQueue) {
+		t.Fatalf("queue size mismatch: have %d, want %d", len(pool.queue[account]), config.GlobalQueue)
+	}
+	for _, tx := range pool.queue[account] {
+		if tx.GasPrice().Cmp(big.NewInt(3)) < 0 {
+			t.Errorf("cheap tx %d kept in the queue", tx.Nonce())
+		}
+	}
+}
+
+// Tests that a full pool rejects transactions which pay no more than the
+// cheapest transaction it could evict.
+func TestTransactionUnderpricedFullPool(t *testing.T) {
+	config := testTxPoolConfig
+	config.AccountSlots = 1
+	config.GlobalSlots = 2
+	config.GlobalQueue = 1
+
+	pool, key := setupTxPoolWithConfig(config)
+	other, _ := crypto.GenerateKey()
+	state, _ := pool.currentState()
+	state.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	state.AddBalance(crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000000))
+
+	for i := uint64(0); i < 2; i++ {
+		if err := pool.Add(pricedTransaction(i, big.NewInt(100000), big.NewInt(2), key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if err := pool.Add(pricedTransaction(3, big.NewInt(100000), big.NewInt(2), key)); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(2), other)); err != ErrUnderpriced {
+		t.Fatalf("underpriced error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	tx := pricedTransaction(0, big.NewInt(100000), big.NewInt(3), other)
+	if err := pool.Add(tx); err != nil {
+		t.Fatalf("failed to add overpriced transaction: %v", err)
+	}
+	if pool.pending[tx.Hash()] == nil {
+		t.Errorf("overpriced transaction not pending")
+	}
+	if len(pool.pending) != int(config.GlobalSlots) {
+		t.Errorf("pending pool size mismatch: have %d, want %d", len(pool.pending), config.GlobalSlots)
+	}
+}
+
+// Tests that the price heaps of the pool track its cheapest non-local pending
+// and queued transactions across removals, and are kept from growing with
+// stale entries.
+func TestTransactionPriceHeap(t *testing.T) {
+	pool, key := setupTxPool()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	local := pricedTransaction(0, big.NewInt(100000), big.NewInt(1), key)
+	pool.SetLocal(local)
+	if err := pool.Add(local); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	// Three pending transactions, and a queued one behind a nonce gap
+	txs := make([]*types.Transaction, 4)
+	for i := range txs {
+		nonce := uint64(i + 1)
+		if i == 3 {
+			nonce++
+		}
+		txs[i] = pricedTransaction(nonce, big.NewInt(100000), big.NewInt(int64(i+2)), key)
+		if err := pool.Add(txs[i]); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if cheapest := pool.cheapest(false); cheapest != txs[3] {
+		t.Fatalf("cheapest queued transaction mismatch: have %v, want %v", cheapest, txs[3])
+	}
+	for i := 0; i < 2; i++ {
+		if cheapest := pool.cheapest(true); cheapest != txs[i] {
+			t.Fatalf("removal %d: cheapest transaction mismatch: have %v, want %v", i, cheapest.GasPrice(), txs[i].GasPrice())
+		}
+		pool.RemoveTx(txs[i].Hash())
+	}
+	if cheapest := pool.cheapest(true); cheapest != txs[2] {
+		t.Fatalf("cheapest transaction mismatch: have %v, want %v", cheapest.GasPrice(), txs[2].GasPrice())
+	}
+	pool.RemoveTx(txs[3].Hash())
+	if cheapest := pool.cheapest(false); cheapest != nil {
+		t.Fatalf("cheapest queued transaction mismatch: have %v, want none", cheapest)
+	}
+	pending, queued := pool.Stats()
+	if pending != 2 || queued != 0 || queued != pool.queued {
+		t.Fatalf("pool size mismatch: have %d pending, %d queued (counted %d)", pending, queued, pool.queued)
+	}
+	// Churning transactions must not grow the heaps past twice the pool
+	tx := pricedTransaction(100, big.NewInt(100000), big.NewInt(10), key)
+	for i := 0; i < 100; i++ {
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("churn %d: failed to add transaction: %v", i, err)
+		}
+		if have, limit := pool.queuePrice.Len(), 2*pool.queued; have > limit {
+			t.Fatalf("churn %d: queued price heap size mismatch: have %d, want at most %d", i, have, limit)
+		}
+		pool.RemoveTx(tx.Hash())
+	}
+	if have, limit := pool.pendingPrice.Len(), 2*len(pool.pending); have > limit {
+		t.Errorf("pending price heap size mismatch: have %d, want at most %d", have, limit)
+	}
+}
+
+// Tests that the pending and queued transactions are limited separately: a
+// transaction joining a full queue is rejected when it pays no more than the
+// cheapest queued one, even though there is room for pending ones.
+func TestTransactionUnderpricedFullQueue(t *testing.T) {
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 1
+
+	pool, key := setupTxPoolWithConfig(config)
+	other, _ := crypto.GenerateKey()
+	state, _ := pool.currentState()
+	state.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	state.AddBalance(crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(2), key)); err != nil {
+		t.Fatalf("failed to add pending transaction: %v", err)
+	}
+	queued := pricedTransaction(2, big.NewInt(100000), big.NewInt(2), key)
+	if err := pool.Add(queued); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(5, big.NewInt(100000), big.NewInt(2), other)); err != ErrUnderpriced {
+		t.Fatalf("underpriced error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// The pending transactions are not full, so cheap ones still join them
+	cheap := pricedTransaction(0, big.NewInt(100000), big.NewInt(1), other)
+	if err := pool.Add(cheap); err != nil {
+		t.Fatalf("failed to add cheap pending transaction: %v", err)
+	}
+	if pool.pending[cheap.Hash()] == nil {
+		t.Errorf("cheap transaction not pending")
+	}
+	// Paying more than the cheapest queued transaction evicts it
+	tx := pricedTransaction(5, big.NewInt(100000), big.NewInt(3), other)
+	if err := pool.Add(tx); err != nil {
+		t.Fatalf("failed to add overpriced queued transaction: %v", err)
+	}
+	if pool.queue[crypto.PubkeyToAddress(other.PublicKey)][tx.Hash()] == nil {
+		t.Errorf("overpriced transaction not queued")
+	}
+	if pool.queue[crypto.PubkeyToAddress(key.PublicKey)][queued.Hash()] != nil {
+		t.Errorf("cheapest queued transaction not evicted")
+	}
+	if pool.queued != int(config.GlobalQueue) {
+		t.Errorf("queued pool size mismatch: have %d, want %d", pool.queued, config.GlobalQueue)
+	}
+}
+
+// Tests that the non-local queued transactions of an account are dropped once
+// it has been inactive for longer than the pool lifetime, while local ones and
+// those of active accounts are kept.
+func TestTransactionQueueTimeLimiting(t *testing.T) {
+	pool, key := setupTxPool()
+	remote := crypto.PubkeyToAddress(key.PublicKey)
+	localKey, _ := crypto.GenerateKey()
+	local := crypto.PubkeyToAddress(localKey.PublicKey)
+
+	state, _ := pool.currentState()
+	state.AddBalance(remote, big.NewInt(1000000000))
+	state.AddBalance(local, big.NewInt(1000000000))
+
+	if err := pool.Add(transaction(1, big.NewInt(100000), key)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	tx := transaction(1, big.NewInt(100000), localKey)
+	pool.SetLocal(tx)
+	if err := pool.Add(tx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	lifetime := pool.poolConfig.Lifetime
+
+	pool.expireQueue(time.Now().Add(lifetime / 2))
+	if _, queued := pool.Stats(); queued != 2 {
+		t.Fatalf("queued transactions mismatch before lifetime: have %d, want %d", queued, 2)
+	}
+	pool.expireQueue(time.Now().Add(2 * lifetime))
+	if _, queued := pool.Stats(); queued != 1 {
+		t.Fatalf("queued transactions mismatch after lifetime: have %d, want %d", queued, 1)
+	}
+	if len(pool.queue[local]) != 1 {
+		t.Errorf("local transaction expired")
+	}
+	if _, ok := pool.beats[remote]; ok {
+		t.Errorf("heartbeat of expired account kept")
+	}
+}
+
+// Tests that local transactions are journaled to disk and replayed into the
+// pool on restart, while remote and mined ones are not.
+func TestTransactionJournaling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txpool-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testTxPoolConfig
+	config.Journal = filepath.Join(dir, "transactions.rlp")
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	pool := newTestTxPool(config, statedb)
+	for i := uint64(0); i < 3; i++ {
+		tx := transaction(i, big.NewInt(100000), local)
+		pool.SetLocal(tx)
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("local tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if err := pool.Add(transaction(0, big.NewInt(100000), remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	pool.Stop()
+
+	// Restart the pool, with the first local transaction mined meanwhile
+	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
+	pool = newTestTxPool(config, statedb)
+	pending, queued := pool.Stats()
+	if pending != 2 || queued != 0 {
+		t.Fatalf("replayed pool mismatch: have %d pending and %d queued, want 2 and 0", pending, queued)
+	}
+	for _, tx := range pool.GetTransactions() {
+		if from, _ := tx.From(); from != crypto.PubkeyToAddress(local.PublicKey) {
+			t.Errorf("replayed transaction %x is not local", tx.Hash())
+		}
+	}
+	pool.Stop()
+
+	// The mined transaction must have been dropped from the journal
+	var count int
+	if err := newTxJournal(config.Journal).load(func(*types.Transaction) error { count++; return nil }); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("journal size mismatch: have %d, want %d", count, 2)
+	}
+}
+
+// Tests that local transactions stay local, journaled and exempt from expiry,
+// for as long as they are in the pool, even once their SetLocal marks expire.
+func TestTransactionLocalsOutliveMarks(t *testing.T) {
+	pool, key := setupTxPool()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	pending := transaction(0, big.NewInt(100000), key)
+	queued := transaction(2, big.NewInt(100000), key)
+	for _, tx := range []*types.Transaction{pending, queued} {
+		pool.SetLocal(tx)
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("failed to add local transaction: %v", err)
+		}
+	}
+	// Drop the marks as if they had expired
+	pool.localTx = newTxSet()
+
+	if locals := pool.locals(); len(locals) != 2 || locals[0] != pending || locals[1] != queued {
+		t.Fatalf("local transactions mismatch: have %d, want %d", len(locals), 2)
+	}
+	pool.expireQueue(time.Now().Add(2 * pool.poolConfig.Lifetime))
+	if pool.queue[account][queued.Hash()] == nil {
+		t.Fatalf("local transaction expired")
+	}
+	// Once they leave the pool they are forgotten
+	pool.RemoveTx(queued.Hash())
+	pool.pruneLocals()
+	if locals := pool.locals(); len(locals) != 1 || pool.isLocal(queued.Hash()) {
+		t.Fatalf("local transactions mismatch after removal: have %d, want %d", len(locals), 1)
+	}
+}
+
 // Tests that the transaction limits are enforced the same way irrelevant whether
 // the transactions are added one by one or in batches.
 func TestTransactionQueueLimitingEquivalency(t *testing.T)   { testTransactionLimitingEquivalency(t, 1) }
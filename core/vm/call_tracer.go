@@ -0,0 +1,120 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+)
+
+// CallFrame is a single message call (or contract creation) made during the
+// execution of a transaction, along with all the calls it made itself.
+type CallFrame struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value,omitempty"`
+	Gas     *hexutil.Big   `json:"gas"`
+	GasUsed *hexutil.Big   `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []*CallFrame   `json:"calls,omitempty"`
+}
+
+// CallTracer is a Tracer which builds the tree of call frames made by a
+// transaction: CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE and
+// SELFDESTRUCT.
+type CallTracer struct {
+	root  *CallFrame
+	stack []*CallFrame // frames entered but not yet left
+}
+
+// NewCallTracer returns a new call tracer.
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// CaptureState records SELFDESTRUCT operations, which transfer the balance
+// of the contract without entering a new frame.
+func (t *CallTracer) CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack []*big.Int, contract *Contract, depth int, err error) error {
+	if op != SUICIDE || err != nil || len(stack) == 0 || len(t.stack) == 0 {
+		return nil
+	}
+	frame := &CallFrame{
+		Type:    "SELFDESTRUCT",
+		From:    contract.Address(),
+		To:      common.BigToAddress(stack[len(stack)-1]),
+		Value:   (*hexutil.Big)(new(big.Int).Set(env.Db().GetBalance(contract.Address()))),
+		Gas:     new(hexutil.Big),
+		GasUsed: new(hexutil.Big),
+	}
+	if cost != nil {
+		frame.GasUsed = (*hexutil.Big)(new(big.Int).Set(cost))
+	}
+	parent := t.stack[len(t.stack)-1]
+	parent.Calls = append(parent.Calls, frame)
+	return nil
+}
+
+// CaptureEnter pushes a new frame, as a child of the currently running one.
+func (t *CallTracer) CaptureEnter(env Environment, typ OpCode, from, to common.Address, input []byte, gas, value *big.Int) {
+	frame := &CallFrame{
+		Type:  typ.String(),
+		From:  from,
+		To:    to,
+		Gas:   (*hexutil.Big)(new(big.Int).Set(gas)),
+		Input: common.CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	if len(t.stack) > 0 {
+		parent := t.stack[len(t.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	} else if t.root == nil {
+		t.root = frame
+	}
+	t.stack = append(t.stack, frame)
+}
+
+// CaptureExit pops the currently running frame and records its result.
+func (t *CallTracer) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+	if len(t.stack) == 0 {
+		return
+	}
+	frame := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+
+	frame.GasUsed = (*hexutil.Big)(new(big.Int).Set(gasUsed))
+	if err != nil {
+		frame.Error = err.Error()
+		// Only a reverted frame carries meaningful return data
+		if err != ErrRevert {
+			return
+		}
+	}
+	frame.Output = common.CopyBytes(output)
+}
+
+// Result returns the top level call frame of the traced transaction, or nil
+// if nothing was executed.
+func (t *CallTracer) Result() *CallFrame {
+	return t.root
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+func TestCallTracerNesting(t *testing.T) {
+	var (
+		tracer = NewCallTracer()
+		a      = common.HexToAddress("0x0a")
+		b      = common.HexToAddress("0x0b")
+		c      = common.HexToAddress("0x0c")
+	)
+	tracer.CaptureEnter(nil, CALL, a, b, []byte{1}, big.NewInt(1000), big.NewInt(5))
+	tracer.CaptureEnter(nil, DELEGATECALL, b, c, []byte{2}, big.NewInt(500), nil)
+	tracer.CaptureExit([]byte{3}, big.NewInt(100), nil)
+	tracer.CaptureEnter(nil, STATICCALL, b, c, nil, big.NewInt(300), nil)
+	tracer.CaptureExit([]byte{4}, big.NewInt(300), OutOfGasError)
+	tracer.CaptureExit([]byte{5}, big.NewInt(600), nil)
+
+	root := tracer.Result()
+	if root == nil {
+		t.Fatal("expected a top level frame")
+	}
+	if root.Type != "CALL" || root.From != a || root.To != b || root.Value.ToInt().Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("unexpected top level frame: %+v", root)
+	}
+	if root.GasUsed.ToInt().Cmp(big.NewInt(600)) != 0 || len(root.Output) != 1 || root.Output[0] != 5 {
+		t.Errorf("unexpected top level result: gasUsed %v, output %x", root.GasUsed, root.Output)
+	}
+	if len(root.Calls) != 2 {
+		t.Fatalf("expected 2 inner calls, got %d", len(root.Calls))
+	}
+	if call := root.Calls[0]; call.Type != "DELEGATECALL" || call.Value != nil || call.Error != "" {
+		t.Errorf("unexpected first inner call: %+v", call)
+	}
+	if call := root.Calls[1]; call.Type != "STATICCALL" || call.Error != OutOfGasError.Error() || call.Output != nil {
+		t.Errorf("unexpected second inner call: %+v", call)
+	}
+}
@@ -22,14 +22,17 @@ import (
 )
 
 type ruleSet struct {
-	hs *big.Int
-	at *big.Int
+	hs  *big.Int
+	at  *big.Int
+	hf2 *big.Int
 }
 
 func (r ruleSet) IsHomestead(n *big.Int) bool { return n.Cmp(r.hs) >= 0 }
 
 func (r ruleSet) IsAtlantis(n *big.Int) bool { return n.Cmp(r.at) >= 0 }
 
+func (r ruleSet) IsHardfork2(n *big.Int) bool { return n.Cmp(r.hf2) >= 0 }
+
 func (r ruleSet) GasTable(*big.Int) *GasTable {
 	return &GasTable{
 		ExtcodeSize: big.NewInt(20),
@@ -43,13 +46,13 @@ func (r ruleSet) GasTable(*big.Int) *GasTable {
 }
 
 func TestInit(t *testing.T) {
-	jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1)}, big.NewInt(0))
+	jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1), big.NewInt(1)}, big.NewInt(0))
 	if jumpTable[DELEGATECALL].valid {
 		t.Error("Expected DELEGATECALL not to be present")
 	}
 
 	for _, n := range []int64{1, 2, 100} {
-		jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1)}, big.NewInt(n))
+		jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1), big.NewInt(1)}, big.NewInt(n))
 		if !jumpTable[DELEGATECALL].valid {
 			t.Error("Expected DELEGATECALL to be present for block", n)
 		}
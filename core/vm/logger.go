@@ -0,0 +1,245 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// ErrTraceLimitReached is returned by the StructLogger once the configured
+// number of steps has been captured.
+var ErrTraceLimitReached = errors.New("the number of logs reached the specified limit")
+
+// Storage represents a contract's storage.
+type Storage map[common.Hash]common.Hash
+
+// Copy duplicates the current storage.
+func (s Storage) Copy() Storage {
+	cpy := make(Storage)
+	for key, value := range s {
+		cpy[key] = value
+	}
+	return cpy
+}
+
+// LogConfig are the configuration options for the structured logger.
+type LogConfig struct {
+	DisableMemory  bool // disable memory capture
+	DisableStack   bool // disable stack capture
+	DisableStorage bool // disable storage capture
+	Limit          int  // maximum length of output, but zero means unlimited
+}
+
+// StructLog is emitted by the EVM for every executed step and holds the
+// state of the VM right before the step's operation is applied.
+type StructLog struct {
+	Pc      uint64
+	Op      OpCode
+	Gas     *big.Int
+	GasCost *big.Int
+	Memory  []byte
+	Stack   []*big.Int
+	Storage Storage
+	Depth   int
+	Err     error
+}
+
+// OpName formats the operand name in a human-readable format.
+func (s *StructLog) OpName() string {
+	return s.Op.String()
+}
+
+// ErrorString formats the log's error as a string.
+func (s *StructLog) ErrorString() string {
+	if s.Err != nil {
+		return s.Err.Error()
+	}
+	return ""
+}
+
+// String implements the Stringer interface, printing the step in a
+// human-readable form.
+func (s StructLog) String() string {
+	str := fmt.Sprintf("%-16spc=%08d gas=%v cost=%v", s.Op, s.Pc, s.Gas, s.GasCost)
+	if s.Err != nil {
+		str += fmt.Sprintf(" ERROR: %v", s.Err)
+	}
+	return str
+}
+
+// Tracer is used to collect execution traces from the EVM. CaptureState is
+// called for every step of the byte code VM, right before the operation is
+// executed, and once more with a non-nil error when the step fails.
+//
+// The memory and stack passed to the tracer are owned by the running EVM and
+// must be copied if they are to be retained.
+//
+// A non-nil error returned by the tracer aborts the execution.
+//
+// CaptureEnter and CaptureExit are called whenever a message call or contract
+// creation frame is entered and left, including the top level frame of a
+// transaction. They are paired, even if the frame failed before executing any
+// code. The environment passed on enter stays valid until the frame is left.
+type Tracer interface {
+	CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack []*big.Int, contract *Contract, depth int, err error) error
+	CaptureEnter(env Environment, typ OpCode, from, to common.Address, input []byte, gas, value *big.Int)
+	CaptureExit(output []byte, gasUsed *big.Int, err error)
+}
+
+// FrameTracer follows the call frames of an execution like a Tracer does, and
+// is notified of SUICIDE operations, right before they are executed, with the
+// balance the contract leaves behind. Being called for no other step, it
+// doesn't need Debug and adds next to no cost to the execution.
+type FrameTracer interface {
+	CaptureEnter(env Environment, typ OpCode, from, to common.Address, input []byte, gas, value *big.Int)
+	CaptureExit(output []byte, gasUsed *big.Int, err error)
+	CaptureSuicide(env Environment, contract, beneficiary common.Address, balance *big.Int)
+}
+
+// TxTracer is a Tracer which also inspects the state once a transaction has
+// been applied. CaptureTxEnd is called after the gas refund and fee payment,
+// before the state of the transaction is finalised.
+type TxTracer interface {
+	Tracer
+	CaptureTxEnd(env Environment)
+}
+
+// StructLogger is a Tracer which collects a StructLog for every executed
+// step. The collected logs can be used for debugging the execution of a
+// transaction.
+type StructLogger struct {
+	cfg LogConfig
+
+	logs          []StructLog
+	changedValues map[common.Address]Storage
+
+	depth  int    // number of call frames entered but not yet left
+	output []byte // return data of the top level frame
+	err    error  // error of the top level frame
+}
+
+// NewStructLogger returns a new logger. A nil config means all data is
+// captured without a limit.
+func NewStructLogger(cfg *LogConfig) *StructLogger {
+	logger := &StructLogger{
+		changedValues: make(map[common.Address]Storage),
+	}
+	if cfg != nil {
+		logger.cfg = *cfg
+	}
+	return logger
+}
+
+// CaptureState logs a new structured log message and pushes it out to the
+// collected set of logs.
+func (l *StructLogger) CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, stack []*big.Int, contract *Contract, depth int, err error) error {
+	// check if already accumulated the specified number of logs
+	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs) {
+		return ErrTraceLimitReached
+	}
+
+	// initialise new changed values storage container for this contract
+	// if not present.
+	if l.changedValues[contract.Address()] == nil {
+		l.changedValues[contract.Address()] = make(Storage)
+	}
+
+	// capture SSTORE opcodes and determine the changed value and store
+	// it in the local storage container. NOTE: we do not need to do any
+	// range checks here because that's already handled prior to calling
+	// this function.
+	if op == SSTORE && len(stack) >= 2 && err == nil {
+		var (
+			value   = common.BigToHash(stack[len(stack)-2])
+			address = common.BigToHash(stack[len(stack)-1])
+		)
+		l.changedValues[contract.Address()][address] = value
+	}
+
+	// copy a snapshot of the current memory state to a new buffer
+	var mem []byte
+	if !l.cfg.DisableMemory {
+		mem = make([]byte, len(memory.Data()))
+		copy(mem, memory.Data())
+	}
+
+	// copy a snapshot of the current stack state to a new buffer
+	var stck []*big.Int
+	if !l.cfg.DisableStack {
+		stck = make([]*big.Int, len(stack))
+		for i, item := range stack {
+			stck[i] = new(big.Int).Set(item)
+		}
+	}
+
+	// Copy the changed values of the contract's storage
+	var storage Storage
+	if !l.cfg.DisableStorage {
+		storage = l.changedValues[contract.Address()].Copy()
+	}
+
+	log := StructLog{
+		Pc:      pc,
+		Op:      op,
+		Gas:     new(big.Int).Set(gas),
+		GasCost: new(big.Int),
+		Memory:  mem,
+		Stack:   stck,
+		Storage: storage,
+		Depth:   depth,
+		Err:     err,
+	}
+	if cost != nil {
+		log.GasCost.Set(cost)
+	}
+	l.logs = append(l.logs, log)
+	return nil
+}
+
+// CaptureEnter keeps track of the call depth. Call frames themselves are
+// reflected by the depth of the captured steps.
+func (l *StructLogger) CaptureEnter(env Environment, typ OpCode, from, to common.Address, input []byte, gas, value *big.Int) {
+	l.depth++
+}
+
+// CaptureExit records the outcome of the top level frame.
+func (l *StructLogger) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+	l.depth--
+	if l.depth == 0 {
+		l.output = common.CopyBytes(output)
+		l.err = err
+	}
+}
+
+// StructLogs returns the logs captured so far.
+func (l *StructLogger) StructLogs() []StructLog {
+	return l.logs
+}
+
+// Output returns the data returned by the top level frame.
+func (l *StructLogger) Output() []byte {
+	return l.output
+}
+
+// Error returns the error the top level frame failed with, if any.
+func (l *StructLogger) Error() error {
+	return l.err
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+type dummyContractRef struct {
+	calledForEach bool
+}
+
+func (dummyContractRef) ReturnGas(*big.Int, *big.Int) {}
+func (dummyContractRef) Address() common.Address      { return common.Address{} }
+func (dummyContractRef) Value() *big.Int              { return new(big.Int) }
+func (dummyContractRef) SetCode(common.Hash, []byte)  {}
+func (d *dummyContractRef) ForEachStorage(callback func(key, value common.Hash) bool) {
+	d.calledForEach = true
+}
+
+func TestStoreCapture(t *testing.T) {
+	var (
+		logger   = NewStructLogger(nil)
+		mem      = NewMemory()
+		stack    = []*big.Int{big.NewInt(1), big.NewInt(0)}
+		contract = NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), new(big.Int), new(big.Int))
+	)
+	if err := logger.CaptureState(nil, 0, SSTORE, new(big.Int), new(big.Int), mem, stack, contract, 0, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logger.changedValues[contract.Address()]) == 0 {
+		t.Fatalf("expected exactly 1 changed value on address %x, got %d", contract.Address(), len(logger.changedValues[contract.Address()]))
+	}
+	exp := common.BigToHash(big.NewInt(1))
+	if logger.changedValues[contract.Address()][common.Hash{}] != exp {
+		t.Errorf("expected %x, got %x", exp, logger.changedValues[contract.Address()][common.Hash{}])
+	}
+	if logs := logger.StructLogs(); len(logs) != 1 || logs[0].Storage[common.Hash{}] != exp {
+		t.Errorf("expected the captured step to carry the changed storage, got %v", logs)
+	}
+}
+
+func TestStructLoggerConfig(t *testing.T) {
+	var (
+		logger   = NewStructLogger(&LogConfig{DisableMemory: true, DisableStack: true, DisableStorage: true, Limit: 1})
+		mem      = NewMemory()
+		stack    = []*big.Int{big.NewInt(1)}
+		contract = NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), new(big.Int), new(big.Int))
+	)
+	mem.Resize(32)
+	if err := logger.CaptureState(nil, 0, PUSH1, big.NewInt(100), big.NewInt(3), mem, stack, contract, 1, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := logger.CaptureState(nil, 2, STOP, big.NewInt(97), big.NewInt(0), mem, stack, contract, 1, nil); err != ErrTraceLimitReached {
+		t.Fatalf("expected %v, got %v", ErrTraceLimitReached, err)
+	}
+	logs := logger.StructLogs()
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d", len(logs))
+	}
+	if logs[0].Memory != nil || logs[0].Stack != nil || logs[0].Storage != nil {
+		t.Errorf("expected memory, stack and storage to be disabled, got %v %v %v", logs[0].Memory, logs[0].Stack, logs[0].Storage)
+	}
+	if logs[0].Gas.Cmp(big.NewInt(100)) != 0 || logs[0].GasCost.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("unexpected gas %v and cost %v", logs[0].Gas, logs[0].GasCost)
+	}
+}
@@ -39,6 +39,17 @@ type VirtualMachine interface {
 	Run(*Contract, []byte) ([]byte, error)
 }
 
+// Config are the configuration options for the EVM
+type Config struct {
+	// Debug enables the Tracer
+	Debug bool
+	// Tracer is called for every executed step when Debug is set
+	Tracer Tracer
+	// FrameTracer is notified of call frames and SUICIDE operations, whether
+	// Debug is set or not
+	FrameTracer FrameTracer
+}
+
 // EVM is used to run Ethereum based contracts and will utilise the
 // passed environment to query external sources for state information.
 // The EVM will run the byte code VM or JIT VM based on the passed
@@ -48,17 +59,30 @@ type EVM struct {
 	jumpTable vmJumpTable
 	gasTable  GasTable
 	readOnly  bool
+	cfg       Config
 }
 
 // New returns a new instance of the EVM.
 func New(env Environment) *EVM {
+	return NewWithConfig(env, Config{})
+}
+
+// NewWithConfig returns a new instance of the EVM using the given
+// configuration.
+func NewWithConfig(env Environment, cfg Config) *EVM {
 	return &EVM{
 		env:       env,
 		jumpTable: newJumpTable(env.RuleSet(), env.BlockNumber()),
 		gasTable:  *env.RuleSet().GasTable(env.BlockNumber()),
+		cfg:       cfg,
 	}
 }
 
+// Config returns the configuration the EVM was created with.
+func (evm *EVM) Config() Config {
+	return evm.cfg
+}
+
 // Run loops and evaluates the contract's code with the given input data
 func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte, err error) {
 	evm.env.SetDepth(evm.env.Depth() + 1)
@@ -132,6 +156,10 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 		// calculate the new memory size and gas price for the current executing opcode
 		newMemSize, cost, err = calculateGasAndSize(&evm.gasTable, evm.env, contract, caller, op, statedb, mem, stack)
 		if err != nil {
+			evm.captureState(pc, op, contract, mem, stack, cost, err)
+			return nil, err
+		}
+		if err := evm.captureState(pc, op, contract, mem, stack, cost, nil); err != nil {
 			return nil, err
 		}
 
@@ -143,6 +171,7 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 			// account to the others means the state is modified and should also
 			// return with an error.
 			if operation.writes || (op == CALL && stack.back(2).Sign() != 0) {
+				evm.captureState(pc, op, contract, mem, stack, cost, errWriteProtection)
 				return nil, errWriteProtection
 			}
 		}
@@ -150,15 +179,21 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 		// Use the calculated gas. When insufficient gas is present, use all gas and return an
 		// Out Of Gas error
 		if !contract.UseGas(cost) {
+			evm.captureState(pc, op, contract, mem, stack, cost, OutOfGasError)
 			return nil, OutOfGasError
 		}
 
 		// Resize the memory calculated previously
 		mem.Resize(newMemSize.Uint64())
 		if !operation.valid {
-			return nil, fmt.Errorf("Invalid opcode %x", op)
+			err := fmt.Errorf("Invalid opcode %x", op)
+			evm.captureState(pc, op, contract, mem, stack, cost, err)
+			return nil, err
 		}
 
+		if op == SUICIDE && evm.cfg.FrameTracer != nil {
+			evm.cfg.FrameTracer.CaptureSuicide(evm.env, contract.Address(), common.BigToAddress(stack.back(0)), statedb.GetBalance(contract.Address()))
+		}
 		res, err := operation.fn(&pc, evm.env, contract, mem, stack)
 
 		if operation.returns {
@@ -167,6 +202,7 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 
 		switch {
 		case err != nil:
+			evm.captureState(pc, op, contract, mem, stack, cost, err)
 			return nil, err
 		case operation.reverts:
 			return res, ErrRevert
@@ -178,6 +214,15 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 	}
 }
 
+// captureState hands the current step over to the configured tracer. It is a
+// no-op when debugging is disabled.
+func (evm *EVM) captureState(pc uint64, op OpCode, contract *Contract, mem *Memory, stack *stack, cost *big.Int, err error) error {
+	if !evm.cfg.Debug || evm.cfg.Tracer == nil {
+		return nil
+	}
+	return evm.cfg.Tracer.CaptureState(evm.env, pc, op, contract.Gas, cost, mem, stack.Data(), contract, evm.env.Depth(), err)
+}
+
 // calculateGasAndSize calculates the required given the opcode and stack items calculates the new memorysize for
 // the operation. This does not reduce gas or resizes the memory.
 func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract, caller ContractRef, op OpCode, statedb Database, mem *Memory, stack *stack) (*big.Int, *big.Int, error) {
@@ -54,6 +54,12 @@ type VMEnv struct {
 }
 
 func NewEnv(state *state.StateDB, chainConfig *ChainConfig, chain *BlockChain, msg Message, header *types.Header) *VMEnv {
+	return NewEnvWithConfig(state, chainConfig, chain, msg, header, vm.Config{})
+}
+
+// NewEnvWithConfig creates a new VM environment whose EVM is set up with the
+// given configuration, e.g. to attach a tracer.
+func NewEnvWithConfig(state *state.StateDB, chainConfig *ChainConfig, chain *BlockChain, msg Message, header *types.Header, cfg vm.Config) *VMEnv {
 	env := &VMEnv{
 		chainConfig: chainConfig,
 		chain:       chain,
@@ -63,7 +69,7 @@ func NewEnv(state *state.StateDB, chainConfig *ChainConfig, chain *BlockChain, m
 		getHashFn:   GetHashFn(header.ParentHash, chain),
 	}
 
-	env.evm = vm.New(env)
+	env.evm = vm.NewWithConfig(env, cfg)
 	return env
 }
 
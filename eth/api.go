@@ -50,6 +50,7 @@ import (
 	"github.com/webchain-network/webchaind/p2p"
 	"github.com/webchain-network/webchaind/rlp"
 	"github.com/webchain-network/webchaind/rpc"
+	"github.com/webchain-network/webchaind/trie"
 )
 
 const defaultGas = uint64(90000)
@@ -75,7 +76,7 @@ func blockByNumber(m *miner.Miner, bc *core.BlockChain, blockNr rpc.BlockNumber)
 // returns the state and containing block for the given block number, capable of
 // handling two special states: rpc.LatestBlockNumber and rpc.PendingBlockNumber.
 // It returns nil when no block or state could be found.
-func stateAndBlockByNumber(m *miner.Miner, bc *core.BlockChain, blockNr rpc.BlockNumber, chainDb ethdb.Database) (*state.StateDB, *types.Block, error) {
+func stateAndBlockByNumber(m *miner.Miner, bc *core.BlockChain, blockNr rpc.BlockNumber) (*state.StateDB, *types.Block, error) {
 	// Pending state is only known by the miner
 	if blockNr == rpc.PendingBlockNumber {
 		block, state := m.Pending()
@@ -86,7 +87,7 @@ func stateAndBlockByNumber(m *miner.Miner, bc *core.BlockChain, blockNr rpc.Bloc
 	if block == nil {
 		return nil, nil, nil
 	}
-	stateDb, err := state.New(block.Root(), state.NewDatabase(chainDb))
+	stateDb, err := bc.StateAt(block.Root())
 	return stateDb, block, err
 }
 
@@ -334,12 +335,75 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string][]*RPCTrans
 	return content
 }
 
-// Status returns the number of pending and queued transaction in the pool.
+// NonceGap is a range of nonces, both ends included, missing from the
+// transactions of an account in the pool.
+type NonceGap struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+// AccountPoolContent is the content of the transaction pool for a single
+// account, along with the nonces deciding which of its transactions are
+// processable.
+type AccountPoolContent struct {
+	Pending    map[string][]*RPCTransaction `json:"pending"`
+	Queued     map[string][]*RPCTransaction `json:"queued"`
+	StateNonce hexutil.Uint64               `json:"stateNonce"` // nonce of the account in the current state
+	NextNonce  hexutil.Uint64               `json:"nextNonce"`  // nonce the next processable transaction has to carry
+	Gaps       []NonceGap                   `json:"gaps"`       // missing nonces keeping queued transactions from being promoted
+}
+
+// ContentFrom returns the pending and queued transactions of a single
+// account, along with the state nonce of the account, the nonce expected next
+// and the nonce gaps keeping its queued transactions from being processable.
+func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) (*AccountPoolContent, error) {
+	statedb, err := s.e.BlockChain().State()
+	if err != nil {
+		return nil, err
+	}
+	pending, queued := s.e.TxPool().ContentFrom(addr)
+
+	content := &AccountPoolContent{
+		Pending:    make(map[string][]*RPCTransaction),
+		Queued:     make(map[string][]*RPCTransaction),
+		StateNonce: hexutil.Uint64(statedb.GetNonce(addr)),
+		NextNonce:  hexutil.Uint64(statedb.GetNonce(addr)),
+		Gaps:       []NonceGap{},
+	}
+	for _, tx := range pending {
+		nonce := fmt.Sprintf("%d", tx.Nonce())
+		content.Pending[nonce] = append(content.Pending[nonce], newRPCPendingTransaction(tx))
+		if next := hexutil.Uint64(tx.Nonce() + 1); next > content.NextNonce {
+			content.NextNonce = next
+		}
+	}
+	expected := uint64(content.NextNonce)
+	for _, tx := range queued {
+		nonce := fmt.Sprintf("%d", tx.Nonce())
+		content.Queued[nonce] = append(content.Queued[nonce], newRPCPendingTransaction(tx))
+		if tx.Nonce() > expected {
+			content.Gaps = append(content.Gaps, NonceGap{From: hexutil.Uint64(expected), To: hexutil.Uint64(tx.Nonce() - 1)})
+		}
+		if tx.Nonce() >= expected {
+			expected = tx.Nonce() + 1
+		}
+	}
+	return content, nil
+}
+
+// Status returns the number of pending and queued transaction in the pool,
+// along with the limits the pool enforces.
 func (s *PublicTxPoolAPI) Status() map[string]*rpc.HexNumber {
 	pending, queue := s.e.TxPool().Stats()
+	config := s.e.TxPool().Config()
 	return map[string]*rpc.HexNumber{
-		"pending": rpc.NewHexNumber(pending),
-		"queued":  rpc.NewHexNumber(queue),
+		"pending":      rpc.NewHexNumber(pending),
+		"queued":       rpc.NewHexNumber(queue),
+		"globalSlots":  rpc.NewHexNumber(config.GlobalSlots),
+		"globalQueue":  rpc.NewHexNumber(config.GlobalQueue),
+		"accountSlots": rpc.NewHexNumber(config.AccountSlots),
+		"accountQueue": rpc.NewHexNumber(config.AccountQueue),
+		"priceBump":    rpc.NewHexNumber(config.PriceBump),
 	}
 }
 
@@ -585,7 +649,7 @@ func (s *PublicBlockChainAPI) BlockNumber() *big.Int {
 // given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
 // block numbers are also allowed.
 func (s *PublicBlockChainAPI) GetBalance(address common.Address, blockNr rpc.BlockNumber) (*big.Int, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -704,7 +768,7 @@ func (s *PublicBlockChainAPI) NewBlocks(ctx context.Context, args NewBlocksArgs)
 
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (s *PublicBlockChainAPI) GetCode(address common.Address, blockNr rpc.BlockNumber) (string, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr)
 	if state == nil || err != nil {
 		return "", err
 	}
@@ -719,13 +783,91 @@ func (s *PublicBlockChainAPI) GetCode(address common.Address, blockNr rpc.BlockN
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
 func (s *PublicBlockChainAPI) GetStorageAt(address common.Address, key string, blockNr rpc.BlockNumber) (string, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr)
 	if state == nil || err != nil {
 		return "0x", err
 	}
 	return state.GetState(address, common.HexToHash(key)).Hex(), nil
 }
 
+// AccountResult is the result of an eth_getProof API call.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the proof of a single storage slot of an AccountResult.
+type StorageResult struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+	Proof []string     `json:"proof"`
+}
+
+// GetProof returns the account with the given address and the requested
+// storage slots of it, along with the merkle proofs of all of them, at the
+// given block. The account proof can be checked against the state root of
+// the block and the storage proofs against the storage hash of the account,
+// for example with state.VerifyAccountProof and state.VerifyStorageProof.
+func (s *PublicBlockChainAPI) GetProof(address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	if blockNr == rpc.PendingBlockNumber {
+		return nil, errors.New("proofs of the pending state are not supported")
+	}
+	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	// An account which doesn't exist has an empty storage and code
+	var (
+		storageTrie  = state.StorageTrie(address)
+		storageHash  = types.EmptyRootHash
+		codeHash     = crypto.Keccak256Hash(nil)
+		storageProof = make([]StorageResult, len(storageKeys))
+	)
+	if storageTrie != nil {
+		storageHash = storageTrie.Hash()
+		codeHash = state.GetCodeHash(address)
+	}
+	for i, key := range storageKeys {
+		storageProof[i] = StorageResult{Key: key, Value: new(hexutil.Big), Proof: []string{}}
+		if storageTrie == nil {
+			continue
+		}
+		proof, err := state.GetStorageProof(address, common.HexToHash(key))
+		if err != nil {
+			return nil, err
+		}
+		storageProof[i].Value = (*hexutil.Big)(state.GetState(address, common.HexToHash(key)).Big())
+		storageProof[i].Proof = toHexSlice(proof)
+	}
+	return &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(state.GetBalance(address)),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(state.GetNonce(address)),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, nil
+}
+
+// toHexSlice encodes a list of byte slices as hex strings.
+func toHexSlice(b [][]byte) []string {
+	r := make([]string, len(b))
+	for i := range b {
+		r[i] = hexutil.Encode(b[i])
+	}
+	return r
+}
+
 // callmsg is the message type used for call transactions.
 type callmsg struct {
 	from          *state.StateObject
@@ -755,11 +897,89 @@ type CallArgs struct {
 	Data     string          `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, error) {
+// OverrideAccount holds the fields of an account to override for the execution
+// of a call. State replaces the entire storage of the account, while
+// StateDiff only replaces the given slots.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce"`
+	Code      *hexutil.Bytes               `json:"code"`
+	Balance   *hexutil.Big                 `json:"balance"`
+	State     *map[common.Hash]common.Hash `json:"state"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the collection of overridden accounts.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply overrides the fields of the specified accounts in the given state.
+func (diff *StateOverride) Apply(stateDb *state.StateDB) error {
+	if diff == nil {
+		return nil
+	}
+	for addr, account := range *diff {
+		if account.Nonce != nil {
+			stateDb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			stateDb.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			stateDb.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if account.State != nil {
+			stateDb.SetStorage(addr, *account.State)
+		}
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				stateDb.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// BlockOverrides holds the fields of the block context to override for the
+// execution of a call.
+type BlockOverrides struct {
+	Number    *hexutil.Big    `json:"number"`
+	Timestamp *hexutil.Big    `json:"timestamp"`
+	Coinbase  *common.Address `json:"coinbase"`
+	GasLimit  *hexutil.Big    `json:"gasLimit"`
+}
+
+// Apply returns a copy of the header with the overridden fields replaced.
+func (o *BlockOverrides) Apply(header *types.Header) *types.Header {
+	header = types.CopyHeader(header)
+	if o == nil {
+		return header
+	}
+	if o.Number != nil {
+		header.Number = new(big.Int).Set(o.Number.ToInt())
+	}
+	if o.Timestamp != nil {
+		header.Time = new(big.Int).Set(o.Timestamp.ToInt())
+	}
+	if o.Coinbase != nil {
+		header.Coinbase = *o.Coinbase
+	}
+	if o.GasLimit != nil {
+		header.GasLimit = new(big.Int).Set(o.GasLimit.ToInt())
+	}
+	return header
+}
+
+// callState prepares the state and block header to simulate a call with: a
+// copy of the state at the given block, with the sender of the call funded
+// and the overrides applied. A nil state is returned if the block is not
+// found.
+func (s *PublicBlockChainAPI) callState(args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (*state.StateDB, *state.StateObject, *types.Header, error) {
 	// Fetch the state associated with the block number
-	stateDb, block, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+	stateDb, block, err := stateAndBlockByNumber(s.miner, s.bc, blockNr)
 	if stateDb == nil || err != nil {
-		return "0x", nil, err
+		return nil, nil, nil, err
 	}
 	stateDb = stateDb.Copy()
 
@@ -777,6 +997,22 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 	}
 	from.SetBalance(common.MaxBig)
 
+	if err := overrides.Apply(stateDb); err != nil {
+		return nil, nil, nil, err
+	}
+	// Keep the funding of the sender and the overrides out of the changes
+	// seen by state tracers
+	stateDb.Finalise(false)
+
+	return stateDb, from, blockOverrides.Apply(block.Header()), nil
+}
+
+func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (string, *big.Int, error) {
+	stateDb, from, header, err := s.callState(args, blockNr, overrides, blockOverrides)
+	if stateDb == nil || err != nil {
+		return "0x", nil, err
+	}
+
 	// Assemble the CALL invocation
 	msg := callmsg{
 		from:     from,
@@ -794,7 +1030,7 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 	}
 
 	// Execute the call and return
-	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, block.Header())
+	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, header)
 	gp := new(core.GasPool).AddGas(common.MaxBig)
 
 	res, requiredGas, _, err := core.NewStateTransition(vmenv, msg, gp).TransitionDb()
@@ -806,14 +1042,16 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(args CallArgs, blockNr rpc.BlockNumber) (string, error) {
-	result, _, err := s.doCall(args, blockNr)
+// The optional overrides replace fields of accounts and of the block context
+// for the execution of the call.
+func (s *PublicBlockChainAPI) Call(args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (string, error) {
+	result, _, err := s.doCall(args, blockNr, overrides, blockOverrides)
 	return result, err
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the given transaction.
 func (s *PublicBlockChainAPI) EstimateGas(args CallArgs) (*rpc.HexNumber, error) {
-	_, gas, err := s.doCall(args, rpc.PendingBlockNumber)
+	_, gas, err := s.doCall(args, rpc.PendingBlockNumber, nil, nil)
 	return rpc.NewHexNumber(gas), err
 }
 
@@ -1066,7 +1304,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByBlockHashAndIndex(blockHash c
 
 // GetTransactionCount returns the number of transactions the given address has sent for the given block number
 func (s *PublicTransactionPoolAPI) GetTransactionCount(address common.Address, blockNr rpc.BlockNumber) (*rpc.HexNumber, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -1613,6 +1851,22 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// Backup writes a consistent copy of the chain database, and of the address
+// transaction index if it is enabled, into the directory at path while the
+// node keeps running. The directory must not exist or be empty.
+func (api *PrivateAdminAPI) Backup(path string) (*core.BackupManifest, error) {
+	start := time.Now()
+	glog.V(logger.Info).Infof("Backing up chain database to %s", path)
+	manifest, err := api.eth.BlockChain().Backup(path, api.eth.indexesDb, func(name string, keys uint64) {
+		glog.V(logger.Info).Infof("Backed up %d keys of the %s database, elapsed %v", keys, name, time.Since(start))
+	})
+	if err != nil {
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Backed up chain database at block #%d [%x…] to %s in %v", manifest.HeadNumber, manifest.HeadHash.Bytes()[:4], path, time.Since(start))
+	return manifest, nil
+}
+
 func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	for _, b := range bs {
 		if !chain.HasBlock(b.Hash()) {
@@ -1699,7 +1953,7 @@ func (api *PublicGethAPI) GetAddressTransactions(address common.Address, blockSt
 	if toOrFrom == "tf" || toOrFrom == "ft" {
 		toOrFrom = "b"
 	}
-	// _s_tandard OR _c_ontract
+	// _s_tandard OR _c_ontract, or _i_nternal value transfers and contract creations
 	if txKindOf == "sc" || txKindOf == "cs" {
 		txKindOf = "b"
 	}
@@ -1773,6 +2027,72 @@ func (api *PublicGethAPI) GetATXIBuildStatus() (*core.AtxiProgressT, error) {
 	return progress, nil
 }
 
+// GetTokenTransfers gets the token Transfer events of a given address, optionally only those
+// of the token contract token. Optional values include start and stop block numbers, and
+// pagination of the transfers, sorted newest first unless reverse is set.
+func (api *PublicGethAPI) GetTokenTransfers(address common.Address, token *common.Address, blockStartN uint64, blockEndN rpc.BlockNumber, pagStart, pagEnd int, reverse bool) ([]*core.TokenTransfer, error) {
+	atxi := api.eth.BlockChain().GetAtxi()
+	if atxi == nil || !atxi.Tokens {
+		return nil, errors.New("token transfer indexing not enabled")
+	}
+	if blockEndN == rpc.LatestBlockNumber || blockEndN == rpc.PendingBlockNumber {
+		blockEndN = 0
+	}
+	list, err := core.GetTokenTransfers(atxi.Db, address, token, blockStartN, uint64(blockEndN.Int64()), pagStart, pagEnd, reverse)
+	if err != nil {
+		return nil, err
+	}
+	// Return an empty array rather than null if no transfers are found.
+	if list == nil {
+		list = []*core.TokenTransfer{}
+	}
+	return list, nil
+}
+
+// BuildTokenTransferIndex starts building the token transfer indexes of the given block range
+// in the background, like BuildATXI does for the address transaction indexes.
+func (api *PublicGethAPI) BuildTokenTransferIndex(start, stop, step rpc.BlockNumber) (bool, error) {
+	convert := func(number rpc.BlockNumber) uint64 {
+		switch number {
+		case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+			return math.MaxUint64
+		default:
+			return uint64(number.Int64())
+		}
+	}
+
+	atxi := api.eth.BlockChain().GetAtxi()
+	if atxi == nil || !atxi.Tokens {
+		return false, errors.New("token transfer indexing not enabled")
+	}
+	if atxi.AutoMode {
+		return false, errors.New("token transfer indexing already running via the auto build mode")
+	}
+	if step == 0 {
+		return false, errors.New("step must be greater than zero")
+	}
+	progress := atxi.TokenProgress
+	if progress != nil && progress.Current < progress.Stop && progress.LastError == nil {
+		return false, fmt.Errorf("token transfer index build process is already running (first block: %d, last block: %d, current block: %d)", progress.Start, progress.Stop, progress.Current)
+	}
+
+	go core.BuildTokenTransferIndex(api.eth.BlockChain(), atxi.Db, convert(start), convert(stop), convert(step))
+
+	return true, nil
+}
+
+// GetTokenTransferIndexBuildStatus returns the progress of the token transfer index build.
+func (api *PublicGethAPI) GetTokenTransferIndexBuildStatus() (*core.AtxiProgressT, error) {
+	progress, err := api.eth.BlockChain().GetTokenTransferBuildProgress()
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return nil, errors.New("no progress available for unstarted token transfer indexing process")
+	}
+	return progress, nil
+}
+
 // PublicDebugAPI is the collection of Etheruem APIs exposed over the public
 // debugging endpoint.
 type PublicDebugAPI struct {
@@ -1799,6 +2119,107 @@ func (api *PublicDebugAPI) DumpBlock(number uint64) (state.Dump, error) {
 	return stateDb.RawDump([]common.Address{}), nil
 }
 
+// StorageRangeResult is the result of a debug_storageRangeAt API call.
+type StorageRangeResult struct {
+	Storage storageMap   `json:"storage"`
+	NextKey *common.Hash `json:"nextKey"` // nil if Storage includes the last key in the trie
+}
+
+type storageMap map[common.Hash]storageEntry
+
+type storageEntry struct {
+	Key   *common.Hash `json:"key"` // nil if the preimage of the hashed key is not known
+	Value common.Hash  `json:"value"`
+}
+
+// StorageRangeAt returns at most limit storage slots of the given contract,
+// as they were right before the transaction with the given index of the block
+// was executed. Slots are listed in the order of the storage trie, that is by
+// hashed key, starting at startKey. The returned next key can be passed as the
+// start key of the following call to page through the whole storage.
+func (api *PublicDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, address common.Address, startKey hexutil.Bytes, limit int) (StorageRangeResult, error) {
+	_, vmenv, err := api.computeTxEnv(blockHash, txIndex, vm.Config{})
+	if err != nil {
+		return StorageRangeResult{}, err
+	}
+	st := vmenv.Db().(*state.StateDB).StorageTrie(address)
+	if st == nil {
+		return StorageRangeResult{}, fmt.Errorf("account %x doesn't exist", address)
+	}
+	return storageRangeAt(st, startKey, limit)
+}
+
+func storageRangeAt(st state.Trie, start []byte, limit int) (StorageRangeResult, error) {
+	it := trie.NewIterator(st.NodeIterator(start))
+	result := StorageRangeResult{Storage: storageMap{}}
+	for i := 0; i < limit && it.Next(); i++ {
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return StorageRangeResult{}, err
+		}
+		entry := storageEntry{Value: common.BytesToHash(content)}
+		if preimage := st.GetKey(it.Key); preimage != nil {
+			key := common.BytesToHash(preimage)
+			entry.Key = &key
+		}
+		result.Storage[common.BytesToHash(it.Key)] = entry
+	}
+	// Add the next key so clients can continue downloading
+	if it.Next() {
+		next := common.BytesToHash(it.Key)
+		result.NextKey = &next
+	}
+	return result, it.Err
+}
+
+// AccountRangeResult is the result of a debug_accountRange API call.
+type AccountRangeResult struct {
+	Accounts map[common.Hash]*RangeAccount `json:"accounts"`
+	Next     *common.Hash                  `json:"next"` // nil if Accounts includes the last account in the trie
+}
+
+// RangeAccount is an account listed by debug_accountRange, keyed by the hash
+// of its address. Its storage can be listed with debug_storageRangeAt.
+type RangeAccount struct {
+	Address  *common.Address `json:"address"` // nil if the preimage of the hashed address is not known
+	Balance  *hexutil.Big    `json:"balance"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Root     common.Hash     `json:"root"`
+	CodeHash common.Hash     `json:"codeHash"`
+}
+
+// AccountRange returns at most limit accounts of the state at the given
+// block. Accounts are listed in the order of the state trie, that is by hashed
+// address, starting at startHash. The returned next hash can be passed as the
+// start hash of the following call to page through the whole state.
+func (api *PublicDebugAPI) AccountRange(number rpc.BlockNumber, startHash common.Hash, limit int) (AccountRangeResult, error) {
+	stateDb, _, err := stateAndBlockByNumber(api.eth.Miner(), api.eth.BlockChain(), number)
+	if err != nil {
+		return AccountRangeResult{}, err
+	}
+	if stateDb == nil {
+		return AccountRangeResult{}, fmt.Errorf("block #%d not found", number)
+	}
+	accounts, next, err := stateDb.AccountRange(startHash, limit)
+	if err != nil {
+		return AccountRangeResult{}, err
+	}
+	result := AccountRangeResult{
+		Accounts: make(map[common.Hash]*RangeAccount, len(accounts)),
+		Next:     next,
+	}
+	for _, account := range accounts {
+		result.Accounts[account.Hash] = &RangeAccount{
+			Address:  account.Address,
+			Balance:  (*hexutil.Big)(account.Balance),
+			Nonce:    hexutil.Uint64(account.Nonce),
+			Root:     account.Root,
+			CodeHash: common.BytesToHash(account.CodeHash),
+		}
+	}
+	return result, nil
+}
+
 // AccountExist checks whether an address is considered exists at a given block.
 func (api *PublicDebugAPI) AccountExist(address common.Address, number uint64) (bool, error) {
 	block := api.eth.BlockChain().GetBlockByNumber(number)
@@ -1947,36 +2368,159 @@ func (api *PublicDebugAPI) Vmodule(s string) (string, error) {
 	return ns, err
 }
 
+// TraceArgs holds extra parameters to the trace functions.
+type TraceArgs struct {
+	*vm.LogConfig
+	Tracer  *string
+	Timeout *string
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as the amount of
 // gas used and the return value
 type ExecutionResult struct {
-	Gas         *big.Int `json:"gas"`
-	ReturnValue string   `json:"returnValue"`
+	Gas         *big.Int       `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes stores a structured log emitted by the EVM while replaying a
+// transaction in debug mode
+type StructLogRes struct {
+	Pc      uint64             `json:"pc"`
+	Op      string             `json:"op"`
+	Gas     *big.Int           `json:"gas"`
+	GasCost *big.Int           `json:"gasCost"`
+	Depth   int                `json:"depth"`
+	Error   string             `json:"error,omitempty"`
+	Stack   *[]string          `json:"stack,omitempty"`
+	Memory  *[]string          `json:"memory,omitempty"`
+	Storage *map[string]string `json:"storage,omitempty"`
+}
+
+// formatLogs formats EVM returned structured logs for json output. Stack,
+// memory and storage are left out when their capture was disabled.
+func formatLogs(structLogs []vm.StructLog, cfg vm.LogConfig) []StructLogRes {
+	formatted := make([]StructLogRes, len(structLogs))
+	for index, trace := range structLogs {
+		formatted[index] = StructLogRes{
+			Pc:      trace.Pc,
+			Op:      trace.OpName(),
+			Gas:     trace.Gas,
+			GasCost: trace.GasCost,
+			Depth:   trace.Depth,
+			Error:   trace.ErrorString(),
+		}
+		if !cfg.DisableStack {
+			stack := make([]string, len(trace.Stack))
+			for i, stackValue := range trace.Stack {
+				stack[i] = fmt.Sprintf("%x", common.LeftPadBytes(stackValue.Bytes(), 32))
+			}
+			formatted[index].Stack = &stack
+		}
+		if !cfg.DisableMemory {
+			memory := make([]string, 0, (len(trace.Memory)+31)/32)
+			for i := 0; i+32 <= len(trace.Memory); i += 32 {
+				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
+			}
+			formatted[index].Memory = &memory
+		}
+		if !cfg.DisableStorage {
+			storage := make(map[string]string)
+			for i, storageValue := range trace.Storage {
+				storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
+			}
+			formatted[index].Storage = &storage
+		}
+	}
+	return formatted
 }
 
-// TraceCall executes a call and returns the amount of gas and optionally returned values.
-func (s *PublicBlockChainAPI) TraceCall(args CallArgs, blockNr rpc.BlockNumber) (*ExecutionResult, error) {
-	// Fetch the state associated with the block number
-	stateDb, block, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
-	if stateDb == nil || err != nil {
-		return nil, err
+// logConfig returns the struct logger configuration of the trace arguments.
+func (args *TraceArgs) logConfig() vm.LogConfig {
+	if args == nil || args.LogConfig == nil {
+		return vm.LogConfig{}
 	}
-	stateDb = stateDb.Copy()
+	return *args.LogConfig
+}
 
-	// Retrieve the account state object to interact with
-	var from *state.StateObject
-	if args.From == (common.Address{}) {
-		accounts := s.am.Accounts()
-		if len(accounts) == 0 {
-			from = stateDb.GetOrNewStateObject(common.Address{})
-		} else {
-			from = stateDb.GetOrNewStateObject(accounts[0].Address)
+// newTracer creates the tracer requested by the trace arguments. The struct
+// logger is used when no tracer is named.
+func (args *TraceArgs) newTracer() (vm.Tracer, error) {
+	if args == nil || args.Tracer == nil {
+		logConfig := args.logConfig()
+		return vm.NewStructLogger(&logConfig), nil
+	}
+	switch *args.Tracer {
+	case "callTracer":
+		return vm.NewCallTracer(), nil
+	case "prestateTracer":
+		return NewPrestateTracer(false), nil
+	case "stateDiff", "stateDiffTracer":
+		return NewPrestateTracer(true), nil
+	}
+	// Any other tracer is JavaScript code to run for every step
+	timeout := defaultTraceTimeout
+	if args.Timeout != nil {
+		var err error
+		if timeout, err = time.ParseDuration(*args.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid tracer timeout: %v", err)
 		}
-	} else {
-		from = stateDb.GetOrNewStateObject(args.From)
 	}
-	from.SetBalance(common.MaxBig)
+	tracer, err := NewJavascriptTracer(*args.Tracer, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracer: %v", err)
+	}
+	return tracer, nil
+}
+
+// traceResult assembles the result of a trace from the tracer used and the
+// amount of gas used by the traced message.
+func (args *TraceArgs) traceResult(tracer vm.Tracer, gas *big.Int) (interface{}, error) {
+	switch tracer := tracer.(type) {
+	case *JavascriptTracer:
+		return tracer.GetResult()
+	case *PrestateTracer:
+		return tracer.Result()
+	case *vm.CallTracer:
+		return tracer.Result(), nil
+	case *vm.StructLogger:
+		return &ExecutionResult{
+			Gas:         gas,
+			Failed:      tracer.Error() != nil,
+			ReturnValue: fmt.Sprintf("%x", tracer.Output()),
+			StructLogs:  formatLogs(tracer.StructLogs(), args.logConfig()),
+		}, nil
+	}
+	return nil, nil
+}
+
+// TraceCallArgs holds extra parameters to TraceCall: the trace options along
+// with the state and block context overrides to execute the call with.
+type TraceCallArgs struct {
+	TraceArgs
+	StateOverrides *StateOverride  `json:"stateOverrides"`
+	BlockOverrides *BlockOverrides `json:"blockOverrides"`
+}
+
+// TraceCall executes a call and returns the amount of gas, the returned
+// value and the structured logs created during the execution of the EVM.
+// When the callTracer is requested the tree of call frames is returned
+// instead, and any other tracer is run as JavaScript code.
+func (s *PublicBlockChainAPI) TraceCall(args CallArgs, blockNr rpc.BlockNumber, config *TraceCallArgs) (interface{}, error) {
+	var (
+		traceArgs      *TraceArgs
+		overrides      *StateOverride
+		blockOverrides *BlockOverrides
+	)
+	if config != nil {
+		traceArgs, overrides, blockOverrides = &config.TraceArgs, config.StateOverrides, config.BlockOverrides
+	}
+	stateDb, from, header, err := s.callState(args, blockNr, overrides, blockOverrides)
+	if stateDb == nil || err != nil {
+		return nil, err
+	}
 
 	// Assemble the CALL invocation
 	msg := callmsg{
@@ -1987,47 +2531,176 @@ func (s *PublicBlockChainAPI) TraceCall(args CallArgs, blockNr rpc.BlockNumber)
 		value:    args.Value.BigInt(),
 		data:     common.FromHex(args.Data),
 	}
-	if msg.gas.Sign() == 0 {
+	if msg.gas == nil || msg.gas.Sign() == 0 {
 		msg.gas = big.NewInt(50000000)
 	}
-	if msg.gasPrice.Sign() == 0 {
+	if msg.gasPrice == nil || msg.gasPrice.Sign() == 0 {
 		msg.gasPrice = new(big.Int).Mul(big.NewInt(50), common.Shannon)
 	}
 
 	// Execute the call and return
-	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, block.Header())
+	tracer, err := traceArgs.newTracer()
+	if err != nil {
+		return nil, err
+	}
+	vmenv := core.NewEnvWithConfig(stateDb, s.config, s.bc, msg, header, vm.Config{Debug: true, Tracer: tracer})
 	gp := new(core.GasPool).AddGas(common.MaxBig)
 
-	ret, gas, err := core.ApplyMessage(vmenv, msg, gp)
-	return &ExecutionResult{
-		Gas:         gas,
-		ReturnValue: fmt.Sprintf("%x", ret),
-	}, nil
+	_, gas, err := core.ApplyMessage(vmenv, msg, gp)
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	return traceArgs.traceResult(tracer, gas)
 }
 
-// TraceTransaction returns the amount of gas and execution result of the given transaction.
-func (s *PublicDebugAPI) TraceTransaction(txHash common.Hash) (*ExecutionResult, error) {
-	var result *ExecutionResult
+// TraceTransaction returns the amount of gas, the execution result and the
+// structured logs created during the execution of the given transaction.
+// When the callTracer is requested the tree of call frames is returned
+// instead, the prestateTracer and stateDiff tracers return the state the
+// transaction read and changed, and any other tracer is run as JavaScript
+// code. The stateDiff tracer is also accepted as stateDiffTracer.
+func (s *PublicDebugAPI) TraceTransaction(txHash common.Hash, config *TraceArgs) (interface{}, error) {
 	tx, blockHash, _, txIndex := core.GetTransaction(s.eth.ChainDb(), txHash)
 	if tx == nil {
-		return result, fmt.Errorf("tx '%x' not found", txHash)
+		return nil, fmt.Errorf("tx '%x' not found", txHash)
 	}
 
-	msg, vmenv, err := s.computeTxEnv(blockHash, int(txIndex))
+	tracer, err := config.newTracer()
+	if err != nil {
+		return nil, err
+	}
+	msg, vmenv, err := s.computeTxEnv(blockHash, int(txIndex), vm.Config{Debug: true, Tracer: tracer})
 	if err != nil {
 		return nil, err
 	}
 
 	gp := new(core.GasPool).AddGas(tx.Gas())
-	ret, gas, err := core.ApplyMessage(vmenv, msg, gp)
-	return &ExecutionResult{
-		Gas:         gas,
-		ReturnValue: fmt.Sprintf("%x", ret),
-	}, nil
+	_, gas, err := core.ApplyMessage(vmenv, msg, gp)
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	return config.traceResult(tracer, gas)
+}
+
+// TxTraceResult is the result of tracing a single transaction of a block.
+type TxTraceResult struct {
+	TxHash common.Hash `json:"txHash"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// TraceBlockByNumber replays the block with the given number and returns the
+// trace of each of its transactions.
+func (s *PublicDebugAPI) TraceBlockByNumber(number rpc.BlockNumber, config *TraceArgs) ([]*TxTraceResult, error) {
+	var block *types.Block
+	switch number {
+	case rpc.PendingBlockNumber:
+		return nil, errors.New("tracing the pending block is not supported")
+	case rpc.LatestBlockNumber:
+		block = s.eth.BlockChain().CurrentBlock()
+	default:
+		block = s.eth.BlockChain().GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return s.traceBlock(block, config)
+}
+
+// TraceBlockByHash replays the block with the given hash and returns the
+// trace of each of its transactions.
+func (s *PublicDebugAPI) TraceBlockByHash(hash common.Hash, config *TraceArgs) ([]*TxTraceResult, error) {
+	block := s.eth.BlockChain().GetBlock(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	return s.traceBlock(block, config)
+}
+
+// TraceBadBlock replays the RLP encoded block and returns the trace of each of
+// its transactions. The block does not need to be part of the chain, only its
+// parent does, so that rejected blocks can be investigated. If a transaction
+// cannot be applied, its trace carries the error and the remaining
+// transactions are left out.
+func (s *PublicDebugAPI) TraceBadBlock(blockRlp string, config *TraceArgs) ([]*TxTraceResult, error) {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(common.FromHex(blockRlp), block); err != nil {
+		return nil, fmt.Errorf("could not decode block: %v", err)
+	}
+	return s.traceBlock(block, config)
+}
+
+// GetBadBlocks returns the blocks rejected by the chain which are kept in the
+// local bad block store, most recent first.
+func (s *PublicDebugAPI) GetBadBlocks() ([]*BadBlock, error) {
+	return GetBadBlocks(s.eth.ChainDb())
+}
+
+// ChaindbInspect counts the keys and bytes of the chain database by kind of
+// data. It iterates over the whole database, which may take a long time.
+func (s *PublicDebugAPI) ChaindbInspect(ctx context.Context) ([]core.DatabaseStat, error) {
+	return core.InspectDatabase(s.eth.ChainDb(), ctx.Done(), nil)
+}
+
+// ChaindbStats returns the LevelDB level statistics of the chain database.
+func (s *PublicDebugAPI) ChaindbStats() (string, error) {
+	return core.DatabaseStats(s.eth.ChainDb())
+}
+
+// traceBlock replays all transactions of the block once on top of the state
+// of its parent, tracing each of them with a tracer of its own.
+func (s *PublicDebugAPI) traceBlock(block *types.Block, config *TraceArgs) ([]*TxTraceResult, error) {
+	// Fail early on invalid tracer options, before replaying anything. The
+	// tracer is that of the first transaction.
+	tracer, err := config.newTracer()
+	if err != nil {
+		return nil, err
+	}
+	parent := s.eth.BlockChain().GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := s.eth.BlockChain().StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	tracers := make([]vm.Tracer, 0, len(block.Transactions()))
+	vmConfig := func(i int, tx *types.Transaction) vm.Config {
+		if len(tracers) > 0 {
+			tracer, _ = config.newTracer()
+		}
+		tracers = append(tracers, tracer)
+		return vm.Config{Debug: true, Tracer: tracer}
+	}
+	processor := core.NewStateProcessor(s.eth.chainConfig, s.eth.BlockChain())
+	receipts, _, _, err := processor.ProcessWithConfig(block, statedb, vmConfig)
+
+	results := make([]*TxTraceResult, 0, len(tracers))
+	for i, receipt := range receipts {
+		result := &TxTraceResult{TxHash: receipt.TxHash}
+		if res, err := config.traceResult(tracers[i], receipt.GasUsed); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Result = res
+		}
+		results = append(results, result)
+	}
+	if err != nil {
+		if len(results) == len(block.Transactions()) {
+			return nil, err
+		}
+		results = append(results, &TxTraceResult{
+			TxHash: block.Transactions()[len(results)].Hash(),
+			Error:  err.Error(),
+		})
+	}
+	return results, nil
 }
 
 // computeTxEnv returns the execution environment of a certain transaction.
-func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.Message, *core.VMEnv, error) {
+// The EVM of the returned environment is configured with cfg.
+func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, cfg vm.Config) (core.Message, *core.VMEnv, error) {
 
 	// Create the parent state.
 	block := s.eth.BlockChain().GetBlock(blockHash)
@@ -2068,11 +2741,11 @@ func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.
 			data:     tx.Data(),
 		}
 
-		vmenv := core.NewEnv(statedb, s.eth.chainConfig, s.eth.BlockChain(), msg, block.Header())
 		if idx == txIndex {
-			return msg, vmenv, nil
+			return msg, core.NewEnvWithConfig(statedb, s.eth.chainConfig, s.eth.BlockChain(), msg, block.Header(), cfg), nil
 		}
 
+		vmenv := core.NewEnv(statedb, s.eth.chainConfig, s.eth.BlockChain(), msg, block.Header())
 		gp := new(core.GasPool).AddGas(tx.Gas())
 		_, _, err := core.ApplyMessage(vmenv, msg, gp)
 		if err != nil {
@@ -2108,3 +2781,80 @@ func (s *PublicNetAPI) PeerCount() *rpc.HexNumber {
 func (s *PublicNetAPI) Version() string {
 	return fmt.Sprintf("%d", s.networkVersion)
 }
+
+// PublicWebchainAPI offers the Webchain specific subscriptions, served
+// through webchain_subscribe.
+type PublicWebchainAPI struct {
+	muDroppedTxSubs sync.Mutex
+	droppedTxSubs   map[string]rpc.Subscription
+}
+
+// NewPublicWebchainAPI creates a new RPC service with the Webchain specific
+// subscriptions, fed by the events posted to eventMux from now on.
+func NewPublicWebchainAPI(eventMux *event.TypeMux) *PublicWebchainAPI {
+	api := &PublicWebchainAPI{
+		droppedTxSubs: make(map[string]rpc.Subscription),
+	}
+	go api.subscriptionLoop(eventMux.Subscribe(core.TxDroppedEvent{}))
+
+	return api
+}
+
+// subscriptionLoop listens for events on the global event mux and creates notifications for subscriptions.
+func (s *PublicWebchainAPI) subscriptionLoop(sub event.Subscription) {
+	for event := range sub.Chan() {
+		dropped := newRPCDroppedTransaction(event.Data.(core.TxDroppedEvent))
+		s.muDroppedTxSubs.Lock()
+		for id, sub := range s.droppedTxSubs {
+			if sub.Notify(dropped) == rpc.ErrNotificationNotFound {
+				delete(s.droppedTxSubs, id)
+			}
+		}
+		s.muDroppedTxSubs.Unlock()
+	}
+}
+
+// RPCDroppedTransaction is the notification sent for a transaction which was
+// rejected on entry to the transaction pool, or evicted from it.
+type RPCDroppedTransaction struct {
+	Hash    common.Hash    `json:"hash"`
+	From    common.Address `json:"from"`
+	Reason  string         `json:"reason"`
+	Evicted bool           `json:"evicted"`
+}
+
+func newRPCDroppedTransaction(ev core.TxDroppedEvent) *RPCDroppedTransaction {
+	return &RPCDroppedTransaction{
+		Hash:    ev.Tx.Hash(),
+		From:    ev.Sender,
+		Reason:  ev.Reason.Error(),
+		Evicted: ev.Evicted,
+	}
+}
+
+// DroppedTransactions creates a subscription that is triggered each time a
+// transaction is rejected on entry to the transaction pool, or evicted from it
+// without being mined. Notifications carry the reason, and whether the
+// transaction was rejected or evicted.
+func (s *PublicWebchainAPI) DroppedTransactions(ctx context.Context) (rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+
+	subscription, err := notifier.NewSubscription(func(id string) {
+		s.muDroppedTxSubs.Lock()
+		delete(s.droppedTxSubs, id)
+		s.muDroppedTxSubs.Unlock()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.muDroppedTxSubs.Lock()
+	s.droppedTxSubs[subscription.ID()] = subscription
+	s.muDroppedTxSubs.Unlock()
+
+	return subscription, nil
+}
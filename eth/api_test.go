@@ -0,0 +1,649 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+// traceTestInitCode stores 42 in slot 0 of the created contract and deploys
+// no code: PUSH1 0x2a PUSH1 0x00 SSTORE STOP.
+var traceTestInitCode = common.FromHex("602a60005500")
+
+// newTraceTestBackend creates an Ethereum backend holding a chain with a
+// single block, which contains a contract creation and a value transfer.
+func newTraceTestBackend(t *testing.T) (*Ethereum, *types.Block) {
+	var (
+		evmux       = new(event.TypeMux)
+		pow         = new(core.FakePow)
+		db, _       = ethdb.NewMemDatabase()
+		genesis     = core.WriteGenesisBlockForTesting(db, testBank)
+		chainConfig = &core.ChainConfig{
+			Forks: []*core.Fork{
+				{
+					Name:  "Homestead",
+					Block: big.NewInt(0),
+				},
+			},
+		}
+		blockchain, _ = core.NewBlockChain(db, chainConfig, pow, evmux)
+	)
+	chain, _ := core.GenerateChain(chainConfig, genesis, db, 1, func(i int, gen *core.BlockGen) {
+		nonce := gen.TxNonce(testBank.Address)
+		create, _ := types.NewContractCreation(nonce, new(big.Int), big.NewInt(100000), new(big.Int), traceTestInitCode).SignECDSA(testBankKey)
+		gen.AddTx(create)
+		transfer, _ := types.NewTransaction(nonce+1, common.Address{0x01}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil).SignECDSA(testBankKey)
+		gen.AddTx(transfer)
+	})
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatalf("failed to insert chain: %v", res.Error)
+	}
+	return &Ethereum{chainConfig: chainConfig, chainDb: db, blockchain: blockchain, eventMux: evmux}, chain[0]
+}
+
+func TestTraceTransactionStructLogs(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+
+	res, err := api.TraceTransaction(block.Transactions()[0].Hash(), &TraceArgs{LogConfig: &vm.LogConfig{DisableMemory: true}})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	result, ok := res.(*ExecutionResult)
+	if !ok {
+		t.Fatalf("unexpected result type %T", res)
+	}
+	if result.Failed {
+		t.Error("expected transaction to succeed")
+	}
+	ops := []string{"PUSH1", "PUSH1", "SSTORE", "STOP"}
+	if len(result.StructLogs) != len(ops) {
+		t.Fatalf("expected %d struct logs, got %d", len(ops), len(result.StructLogs))
+	}
+	for i, op := range ops {
+		if log := result.StructLogs[i]; log.Op != op || log.Depth != 1 {
+			t.Errorf("log %d: expected %s at depth 1, got %s at depth %d", i, op, log.Op, log.Depth)
+		}
+	}
+	sstore := result.StructLogs[2]
+	if sstore.Memory != nil {
+		t.Error("expected memory to be disabled")
+	}
+	if sstore.Stack == nil || len(*sstore.Stack) != 2 {
+		t.Errorf("expected 2 stack items for SSTORE, got %v", sstore.Stack)
+	}
+	key := common.Hash{}.Hex()[2:]
+	if sstore.Storage == nil || (*sstore.Storage)[key] != common.BigToHash(big.NewInt(42)).Hex()[2:] {
+		t.Errorf("expected SSTORE to capture the stored slot, got %v", sstore.Storage)
+	}
+}
+
+func TestTraceTransactionCallTracer(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+
+	tracer := "callTracer"
+	res, err := api.TraceTransaction(block.Transactions()[1].Hash(), &TraceArgs{Tracer: &tracer})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	frame, ok := res.(*vm.CallFrame)
+	if !ok {
+		t.Fatalf("unexpected result type %T", res)
+	}
+	if frame.Type != "CALL" || frame.From != testBank.Address || frame.To != (common.Address{0x01}) || frame.Value.ToInt().Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("unexpected call frame: %+v", frame)
+	}
+
+	unknown := "noSuchTracer"
+	if _, err := api.TraceTransaction(block.Transactions()[1].Hash(), &TraceArgs{Tracer: &unknown}); err == nil {
+		t.Error("expected an error for an unknown tracer")
+	}
+}
+
+func TestTraceBlock(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+
+	results, err := api.TraceBlockByNumber(rpc.BlockNumber(1), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(results) != len(block.Transactions()) {
+		t.Fatalf("expected %d results, got %d", len(block.Transactions()), len(results))
+	}
+	for i, result := range results {
+		if result.TxHash != block.Transactions()[i].Hash() {
+			t.Errorf("result %d: expected tx %x, got %x", i, block.Transactions()[i].Hash(), result.TxHash)
+		}
+		if result.Error != "" {
+			t.Errorf("result %d: unexpected error %s", i, result.Error)
+		}
+	}
+	if logs := results[0].Result.(*ExecutionResult).StructLogs; len(logs) != 4 {
+		t.Errorf("expected 4 struct logs for the contract creation, got %d", len(logs))
+	}
+	if logs := results[1].Result.(*ExecutionResult).StructLogs; len(logs) != 0 {
+		t.Errorf("expected no struct logs for the value transfer, got %d", len(logs))
+	}
+	byHash, err := api.TraceBlockByHash(block.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block by hash: %v", err)
+	}
+	if len(byHash) != len(results) {
+		t.Errorf("expected %d results by hash, got %d", len(results), len(byHash))
+	}
+}
+
+func TestTraceTransactionJavascript(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+
+	tracer := `{
+		stores: 0,
+		step: function(log, db) {
+			if (log.op.toString() == "SSTORE") {
+				this.stores++;
+				this.slot = log.stack.peek(0).toNumber();
+				this.value = log.stack.peek(1).toNumber();
+			}
+		},
+		result: function(ctx, db) {
+			return {stores: this.stores, slot: this.slot, value: this.value, type: ctx.type, stored: db.getState(ctx.to, "0x00")};
+		}
+	}`
+	res, err := api.TraceTransaction(block.Transactions()[0].Hash(), &TraceArgs{Tracer: &tracer})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	result, ok := res.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected result type %T", res)
+	}
+	if result["stores"] != int64(1) && result["stores"] != float64(1) {
+		t.Errorf("expected 1 SSTORE, got %v", result["stores"])
+	}
+	if result["slot"] != int64(0) && result["slot"] != float64(0) {
+		t.Errorf("expected slot 0, got %v", result["slot"])
+	}
+	if result["value"] != int64(42) && result["value"] != float64(42) {
+		t.Errorf("expected value 42, got %v", result["value"])
+	}
+	if result["type"] != "CREATE" {
+		t.Errorf("expected a CREATE frame, got %v", result["type"])
+	}
+	if result["stored"] != common.BigToHash(big.NewInt(42)).Hex() {
+		t.Errorf("expected the stored value to be readable from the state, got %v", result["stored"])
+	}
+
+	failing := `{step: function(log, db) { throw "boom"; }, result: function(ctx, db) { return 1; }}`
+	if _, err := api.TraceTransaction(block.Transactions()[0].Hash(), &TraceArgs{Tracer: &failing}); err == nil || !strings.Contains(err.Error(), "'step'") {
+		t.Errorf("expected the error of step to be returned, got %v", err)
+	}
+
+	looping := `{step: function(log, db) { while (true) {} }, result: function(ctx, db) { return 1; }}`
+	timeout := "100ms"
+	if _, err := api.TraceTransaction(block.Transactions()[0].Hash(), &TraceArgs{Tracer: &looping, Timeout: &timeout}); err == nil {
+		t.Error("expected the tracer to time out")
+	}
+
+	// The timeout is disarmed once the trace is over
+	jst, err := NewJavascriptTracer(tracer, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	msg, vmenv, err := api.computeTxEnv(block.Hash(), 0, vm.Config{Debug: true, Tracer: jst})
+	if err != nil {
+		t.Fatalf("failed to compute tx env: %v", err)
+	}
+	if _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(common.MaxBig)); err != nil {
+		t.Fatalf("failed to apply message: %v", err)
+	}
+	if jst.timer.Stop() {
+		t.Error("expected the timeout to be stopped at the end of the trace")
+	}
+
+	// Every transaction of a block gets a tracer of its own
+	results, err := api.TraceBlockByHash(block.Hash(), &TraceArgs{Tracer: &tracer})
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	for i, result := range results {
+		if result.Error != "" {
+			t.Errorf("result %d: unexpected error %s", i, result.Error)
+		}
+	}
+	if len(results) != 2 || results[1].Result.(map[string]interface{})["type"] != "CALL" {
+		t.Errorf("unexpected block trace results: %v", results)
+	}
+}
+
+func TestTraceTransactionPrestate(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+
+	prestate := "prestateTracer"
+	res, err := api.TraceTransaction(block.Transactions()[0].Hash(), &TraceArgs{Tracer: &prestate})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	accounts, ok := res.(map[common.Address]*AccountState)
+	if !ok {
+		t.Fatalf("unexpected result type %T", res)
+	}
+	sender, ok := accounts[testBank.Address]
+	if !ok {
+		t.Fatalf("expected the sender in the prestate, got %v", accounts)
+	}
+	if uint64(*sender.Nonce) != 0 || sender.Balance.ToInt().Cmp(testBank.Balance) != 0 {
+		t.Errorf("expected the sender before gas was bought, got nonce %d balance %v", *sender.Nonce, sender.Balance)
+	}
+	contract := crypto.CreateAddress(testBank.Address, 0)
+	if _, ok := accounts[contract]; ok {
+		t.Errorf("expected the created contract to be missing from the prestate")
+	}
+
+	diff := "stateDiff"
+	res, err = api.TraceTransaction(block.Transactions()[0].Hash(), &TraceArgs{Tracer: &diff})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	stateDiff, ok := res.(*StateDiff)
+	if !ok {
+		t.Fatalf("unexpected result type %T", res)
+	}
+	if _, ok := stateDiff.Pre[contract]; ok {
+		t.Error("expected the created contract to be missing from the pre state")
+	}
+	post, ok := stateDiff.Post[contract]
+	if !ok {
+		t.Fatalf("expected the created contract in the post state, got %v", stateDiff.Post)
+	}
+	if value := post.Storage[common.Hash{}]; value != common.BigToHash(big.NewInt(42)) {
+		t.Errorf("expected slot 0 to be set to 42, got %x", value)
+	}
+	if pre, post := stateDiff.Pre[testBank.Address], stateDiff.Post[testBank.Address]; pre == nil || post == nil || uint64(*pre.Nonce) != 0 || uint64(*post.Nonce) != 1 {
+		t.Errorf("expected the sender nonce to go from 0 to 1, got %+v -> %+v", pre, post)
+	}
+
+	results, err := api.TraceBlockByNumber(rpc.BlockNumber(1), &TraceArgs{Tracer: &diff})
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	transfer := results[1].Result.(*StateDiff)
+	recipient := common.Address{0x01}
+	if _, ok := transfer.Pre[recipient]; ok {
+		t.Error("expected the recipient to be missing from the pre state")
+	}
+	if post := transfer.Post[recipient]; post == nil || post.Balance.ToInt().Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("expected the recipient to receive 1 wei, got %+v", post)
+	}
+
+	alias := "stateDiffTracer"
+	res, err = api.TraceTransaction(block.Transactions()[0].Hash(), &TraceArgs{Tracer: &alias})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	if _, ok := res.(*StateDiff); !ok {
+		t.Fatalf("unexpected result type %T for %s", res, alias)
+	}
+}
+
+// Tests that blocks are traced on the native EVM when SputnikVM is enabled,
+// which can't be traced. Without the sputnikvm build tag, any transaction run
+// on SputnikVM panics.
+func TestTraceBlockSputnikVM(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+
+	core.UseSputnikVM = true
+	defer func() { core.UseSputnikVM = false }()
+
+	tracer := "callTracer"
+	results, err := api.TraceBlockByHash(block.Hash(), &TraceArgs{Tracer: &tracer})
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(results) != len(block.Transactions()) {
+		t.Fatalf("expected %d results, got %d", len(block.Transactions()), len(results))
+	}
+	if frame, ok := results[1].Result.(*vm.CallFrame); !ok || frame.Type != "CALL" {
+		t.Errorf("expected the call frame of the value transfer, got %v", results[1].Result)
+	}
+}
+
+func TestStorageRangeAt(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+	contract := crypto.CreateAddress(testBank.Address, 0)
+
+	// The slot is set by the first transaction, so only shows after it
+	if _, err := api.StorageRangeAt(block.Hash(), 0, contract, nil, 10); err == nil {
+		t.Error("expected an error for a contract which doesn't exist yet")
+	}
+	result, err := api.StorageRangeAt(block.Hash(), 1, contract, nil, 10)
+	if err != nil {
+		t.Fatalf("failed to get storage range: %v", err)
+	}
+	if len(result.Storage) != 1 || result.NextKey != nil {
+		t.Fatalf("expected a single slot and no next key, got %v", result)
+	}
+	entry := result.Storage[crypto.Keccak256Hash(common.Hash{}.Bytes())]
+	if entry.Value != common.BigToHash(big.NewInt(42)) {
+		t.Errorf("expected slot 0 to hold 42, got %x", entry.Value)
+	}
+}
+
+func TestAccountRange(t *testing.T) {
+	eth, _ := newTraceTestBackend(t)
+	api := NewPublicDebugAPI(eth)
+
+	// Sender, contract, recipient and coinbase of the test chain
+	full, err := api.AccountRange(rpc.BlockNumber(1), common.Hash{}, 10)
+	if err != nil {
+		t.Fatalf("failed to get account range: %v", err)
+	}
+	if len(full.Accounts) != 4 || full.Next != nil {
+		t.Fatalf("expected 4 accounts and no next hash, got %d and %v", len(full.Accounts), full.Next)
+	}
+	if account := full.Accounts[crypto.Keccak256Hash(testBank.Address[:])]; account == nil || *account.Address != testBank.Address {
+		t.Errorf("expected the sender to be listed with its address, got %+v", account)
+	}
+	// Page through the accounts one at a time
+	var (
+		start = common.Hash{}
+		seen  = make(map[common.Hash]bool)
+	)
+	for i := 0; i < len(full.Accounts); i++ {
+		page, err := api.AccountRange(rpc.BlockNumber(1), start, 1)
+		if err != nil {
+			t.Fatalf("failed to get account range: %v", err)
+		}
+		if len(page.Accounts) != 1 {
+			t.Fatalf("page %d: expected 1 account, got %d", i, len(page.Accounts))
+		}
+		for hash := range page.Accounts {
+			seen[hash] = true
+		}
+		if (i < len(full.Accounts)-1) != (page.Next != nil) {
+			t.Fatalf("page %d: unexpected next hash %v", i, page.Next)
+		}
+		if page.Next != nil {
+			start = *page.Next
+		}
+	}
+	for hash := range full.Accounts {
+		if !seen[hash] {
+			t.Errorf("account %x was not paged through", hash)
+		}
+	}
+}
+
+func TestGetProof(t *testing.T) {
+	eth, block := newTraceTestBackend(t)
+	api := &PublicBlockChainAPI{config: eth.chainConfig, bc: eth.blockchain, chainDb: eth.chainDb}
+	contract := crypto.CreateAddress(testBank.Address, 0)
+
+	slot, missing := common.Hash{}.Hex(), common.BigToHash(big.NewInt(1)).Hex()
+	result, err := api.GetProof(contract, []string{slot, missing}, rpc.BlockNumber(1))
+	if err != nil {
+		t.Fatalf("failed to get proof: %v", err)
+	}
+	account, err := state.VerifyAccountProof(block.Root(), contract, decodeHexSlice(result.AccountProof))
+	if err != nil {
+		t.Fatalf("failed to verify account proof: %v", err)
+	}
+	if account == nil || account.Root != result.StorageHash || uint64(result.Nonce) != account.Nonce {
+		t.Fatalf("account proof doesn't match the result: %+v vs %+v", account, result)
+	}
+	for i, want := range []common.Hash{common.BigToHash(big.NewInt(42)), {}} {
+		proof := result.StorageProof[i]
+		value, err := state.VerifyStorageProof(result.StorageHash, common.HexToHash(proof.Key), decodeHexSlice(proof.Proof))
+		if err != nil {
+			t.Fatalf("failed to verify proof of slot %s: %v", proof.Key, err)
+		}
+		if value != want || proof.Value.ToInt().Cmp(want.Big()) != 0 {
+			t.Errorf("slot %s: expected %x, got %x (result %v)", proof.Key, want, value, proof.Value)
+		}
+	}
+
+	// A missing account is proven absent
+	absent := common.Address{0xff}
+	result, err = api.GetProof(absent, nil, rpc.BlockNumber(1))
+	if err != nil {
+		t.Fatalf("failed to get proof: %v", err)
+	}
+	if account, err := state.VerifyAccountProof(block.Root(), absent, decodeHexSlice(result.AccountProof)); account != nil || err != nil {
+		t.Errorf("expected the account to be proven absent, got %+v, %v", account, err)
+	}
+	// A tampered proof fails
+	proof := decodeHexSlice(result.AccountProof)
+	proof[0][len(proof[0])-1]++
+	if _, err := state.VerifyAccountProof(block.Root(), absent, proof); err == nil {
+		t.Error("expected a tampered proof to fail")
+	}
+}
+
+func decodeHexSlice(s []string) [][]byte {
+	b := make([][]byte, len(s))
+	for i := range s {
+		b[i] = common.FromHex(s[i])
+	}
+	return b
+}
+
+func TestCallOverrides(t *testing.T) {
+	eth, _ := newTraceTestBackend(t)
+	api := &PublicBlockChainAPI{config: eth.chainConfig, bc: eth.blockchain, chainDb: eth.chainDb}
+
+	// Code returning a single word pushed by the given opcodes
+	returning := func(push string) hexutil.Bytes {
+		return common.FromHex(push + "60005260206000f3")
+	}
+	var (
+		contract = common.Address{0xc0}
+		slot     = common.BigToHash(big.NewInt(1))
+		number   = hexutil.Big(*big.NewInt(1000))
+		time     = hexutil.Big(*big.NewInt(123456))
+		coinbase = common.Address{0xcb}
+		gasLimit = hexutil.Big(*big.NewInt(7777777))
+		balance  = hexutil.Big(*big.NewInt(31337))
+	)
+	call := func(code hexutil.Bytes, account OverrideAccount, block *BlockOverrides) *big.Int {
+		account.Code = &code
+		args := CallArgs{From: testBank.Address, To: &contract, GasPrice: rpc.NewHexNumber(1)}
+		res, err := api.Call(args, rpc.BlockNumber(1), &StateOverride{contract: account}, block)
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		return new(big.Int).SetBytes(common.FromHex(res))
+	}
+	blockOverrides := &BlockOverrides{Number: &number, Timestamp: &time, Coinbase: &coinbase, GasLimit: &gasLimit}
+	for op, want := range map[string]*big.Int{
+		"43":   number.ToInt(),   // NUMBER
+		"42":   time.ToInt(),     // TIMESTAMP
+		"41":   coinbase.Big(),   // COINBASE
+		"45":   gasLimit.ToInt(), // GASLIMIT
+		"3031": balance.ToInt(),  // ADDRESS BALANCE
+	} {
+		if have := call(returning(op), OverrideAccount{Balance: &balance}, blockOverrides); have.Cmp(want) != 0 {
+			t.Errorf("op %s: expected %v, got %v", op, want, have)
+		}
+	}
+
+	sload := returning("600154") // PUSH1 1 SLOAD
+	value := common.BigToHash(big.NewInt(99))
+	if have := call(sload, OverrideAccount{StateDiff: &map[common.Hash]common.Hash{slot: value}}, nil); have.Cmp(value.Big()) != 0 {
+		t.Errorf("expected the state diff to set the slot to %v, got %v", value.Big(), have)
+	}
+	if have := call(sload, OverrideAccount{State: &map[common.Hash]common.Hash{slot: value}}, nil); have.Cmp(value.Big()) != 0 {
+		t.Errorf("expected the state to set the slot to %v, got %v", value.Big(), have)
+	}
+
+	// Replacing the storage of the test contract clears its slot 0
+	created := crypto.CreateAddress(testBank.Address, 0)
+	args := CallArgs{From: testBank.Address, To: &created, GasPrice: rpc.NewHexNumber(1)}
+	code := returning("600054") // PUSH1 0 SLOAD
+	res, err := api.Call(args, rpc.BlockNumber(1), &StateOverride{created: {Code: &code, State: &map[common.Hash]common.Hash{}}}, nil)
+	if err != nil || new(big.Int).SetBytes(common.FromHex(res)).Sign() != 0 {
+		t.Errorf("expected the replaced storage to be empty, got %s, %v", res, err)
+	}
+	res, err = api.Call(args, rpc.BlockNumber(1), &StateOverride{created: {Code: &code}}, nil)
+	if err != nil || new(big.Int).SetBytes(common.FromHex(res)).Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("expected the stored slot to be kept, got %s, %v", res, err)
+	}
+
+	both := &StateOverride{contract: {State: &map[common.Hash]common.Hash{}, StateDiff: &map[common.Hash]common.Hash{}}}
+	if _, err := api.Call(CallArgs{From: testBank.Address, To: &contract, GasPrice: rpc.NewHexNumber(1)}, rpc.BlockNumber(1), both, nil); err == nil {
+		t.Error("expected an error for both state and stateDiff overrides")
+	}
+}
+
+func TestTxPoolContentFrom(t *testing.T) {
+	eth, _ := newTraceTestBackend(t)
+	eth.txPool = core.NewTxPool(eth.chainConfig, core.TxPoolConfig{}, eth.eventMux, eth.blockchain.State, eth.blockchain.GasLimit)
+	defer eth.txPool.Stop()
+
+	// The bank sent two transactions in the chain, so nonce 2 is processable
+	// and nonces 3, 5 and 6 are missing
+	for _, nonce := range []uint64{2, 4, 7} {
+		tx, _ := types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil).SignECDSA(testBankKey)
+		if err := eth.txPool.Add(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+
+	content, err := NewPublicTxPoolAPI(eth).ContentFrom(testBank.Address)
+	if err != nil {
+		t.Fatalf("failed to get account content: %v", err)
+	}
+	if len(content.Pending) != 1 || content.Pending["2"] == nil {
+		t.Errorf("pending mismatch: have %v, want nonce 2", content.Pending)
+	}
+	if len(content.Queued) != 2 || content.Queued["4"] == nil || content.Queued["7"] == nil {
+		t.Errorf("queued mismatch: have %v, want nonces 4 and 7", content.Queued)
+	}
+	if content.StateNonce != 2 {
+		t.Errorf("state nonce mismatch: have %d, want %d", content.StateNonce, 2)
+	}
+	if content.NextNonce != 3 {
+		t.Errorf("next nonce mismatch: have %d, want %d", content.NextNonce, 3)
+	}
+	gaps := []NonceGap{{From: 3, To: 3}, {From: 5, To: 6}}
+	if len(content.Gaps) != len(gaps) {
+		t.Fatalf("gaps mismatch: have %v, want %v", content.Gaps, gaps)
+	}
+	for i, gap := range gaps {
+		if content.Gaps[i] != gap {
+			t.Errorf("gap %d mismatch: have %v, want %v", i, content.Gaps[i], gap)
+		}
+	}
+
+	// Accounts without transactions in the pool have no gaps
+	content, err = NewPublicTxPoolAPI(eth).ContentFrom(common.Address{0x02})
+	if err != nil {
+		t.Fatalf("failed to get empty account content: %v", err)
+	}
+	if len(content.Pending) != 0 || len(content.Queued) != 0 || len(content.Gaps) != 0 || content.NextNonce != 0 {
+		t.Errorf("empty account content mismatch: %+v", content)
+	}
+}
+
+// Tests that webchain_subscribe("droppedTransactions") notifies of dropped
+// transactions, telling rejected ones from evicted ones.
+func TestDroppedTransactionsSubscription(t *testing.T) {
+	mux := new(event.TypeMux)
+	defer mux.Stop()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("webchain", NewPublicWebchainAPI(mux)); err != nil {
+		t.Fatalf("failed to register webchain service: %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(rpc.NewJSONCodec(serverConn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+
+	out, in := json.NewEncoder(clientConn), json.NewDecoder(clientConn)
+	request := map[string]interface{}{
+		"id":      1,
+		"method":  "webchain_subscribe",
+		"jsonrpc": "2.0",
+		"params":  []interface{}{"droppedTransactions"},
+	}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var response struct {
+		Result string           `json:"result"`
+		Error  *json.RawMessage `json:"error"`
+	}
+	clientConn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := in.Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil || response.Result == "" {
+		t.Fatalf("failed to subscribe: %s", *response.Error)
+	}
+
+	rejected, _ := types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil).SignECDSA(testBankKey)
+	evicted, _ := types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil).SignECDSA(testBankKey)
+	events := []core.TxDroppedEvent{
+		{Tx: rejected, Sender: testBank.Address, Reason: core.ErrNonce, Evicted: false},
+		{Tx: evicted, Sender: testBank.Address, Reason: core.ErrTxReplaced, Evicted: true},
+	}
+	for _, ev := range events {
+		mux.Post(ev)
+	}
+	for i, ev := range events {
+		var notification struct {
+			Method string `json:"method"`
+			Params struct {
+				Subscription string                `json:"subscription"`
+				Result       RPCDroppedTransaction `json:"result"`
+			} `json:"params"`
+		}
+		if err := in.Decode(&notification); err != nil {
+			t.Fatalf("notification %d: %v", i, err)
+		}
+		if notification.Method != "webchain_subscription" {
+			t.Errorf("notification %d: method mismatch: have %s, want webchain_subscription", i, notification.Method)
+		}
+		if notification.Params.Subscription != response.Result {
+			t.Errorf("notification %d: subscription mismatch: have %s, want %s", i, notification.Params.Subscription, response.Result)
+		}
+		want := newRPCDroppedTransaction(ev)
+		if have := notification.Params.Result; have != *want {
+			t.Errorf("notification %d: dropped transaction mismatch: have %+v, want %+v", i, have, *want)
+		}
+	}
+}
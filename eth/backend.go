@@ -50,6 +50,10 @@ import (
 
 const (
 	cryptonightRevision = 1
+
+	// DefaultTxPoolJournal is the default journal of local transactions,
+	// relative to the chain data directory.
+	DefaultTxPoolJournal = "transactions.rlp"
 )
 
 type Config struct {
@@ -64,6 +68,8 @@ type Config struct {
 	SkipBcVersionCheck bool // e.g. blockchain export
 	DatabaseCache      int
 	DatabaseHandles    int
+	AncientDepth       uint64 // number of recent blocks kept out of the freezer, 0 disables freezing
+	StateGCDepth       uint64 // number of recent block states kept by a pruning node, 0 keeps all states
 
 	NatSpec   bool
 	DocRoot   string
@@ -76,7 +82,13 @@ type Config struct {
 	MinerThreads   int
 	SolcPath       string
 
-	UseAddrTxIndex bool
+	UseAddrTxIndex      bool
+	AddrTxIndexInternal bool // also index internal value transfers and contract creations
+	AddrTxIndexTokens   bool // also index token Transfer events
+
+	TxPool core.TxPoolConfig
+
+	MinerPolicy miner.PolicyConfig
 
 	GpoMinGasPrice          *big.Int
 	GpoMaxGasPrice          *big.Int
@@ -138,6 +150,12 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Frozen blocks are read from the freezer even if freezing is disabled
+	if dir := ctx.ResolvePath("ancient"); dir != "" && (config.AncientDepth > 0 || core.HasFreezer(dir)) {
+		if chainDb, err = core.NewFreezerDatabase(chainDb, dir, config.AncientDepth); err != nil {
+			return nil, err
+		}
+	}
 	if err := upgradeChainDatabase(chainDb); err != nil {
 		return nil, err
 	}
@@ -271,7 +289,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 
 	eth.chainConfig = config.ChainConfig
 
-	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux())
+	eth.blockchain, err = core.NewBlockChainWithStateGC(chainDb, eth.chainConfig, eth.pow, eth.EventMux(), config.StateGCDepth)
 	if err != nil {
 		if err == core.ErrNoGenesis {
 			return nil, fmt.Errorf(`No chain found. Please initialise a new chain using the "init" subcommand.`)
@@ -281,13 +299,19 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	// Configure enabled atxi for blockchain
 	if config.UseAddrTxIndex {
 		eth.blockchain.SetAtxi(&core.AtxiT{
-			Db: eth.indexesDb,
+			Db:       eth.indexesDb,
+			Internal: config.AddrTxIndexInternal,
+			Tokens:   config.AddrTxIndexTokens,
 		})
 	}
 
 	eth.gpo = NewGasPriceOracle(eth)
 
-	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
+	poolConfig := config.TxPool
+	if poolConfig.Journal != "" {
+		poolConfig.Journal = ctx.ResolvePath(poolConfig.Journal)
+	}
+	newPool := core.NewTxPool(eth.chainConfig, poolConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
 	eth.txPool = newPool
 
 	m := downloader.FullSync
@@ -297,7 +321,11 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, m, uint64(config.NetworkId), eth.eventMux, eth.txPool, eth.pow, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
-	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.pow)
+	policy, err := miner.NewTxPolicy(config.MinerPolicy)
+	if err != nil {
+		return nil, err
+	}
+	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.pow, policy)
 	if err = eth.miner.SetGasPrice(config.GasPrice); err != nil {
 		return nil, err
 	}
@@ -382,6 +410,11 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicGethAPI(s),
 			Public:    true,
+		}, {
+			Namespace: "webchain",
+			Version:   "1.0",
+			Service:   NewPublicWebchainAPI(s.eventMux),
+			Public:    true,
 		},
 	}
 }
@@ -433,6 +466,9 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 // Start implements node.Service, starting all internal goroutines needed by the
 // Ethereum protocol implementation.
 func (s *Ethereum) Start(srvr *p2p.Server) error {
+	if fdb, ok := s.chainDb.(*core.FreezerDatabase); ok {
+		fdb.Start()
+	}
 	s.protocolManager.Start(s.config.MaxPeers)
 	s.netRPCService = NewPublicNetAPI(srvr, s.NetVersion())
 	return nil
@@ -496,45 +532,41 @@ func upgradeChainDatabase(db ethdb.Database) error {
 	// At least some of the database is still the old format, upgrade (skip the head block!)
 	glog.V(logger.Info).Info("Old database detected, upgrading...")
 
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
-		blockPrefix := []byte("block-hash-")
-		for it := db.NewIterator(); it.Next(); {
-			// Skip anything other than a combined block
-			if !bytes.HasPrefix(it.Key(), blockPrefix) {
-				continue
-			}
-			// Skip the head block (merge last to signal upgrade completion)
-			if bytes.HasSuffix(it.Key(), head.Bytes()) {
-				continue
-			}
-			// Load the block, split and serialize (order!)
-			block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
-
-			if err := core.WriteTd(db, block.Hash(), block.DeprecatedTd()); err != nil {
-				return err
-			}
-			if err := core.WriteBody(db, block.Hash(), block.Body()); err != nil {
-				return err
-			}
-			if err := core.WriteHeader(db, block.Header()); err != nil {
-				return err
-			}
-			if err := db.Delete(it.Key()); err != nil {
-				return err
-			}
+	blockPrefix := []byte("block-hash-")
+	it := db.NewIteratorWithPrefix(blockPrefix)
+	defer it.Release()
+	for it.Next() {
+		// Skip the head block (merge last to signal upgrade completion)
+		if bytes.HasSuffix(it.Key(), head.Bytes()) {
+			continue
 		}
-		// Lastly, upgrade the head block, disabling the upgrade mechanism
-		current := core.GetBlockByHashOld(db, head)
+		// Load the block, split and serialize (order!)
+		block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
 
-		if err := core.WriteTd(db, current.Hash(), current.DeprecatedTd()); err != nil {
+		if err := core.WriteTd(db, block.Hash(), block.DeprecatedTd()); err != nil {
 			return err
 		}
-		if err := core.WriteBody(db, current.Hash(), current.Body()); err != nil {
+		if err := core.WriteBody(db, block.Hash(), block.Body()); err != nil {
 			return err
 		}
-		if err := core.WriteHeader(db, current.Header()); err != nil {
+		if err := core.WriteHeader(db, block.Header()); err != nil {
 			return err
 		}
+		if err := db.Delete(it.Key()); err != nil {
+			return err
+		}
+	}
+	// Lastly, upgrade the head block, disabling the upgrade mechanism
+	current := core.GetBlockByHashOld(db, head)
+
+	if err := core.WriteTd(db, current.Hash(), current.DeprecatedTd()); err != nil {
+		return err
+	}
+	if err := core.WriteBody(db, current.Hash(), current.Body()); err != nil {
+		return err
+	}
+	if err := core.WriteHeader(db, current.Header()); err != nil {
+		return err
 	}
 	return nil
 }
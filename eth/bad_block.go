@@ -18,13 +18,16 @@ package eth
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
 	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/rlp"
@@ -37,8 +40,141 @@ const (
 	badBlocksURL = "https://badblocks.ethdev.com"
 )
 
+// maxBadBlocks is the number of rejected blocks kept in the chain database.
+// Once reached, the oldest ones are overwritten.
+const maxBadBlocks = 64
+
+var (
+	badBlockPrefix  = []byte("bad-block-")   // badBlockPrefix + slot (uint64 big endian) -> RLP(badBlockEntry)
+	badBlockHeadKey = []byte("LastBadBlock") // number of bad blocks ever written
+)
+
 var EnableBadBlockReporting = false
 
+// badBlockEntry is the database representation of a rejected block.
+type badBlockEntry struct {
+	Block      []byte // RLP of the block
+	Reason     string
+	PeerID     string
+	ReceivedAt uint64 // unix time in seconds
+}
+
+// BadBlock is a block which was rejected during import, along with the
+// reason and where it came from.
+type BadBlock struct {
+	Hash       common.Hash    `json:"hash"`
+	Number     hexutil.Uint64 `json:"number"`
+	RLP        hexutil.Bytes  `json:"rlp"`
+	Reason     string         `json:"reason"`
+	PeerID     string         `json:"peerId,omitempty"`
+	ReceivedAt time.Time      `json:"receivedAt"`
+}
+
+func badBlockKey(slot uint64) []byte {
+	key := make([]byte, len(badBlockPrefix)+8)
+	copy(key, badBlockPrefix)
+	binary.BigEndian.PutUint64(key[len(badBlockPrefix):], slot)
+	return key
+}
+
+// badBlockCount returns the number of bad blocks ever written to db.
+func badBlockCount(db ethdb.Database) uint64 {
+	data, _ := db.Get(badBlockHeadKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteBadBlock stores a rejected block in the ring of bad blocks kept in db,
+// overwriting the oldest one if the ring is full. A block which is already
+// stored is not written again.
+func WriteBadBlock(db ethdb.Database, block *types.Block, reason error, peerID string, receivedAt time.Time) error {
+	stored, err := GetBadBlocks(db)
+	if err != nil {
+		return err
+	}
+	for _, bad := range stored {
+		if bad.Hash == block.Hash() {
+			return nil
+		}
+	}
+	blockRLP, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return err
+	}
+	if receivedAt.IsZero() {
+		receivedAt = time.Now()
+	}
+	data, err := rlp.EncodeToBytes(&badBlockEntry{
+		Block:      blockRLP,
+		Reason:     reason.Error(),
+		PeerID:     peerID,
+		ReceivedAt: uint64(receivedAt.Unix()),
+	})
+	if err != nil {
+		return err
+	}
+	count := badBlockCount(db)
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint64(head, count+1)
+
+	batch := db.NewBatch()
+	if err := batch.Put(badBlockKey(count%maxBadBlocks), data); err != nil {
+		return err
+	}
+	if err := batch.Put(badBlockHeadKey, head); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// GetBadBlocks returns the bad blocks stored in db, most recent first.
+func GetBadBlocks(db ethdb.Database) ([]*BadBlock, error) {
+	count := badBlockCount(db)
+	n := count
+	if n > maxBadBlocks {
+		n = maxBadBlocks
+	}
+	blocks := make([]*BadBlock, 0, n)
+	for i := uint64(1); i <= n; i++ {
+		data, err := db.Get(badBlockKey((count - i) % maxBadBlocks))
+		if err != nil {
+			return nil, fmt.Errorf("bad block %d missing: %v", count-i, err)
+		}
+		var entry badBlockEntry
+		if err := rlp.DecodeBytes(data, &entry); err != nil {
+			return nil, fmt.Errorf("invalid bad block %d: %v", count-i, err)
+		}
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(entry.Block, block); err != nil {
+			return nil, fmt.Errorf("invalid bad block %d: %v", count-i, err)
+		}
+		blocks = append(blocks, &BadBlock{
+			Hash:       block.Hash(),
+			Number:     hexutil.Uint64(block.NumberU64()),
+			RLP:        entry.Block,
+			Reason:     entry.Reason,
+			PeerID:     entry.PeerID,
+			ReceivedAt: time.Unix(int64(entry.ReceivedAt), 0).UTC(),
+		})
+	}
+	return blocks, nil
+}
+
+// storeBadBlock keeps a block rejected by the chain in the local store, and
+// reports it if bad block reporting is enabled.
+func storeBadBlock(db ethdb.Database, block *types.Block, reason error) {
+	var peerID string
+	if p, ok := block.ReceivedFrom.(*peer); ok {
+		peerID = p.ID().String()
+	}
+	if err := WriteBadBlock(db, block, reason, peerID, block.ReceivedAt); err != nil {
+		glog.V(logger.Error).Errorf("Failed to store bad block #%d [%s]: %v", block.NumberU64(), block.Hash().Hex(), err)
+	}
+	sendBadBlockReport(block, reason)
+}
+
 func sendBadBlockReport(block *types.Block, err error) {
 	if !EnableBadBlockReporting {
 		return
@@ -0,0 +1,78 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+func TestBadBlockStore(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	blocks, err := GetBadBlocks(db)
+	if err != nil || len(blocks) != 0 {
+		t.Fatalf("empty store: have %d blocks, err %v", len(blocks), err)
+	}
+
+	received := time.Unix(1500000000, 0)
+	total := maxBadBlocks + 5
+	for i := 0; i < total; i++ {
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1)})
+		if err := WriteBadBlock(db, block, fmt.Errorf("bad %d", i), "peer", received); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+		// Writing the same block twice must not take another slot
+		if err := WriteBadBlock(db, block, errors.New("again"), "other", received); err != nil {
+			t.Fatalf("rewrite %d: %v", i, err)
+		}
+	}
+
+	blocks, err = GetBadBlocks(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != maxBadBlocks {
+		t.Fatalf("have %d blocks, want %d", len(blocks), maxBadBlocks)
+	}
+	for i, bad := range blocks {
+		number := uint64(total - 1 - i)
+		if uint64(bad.Number) != number {
+			t.Fatalf("block %d: number %d, want %d", i, bad.Number, number)
+		}
+		if want := fmt.Sprintf("bad %d", number); bad.Reason != want {
+			t.Errorf("block %d: reason %q, want %q", i, bad.Reason, want)
+		}
+		if bad.PeerID != "peer" {
+			t.Errorf("block %d: peer %q, want %q", i, bad.PeerID, "peer")
+		}
+		if !bad.ReceivedAt.Equal(received) {
+			t.Errorf("block %d: received at %v, want %v", i, bad.ReceivedAt, received)
+		}
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(bad.RLP, block); err != nil || block.Hash() != bad.Hash {
+			t.Errorf("block %d: RLP does not match hash: %v", i, err)
+		}
+	}
+}
@@ -83,6 +83,7 @@ type ProtocolManager struct {
 	eventMux      *event.TypeMux
 	txSub         event.Subscription
 	minedBlockSub event.Subscription
+	badBlockSub   event.Subscription
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -216,6 +217,9 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	// broadcast mined blocks
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go pm.minedBroadcastLoop()
+	// keep blocks rejected by the chain
+	pm.badBlockSub = pm.eventMux.Subscribe(core.BadBlockEvent{})
+	go pm.badBlockLoop()
 
 	// start sync handlers
 	go pm.syncer()
@@ -227,6 +231,7 @@ func (pm *ProtocolManager) Stop() {
 
 	pm.txSub.Unsubscribe()         // quits txBroadcastLoop
 	pm.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	pm.badBlockSub.Unsubscribe()   // quits badBlockLoop
 
 	// Quit the sync loop.
 	// After this send has completed, no new peers will be accepted.
@@ -844,6 +849,16 @@ func (self *ProtocolManager) txBroadcastLoop() {
 	}
 }
 
+// badBlockLoop stores the blocks rejected by the chain.
+func (self *ProtocolManager) badBlockLoop() {
+	// automatically stops if unsubscribe
+	for obj := range self.badBlockSub.Chan() {
+		if ev, ok := obj.Data.(core.BadBlockEvent); ok {
+			storeBadBlock(self.chaindb, ev.Block, ev.Err)
+		}
+	}
+}
+
 // EthNodeInfo represents a short summary of the Ethereum sub-protocol metadata known
 // about the host peer.
 type EthNodeInfo struct {
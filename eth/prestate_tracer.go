@@ -0,0 +1,238 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// AccountState is the state of an account as returned by the prestate and
+// stateDiff tracers. Fields which are left out are unchanged, or empty.
+type AccountState struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   *hexutil.Uint64             `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// StateDiff holds the accounts modified by a transaction as they were before
+// and after it. Accounts created by the transaction are missing from Pre,
+// accounts deleted by it are missing from Post.
+type StateDiff struct {
+	Pre  map[common.Address]*AccountState `json:"pre"`
+	Post map[common.Address]*AccountState `json:"post"`
+}
+
+// PrestateTracer is a vm.TxTracer which collects the state a transaction
+// depends on: the balance, nonce and code of every account it touches, along
+// with the storage slots it reads or writes, as they were before the
+// transaction. In diff mode it instead collects the accounts the transaction
+// modified, before and after it.
+//
+// Reads are captured through the VM hooks. Values are taken from the state
+// once the transaction is done, with the changes it made undone through the
+// journal of the state.
+type PrestateTracer struct {
+	diff    bool
+	touched map[common.Address]map[common.Hash]struct{} // accounts and slots read or written
+
+	result interface{}
+	err    error
+}
+
+// NewPrestateTracer returns a new prestate tracer, or a stateDiff tracer if
+// diff is set.
+func NewPrestateTracer(diff bool) *PrestateTracer {
+	return &PrestateTracer{
+		diff:    diff,
+		touched: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// touch records an account, and optionally storage slots of it, as touched.
+func (t *PrestateTracer) touch(addr common.Address, keys ...common.Hash) {
+	if t.touched[addr] == nil {
+		t.touched[addr] = make(map[common.Hash]struct{})
+	}
+	for _, key := range keys {
+		t.touched[addr][key] = struct{}{}
+	}
+}
+
+// CaptureState implements the vm.Tracer interface, recording the accounts
+// and storage slots read by an operation.
+func (t *PrestateTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack []*big.Int, contract *vm.Contract, depth int, err error) error {
+	if err != nil || len(stack) == 0 {
+		return nil
+	}
+	top := stack[len(stack)-1]
+	switch op {
+	case vm.SLOAD, vm.SSTORE:
+		t.touch(contract.Address(), common.BigToHash(top))
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODECOPY, vm.SUICIDE:
+		t.touch(common.BigToAddress(top))
+	}
+	return nil
+}
+
+// CaptureEnter implements the vm.Tracer interface, recording both parties of
+// a message call or contract creation.
+func (t *PrestateTracer) CaptureEnter(env vm.Environment, typ vm.OpCode, from, to common.Address, input []byte, gas, value *big.Int) {
+	t.touch(from)
+	t.touch(to)
+}
+
+// CaptureExit implements the vm.Tracer interface.
+func (t *PrestateTracer) CaptureExit(output []byte, gasUsed *big.Int, err error) {}
+
+// CaptureTxEnd implements the vm.TxTracer interface, assembling the result
+// while the journal still holds the changes made by the transaction.
+func (t *PrestateTracer) CaptureTxEnd(env vm.Environment) {
+	db, ok := env.Db().(*state.StateDB)
+	if !ok {
+		t.err = errors.New("state tracing is not supported by this state database")
+		return
+	}
+	dirties := db.JournalDirties()
+	for addr, slots := range dirties {
+		for key := range slots {
+			t.touch(addr, key)
+		}
+	}
+	if t.diff {
+		t.result = t.stateDiff(db, dirties)
+	} else {
+		t.result = t.prestate(db)
+	}
+}
+
+// prestate returns the state of the touched accounts before the transaction.
+func (t *PrestateTracer) prestate(db *state.StateDB) map[common.Address]*AccountState {
+	result := make(map[common.Address]*AccountState)
+	for addr, slots := range t.touched {
+		keys := make([]common.Hash, 0, len(slots))
+		for key := range slots {
+			keys = append(keys, key)
+		}
+		pre := db.Prestate(addr, keys)
+		if !pre.Exists {
+			continue
+		}
+		nonce := hexutil.Uint64(pre.Nonce)
+		account := &AccountState{
+			Balance: (*hexutil.Big)(pre.Balance),
+			Nonce:   &nonce,
+			Code:    pre.Code,
+		}
+		if len(pre.Storage) > 0 {
+			account.Storage = pre.Storage
+		}
+		result[addr] = account
+	}
+	return result
+}
+
+// stateDiff returns the modified accounts before and after the transaction,
+// leaving out the values which ended up unchanged.
+func (t *PrestateTracer) stateDiff(db *state.StateDB, dirties map[common.Address]map[common.Hash]struct{}) *StateDiff {
+	diff := &StateDiff{
+		Pre:  make(map[common.Address]*AccountState),
+		Post: make(map[common.Address]*AccountState),
+	}
+	for addr, slots := range dirties {
+		keys := make([]common.Hash, 0, len(slots))
+		for key := range slots {
+			keys = append(keys, key)
+		}
+		pre := db.Prestate(addr, keys)
+		exists := db.Exist(addr) && !db.HasSuicided(addr)
+		if !pre.Exists && !exists {
+			continue
+		}
+		preState, postState := new(AccountState), new(AccountState)
+		changed := pre.Exists != exists
+
+		balance := new(big.Int)
+		if exists {
+			balance = db.GetBalance(addr)
+		}
+		if !exists || balance.Cmp(pre.Balance) != 0 {
+			preState.Balance = (*hexutil.Big)(pre.Balance)
+			postState.Balance = (*hexutil.Big)(new(big.Int).Set(balance))
+			changed = true
+		}
+		var nonce uint64
+		if exists {
+			nonce = db.GetNonce(addr)
+		}
+		if !exists || nonce != pre.Nonce {
+			preNonce, postNonce := hexutil.Uint64(pre.Nonce), hexutil.Uint64(nonce)
+			preState.Nonce, postState.Nonce = &preNonce, &postNonce
+			changed = true
+		}
+		var code []byte
+		if exists {
+			code = db.GetCode(addr)
+		}
+		if !bytes.Equal(code, pre.Code) {
+			preState.Code, postState.Code = pre.Code, code
+			changed = true
+		}
+		for _, key := range keys {
+			var value common.Hash
+			if exists {
+				value = db.GetState(addr, key)
+			}
+			if value == pre.Storage[key] {
+				continue
+			}
+			if preState.Storage == nil {
+				preState.Storage = make(map[common.Hash]common.Hash)
+				postState.Storage = make(map[common.Hash]common.Hash)
+			}
+			if (pre.Storage[key] != common.Hash{}) {
+				preState.Storage[key] = pre.Storage[key]
+			}
+			if (value != common.Hash{}) {
+				postState.Storage[key] = value
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if pre.Exists {
+			diff.Pre[addr] = preState
+		}
+		if exists {
+			diff.Post[addr] = postState
+		}
+	}
+	return diff
+}
+
+// Result returns the prestate or state diff of the traced transaction.
+func (t *PrestateTracer) Result() (interface{}, error) {
+	return t.result, t.err
+}
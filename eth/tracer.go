@@ -0,0 +1,389 @@
+// Copyright 2016 The go-ethereum Authors
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/robertkrimen/otto"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/internal/jsre"
+)
+
+// defaultTraceTimeout is the amount of time a JavaScript tracer may run for
+// when the trace arguments don't specify a timeout.
+const defaultTraceTimeout = 5 * time.Second
+
+var errTraceTimeout = errors.New("execution timeout")
+
+// JavascriptTracer is a vm.Tracer which hands the execution of a transaction
+// over to user supplied JavaScript, run in an otto runtime. The code must
+// evaluate to an object with the following functions:
+//
+//	step(log, db)    called for every executed step
+//	fault(log, db)   called when a step fails (optional)
+//	result(ctx, db)  called once the transaction is done, its return value
+//	                 is the result of the trace
+//
+// The log object exposes pc, op (toNumber(), toString(), isPush()), gas,
+// gasCost, depth, err, account, memory (slice(start, end), getUint(offset),
+// length()), stack (peek(n), length()) and contract (getAddress(),
+// getCaller(), getValue(), getInput()).
+//
+// The ctx object holds type, from, to, input, gas, value, gasUsed, output
+// and error of the top level call frame.
+//
+// The db object gives read access to the state through getBalance(addr),
+// getNonce(addr), getCode(addr), getState(addr, key) and exists(addr).
+//
+// Big numbers are handed to the JavaScript code as bignumber.js objects,
+// byte slices, addresses and hashes as hex strings.
+type JavascriptTracer struct {
+	vm        *otto.Otto
+	traceobj  *otto.Object // user supplied object to call
+	bigNumber otto.Value   // creates a bignumber.js object from a string
+
+	log   *otto.Object // reusable `log` argument of step and fault
+	db    *otto.Object // `db` argument of all functions
+	ctx   *otto.Object // `ctx` argument of result
+	fault bool         // whether the user object defines fault
+
+	// VM internals of the step being traced
+	state    vm.Database
+	memory   *vm.Memory
+	stack    []*big.Int
+	contract *vm.Contract
+
+	depth  int         // number of call frames entered but not yet left
+	result interface{} // result of the trace, once done
+	err    error       // first error the tracer ran into
+
+	timer *time.Timer // aborts the JavaScript execution once the timeout passes
+}
+
+// NewJavascriptTracer compiles the given tracer code. Once the timeout
+// passes, any running or later JavaScript execution is aborted, unless the
+// trace is over by then.
+func NewJavascriptTracer(code string, timeout time.Duration) (*JavascriptTracer, error) {
+	jst := &JavascriptTracer{vm: otto.New()}
+	jst.vm.Interrupt = make(chan func(), 1)
+
+	if _, err := jst.vm.Run(jsre.BigNumber_JS); err != nil {
+		return nil, err
+	}
+	bigNumber, err := jst.vm.Run("(function(n) { return new BigNumber(n); })")
+	if err != nil {
+		return nil, err
+	}
+	jst.bigNumber = bigNumber
+
+	traceobj, err := jst.vm.Object("(" + code + ")")
+	if err != nil {
+		return nil, err
+	}
+	jst.traceobj = traceobj
+
+	// Check the required functions exist
+	for _, name := range []string{"step", "result"} {
+		fn, err := traceobj.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if !fn.IsFunction() {
+			return nil, fmt.Errorf("trace object must expose a function %s()", name)
+		}
+	}
+	if fn, err := traceobj.Get("fault"); err == nil && fn.IsFunction() {
+		jst.fault = true
+	}
+
+	if err := jst.setupObjects(); err != nil {
+		return nil, err
+	}
+	jst.timer = time.AfterFunc(timeout, func() { jst.Stop(errTraceTimeout) })
+
+	return jst, nil
+}
+
+// setupObjects creates the persistent JavaScript objects passed to the user
+// functions. Their native methods read the VM internals of the current step.
+func (jst *JavascriptTracer) setupObjects() (err error) {
+	newObject := func() *otto.Object {
+		if err != nil {
+			return nil
+		}
+		var obj *otto.Object
+		obj, err = jst.vm.Object("({})")
+		return obj
+	}
+	jst.log, jst.db, jst.ctx = newObject(), newObject(), newObject()
+	memory, stack, contract := newObject(), newObject(), newObject()
+	if err != nil {
+		return err
+	}
+
+	memory.Set("slice", func(call otto.FunctionCall) otto.Value {
+		begin, end := jst.intArg(call, 0), jst.intArg(call, 1)
+		if begin < 0 || end < begin || end > int64(jst.memory.Len()) {
+			panic(jst.vm.MakeRangeError(fmt.Sprintf("memory slice [%d:%d] out of bounds (size %d)", begin, end, jst.memory.Len())))
+		}
+		return jst.value(hexutil.Encode(jst.memory.Data()[begin:end]))
+	})
+	memory.Set("getUint", func(call otto.FunctionCall) otto.Value {
+		offset := jst.intArg(call, 0)
+		if offset < 0 || offset+32 > int64(jst.memory.Len()) {
+			panic(jst.vm.MakeRangeError(fmt.Sprintf("memory word at %d out of bounds (size %d)", offset, jst.memory.Len())))
+		}
+		return jst.big(new(big.Int).SetBytes(jst.memory.Data()[offset : offset+32]))
+	})
+	memory.Set("length", func(call otto.FunctionCall) otto.Value {
+		return jst.value(jst.memory.Len())
+	})
+
+	stack.Set("peek", func(call otto.FunctionCall) otto.Value {
+		idx := jst.intArg(call, 0)
+		if idx < 0 || idx >= int64(len(jst.stack)) {
+			panic(jst.vm.MakeRangeError(fmt.Sprintf("stack item %d out of bounds (size %d)", idx, len(jst.stack))))
+		}
+		return jst.big(jst.stack[int64(len(jst.stack))-idx-1])
+	})
+	stack.Set("length", func(call otto.FunctionCall) otto.Value {
+		return jst.value(len(jst.stack))
+	})
+
+	contract.Set("getAddress", func(call otto.FunctionCall) otto.Value {
+		return jst.value(jst.contract.Address().Hex())
+	})
+	contract.Set("getCaller", func(call otto.FunctionCall) otto.Value {
+		return jst.value(jst.contract.Caller().Hex())
+	})
+	contract.Set("getValue", func(call otto.FunctionCall) otto.Value {
+		return jst.big(jst.contract.Value())
+	})
+	contract.Set("getInput", func(call otto.FunctionCall) otto.Value {
+		return jst.value(hexutil.Encode(jst.contract.Input))
+	})
+
+	jst.log.Set("memory", memory)
+	jst.log.Set("stack", stack)
+	jst.log.Set("contract", contract)
+
+	jst.db.Set("getBalance", func(call otto.FunctionCall) otto.Value {
+		return jst.big(jst.state.GetBalance(jst.addressArg(call, 0)))
+	})
+	jst.db.Set("getNonce", func(call otto.FunctionCall) otto.Value {
+		return jst.value(jst.state.GetNonce(jst.addressArg(call, 0)))
+	})
+	jst.db.Set("getCode", func(call otto.FunctionCall) otto.Value {
+		return jst.value(hexutil.Encode(jst.state.GetCode(jst.addressArg(call, 0))))
+	})
+	jst.db.Set("getState", func(call otto.FunctionCall) otto.Value {
+		key := common.HexToHash(call.Argument(1).String())
+		return jst.value(jst.state.GetState(jst.addressArg(call, 0), key).Hex())
+	})
+	jst.db.Set("exists", func(call otto.FunctionCall) otto.Value {
+		return jst.value(jst.state.Exist(jst.addressArg(call, 0)))
+	})
+	return nil
+}
+
+// value converts a Go value to its JavaScript counterpart.
+func (jst *JavascriptTracer) value(v interface{}) otto.Value {
+	value, err := jst.vm.ToValue(v)
+	if err != nil {
+		panic(jst.vm.MakeTypeError(err.Error()))
+	}
+	return value
+}
+
+// big converts a big integer to a bignumber.js object. It must only be used
+// by native functions, as it throws on failure.
+func (jst *JavascriptTracer) big(n *big.Int) otto.Value {
+	value, err := jst.bigNumber.Call(otto.NullValue(), n.String())
+	if err != nil {
+		panic(jst.vm.MakeTypeError(err.Error()))
+	}
+	return value
+}
+
+// intArg returns the n'th argument of a native call as an integer.
+func (jst *JavascriptTracer) intArg(call otto.FunctionCall, n int) int64 {
+	i, err := call.Argument(n).ToInteger()
+	if err != nil {
+		panic(jst.vm.MakeTypeError(err.Error()))
+	}
+	return i
+}
+
+// addressArg returns the n'th argument of a native call as an address. The
+// database is checked too, as all callers go on to read from it.
+func (jst *JavascriptTracer) addressArg(call otto.FunctionCall, n int) common.Address {
+	if jst.state == nil {
+		panic(jst.vm.MakeCustomError("Error", "state not available"))
+	}
+	return common.HexToAddress(call.Argument(n).String())
+}
+
+// Stop terminates execution of any running JavaScript and fails the trace
+// with the given error.
+func (jst *JavascriptTracer) Stop(err error) {
+	select {
+	case jst.vm.Interrupt <- func() { panic(err) }:
+	default:
+	}
+}
+
+// callSafely executes a method on the user supplied object, catching any
+// panics and returning them as errors.
+func (jst *JavascriptTracer) callSafely(method string, argumentList ...interface{}) (ret interface{}, err error) {
+	defer func() {
+		if caught := recover(); caught != nil {
+			switch caught := caught.(type) {
+			case error:
+				err = caught
+			case string:
+				err = errors.New(caught)
+			case fmt.Stringer:
+				err = errors.New(caught.String())
+			default:
+				panic(caught)
+			}
+		}
+	}()
+
+	value, err := jst.traceobj.Call(method, argumentList...)
+	if err != nil {
+		return nil, err
+	}
+	return value.Export()
+}
+
+// wrapError annotates an error with the tracer function it was raised in.
+func wrapError(context string, err error) error {
+	var message string
+	switch err := err.(type) {
+	case *otto.Error:
+		message = err.String()
+	default:
+		message = err.Error()
+	}
+	return fmt.Errorf("%v    in server-side tracer function '%v'", message, context)
+}
+
+// CaptureState implements the vm.Tracer interface, calling step for every
+// executed step and fault for a failed one.
+func (jst *JavascriptTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack []*big.Int, contract *vm.Contract, depth int, err error) error {
+	if jst.err != nil {
+		return nil
+	}
+	method := "step"
+	if err != nil {
+		if !jst.fault {
+			return nil
+		}
+		method = "fault"
+	}
+	jst.state, jst.memory, jst.stack, jst.contract = env.Db(), memory, stack, contract
+
+	opcode, _ := jst.vm.Object("({})")
+	opcode.Set("toNumber", func(call otto.FunctionCall) otto.Value { return jst.value(int(op)) })
+	opcode.Set("toString", func(call otto.FunctionCall) otto.Value { return jst.value(op.String()) })
+	opcode.Set("isPush", func(call otto.FunctionCall) otto.Value { return jst.value(op.IsPush()) })
+
+	jst.log.Set("pc", pc)
+	jst.log.Set("op", opcode)
+	jst.log.Set("gas", gas.Uint64())
+	if cost != nil {
+		jst.log.Set("gasCost", cost.Uint64())
+	} else {
+		jst.log.Set("gasCost", 0)
+	}
+	jst.log.Set("depth", depth)
+	jst.log.Set("account", contract.Address().Hex())
+	if err != nil {
+		jst.log.Set("err", err.Error())
+	} else {
+		jst.log.Set("err", otto.UndefinedValue())
+	}
+
+	if _, err := jst.callSafely(method, jst.log, jst.db); err != nil {
+		jst.err = wrapError(method, err)
+	}
+	return nil
+}
+
+// CaptureEnter implements the vm.Tracer interface, recording the top level
+// call frame for the `ctx` argument of result.
+func (jst *JavascriptTracer) CaptureEnter(env vm.Environment, typ vm.OpCode, from, to common.Address, input []byte, gas, value *big.Int) {
+	jst.depth++
+	if jst.depth > 1 {
+		return
+	}
+	jst.state = env.Db()
+
+	jst.ctx.Set("type", typ.String())
+	jst.ctx.Set("from", from.Hex())
+	jst.ctx.Set("to", to.Hex())
+	jst.ctx.Set("input", hexutil.Encode(input))
+	jst.ctx.Set("gas", gas.Uint64())
+	if value != nil {
+		v, err := jst.bigNumber.Call(otto.NullValue(), value.String())
+		if err != nil {
+			jst.err = err
+			return
+		}
+		jst.ctx.Set("value", v)
+	}
+}
+
+// CaptureExit implements the vm.Tracer interface. Once the top level frame
+// is left, result is evaluated while the state still reflects the traced
+// transaction.
+func (jst *JavascriptTracer) CaptureExit(output []byte, gasUsed *big.Int, err error) {
+	jst.depth--
+	if jst.depth > 0 {
+		return
+	}
+	defer jst.timer.Stop()
+
+	jst.ctx.Set("gasUsed", gasUsed.Uint64())
+	jst.ctx.Set("output", hexutil.Encode(output))
+	if err != nil {
+		jst.ctx.Set("error", err.Error())
+	}
+	if jst.err != nil {
+		return
+	}
+	result, err := jst.callSafely("result", jst.ctx, jst.db)
+	if err != nil {
+		jst.err = wrapError("result", err)
+		return
+	}
+	jst.result = result
+}
+
+// GetResult returns the value returned by result, or the first error the
+// tracer ran into.
+func (jst *JavascriptTracer) GetResult() (interface{}, error) {
+	return jst.result, jst.err
+}
@@ -0,0 +1,275 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+// boltIteratorChunk is the number of pairs a BoltDB iterator reads in one
+// transaction.
+const boltIteratorChunk = 1024
+
+var (
+	boltBucket = []byte("ethdb")
+
+	errBoltNotFound = errors.New("not found")
+)
+
+// BoltDatabase is a Database stored in a single BoltDB file. All pairs are kept
+// in one bucket.
+//
+// Every write transaction is synced to disk before it returns, which is what
+// keeps the file consistent after a crash. Put and Delete each run their own
+// transaction, so they pay for a sync every time: BenchmarkBoltPut measures
+// them at about 30 times the cost per key of writes collected in batches of
+// 100, and more on slow disks. Bulk writes should go through NewBatch.
+type BoltDatabase struct {
+	file string
+	db   *bolt.DB
+}
+
+// NewBoltDatabase opens the BoltDB file at the given path, creating it if
+// needed.
+func NewBoltDatabase(file string) (*BoltDatabase, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Opened BoltDB database %s", file)
+
+	return &BoltDatabase{
+		file: file,
+		db:   db,
+	}, nil
+}
+
+// Path returns the path to the database file.
+func (db *BoltDatabase) Path() string {
+	return db.file
+}
+
+// Put writes a single pair in its own synced transaction.
+func (db *BoltDatabase) Put(key []byte, value []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+func (db *BoltDatabase) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := db.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get(key)
+		if v == nil {
+			return errBoltNotFound
+		}
+		// Values are only valid for the life of the transaction
+		value = common.CopyBytes(v)
+		return nil
+	})
+	return value, err
+}
+
+func (db *BoltDatabase) Has(key []byte) (bool, error) {
+	var ok bool
+	err := db.db.View(func(tx *bolt.Tx) error {
+		ok = tx.Bucket(boltBucket).Get(key) != nil
+		return nil
+	})
+	return ok, err
+}
+
+// Delete deletes a single key in its own synced transaction.
+func (db *BoltDatabase) Delete(key []byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+func (db *BoltDatabase) Close() {
+	if err := db.db.Close(); err != nil {
+		glog.Errorf("eth: DB %s: %s", db.file, err)
+	}
+}
+
+func (db *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: db.db}
+}
+
+func (db *BoltDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &boltIterator{
+		db:    db.db,
+		next:  common.CopyBytes(prefix),
+		match: func(key []byte) bool { return bytes.HasPrefix(key, prefix) },
+	}
+}
+
+func (db *BoltDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	return &boltIterator{
+		db:    db.db,
+		next:  common.CopyBytes(start),
+		match: func(key []byte) bool { return limit == nil || bytes.Compare(key, limit) < 0 },
+	}
+}
+
+// DeleteRange deletes all keys in [start, limit). The keys are deleted in
+// chunks, each in its own transaction.
+func (db *BoltDatabase) DeleteRange(start, limit []byte) error {
+	it := db.NewIteratorWithRange(start, limit)
+	defer it.Release()
+
+	var keys [][]byte
+	flush := func() error {
+		err := db.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(boltBucket)
+			for _, key := range keys {
+				if err := b.Delete(key); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		keys = keys[:0]
+		return err
+	}
+	for it.Next() {
+		keys = append(keys, common.CopyBytes(it.Key()))
+		if len(keys) == boltIteratorChunk {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// boltIterator iterates over the pairs of a BoltDatabase. It reads them in
+// chunks, each in its own read transaction, so that the database can be
+// written to while iterating.
+type boltIterator struct {
+	db    *bolt.DB
+	match func(key []byte) bool // reports whether a key is still within the iterated keys
+	next  []byte                // key to continue reading from, nil once done
+	done  bool
+
+	pairs kvs
+	index int
+	err   error
+}
+
+func (it *boltIterator) Next() bool {
+	if it.index+1 < len(it.pairs) {
+		it.index++
+		return true
+	}
+	if it.done || it.err != nil {
+		it.pairs, it.index = nil, 0
+		return false
+	}
+	it.pairs, it.index = it.pairs[:0], 0
+	it.err = it.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+
+		k, v := c.First()
+		if it.next != nil {
+			k, v = c.Seek(it.next)
+		}
+		for ; k != nil && len(it.pairs) < boltIteratorChunk; k, v = c.Next() {
+			if !it.match(k) {
+				it.done = true
+				return nil
+			}
+			it.pairs = append(it.pairs, kv{common.CopyBytes(k), common.CopyBytes(v)})
+		}
+		if k == nil {
+			it.done = true
+		} else {
+			it.next = common.CopyBytes(k)
+		}
+		return nil
+	})
+	return it.err == nil && len(it.pairs) > 0
+}
+
+func (it *boltIterator) Key() []byte {
+	if it.index >= len(it.pairs) {
+		return nil
+	}
+	return it.pairs[it.index].k
+}
+
+func (it *boltIterator) Value() []byte {
+	if it.index >= len(it.pairs) {
+		return nil
+	}
+	return it.pairs[it.index].v
+}
+
+func (it *boltIterator) Release() {
+	it.pairs, it.done = nil, true
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+// boltBatch collects writes to a BoltDatabase and commits them in a single
+// transaction.
+type boltBatch struct {
+	db     *bolt.DB
+	writes []kv
+	size   int
+}
+
+func (b *boltBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value)})
+	b.size += len(value)
+	return nil
+}
+
+func (b *boltBatch) Write() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, kv := range b.writes {
+			if err := bucket.Put(kv.k, kv.v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltBatch) ValueSize() int {
+	return b.size
+}
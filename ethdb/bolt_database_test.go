@@ -0,0 +1,67 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Benchmarks the cost of single writes, each committed and synced in its own
+// BoltDB transaction, against writes collected in batches.
+func BenchmarkBoltPut(b *testing.B)         { benchmarkBoltWrite(b, 1) }
+func BenchmarkBoltBatchPut100(b *testing.B) { benchmarkBoltWrite(b, 100) }
+
+func benchmarkBoltWrite(b *testing.B, batchSize int) {
+	dir, err := ioutil.TempDir("", "ethdb-bolt-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewBoltDatabase(filepath.Join(dir, "chaindata.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	value := make([]byte, 100)
+	b.ResetTimer()
+	if batchSize == 1 {
+		for i := 0; i < b.N; i++ {
+			if err := db.Put(testKey(i), value); err != nil {
+				b.Fatal(err)
+			}
+		}
+		return
+	}
+	batch := db.NewBatch()
+	for i := 0; i < b.N; i++ {
+		batch.Put(testKey(i), value)
+		if (i+1)%batchSize == 0 {
+			if err := batch.Write(); err != nil {
+				b.Fatal(err)
+			}
+			batch = db.NewBatch()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		b.Fatal(err)
+	}
+}
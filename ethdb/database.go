@@ -17,6 +17,7 @@
 package ethdb
 
 import (
+	"fmt"
 	"path/filepath"
 
 	"strconv"
@@ -64,6 +65,28 @@ type LDBDatabase struct {
 	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
 }
 
+// Database backends supported by OpenDatabase.
+const (
+	BackendLevelDB = "leveldb"
+	BackendBolt    = "bolt"
+	BackendMemory  = "memory"
+)
+
+// OpenDatabase opens the database at path with the given backend, an empty
+// backend selecting LevelDB. A BoltDB database is a single file, stored at path
+// with a .db extension. A memory database isn't persisted at all.
+func OpenDatabase(backend string, path string, cache int, handles int) (Database, error) {
+	switch backend {
+	case "", BackendLevelDB:
+		return NewLDBDatabase(path, cache, handles)
+	case BackendBolt:
+		return NewBoltDatabase(path + ".db")
+	case BackendMemory:
+		return NewMemDatabase()
+	}
+	return nil, fmt.Errorf("unknown database backend %q (want %s, %s or %s)", backend, BackendLevelDB, BackendBolt, BackendMemory)
+}
+
 // NewLDBDatabase returns a LevelDB wrapped object.
 func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	// Calculate the cache and file descriptor allowance for this particular database
@@ -140,6 +163,39 @@ func NewBytesPrefix(prefix []byte) *ldbutil.Range {
 	return ldbutil.BytesPrefix(prefix)
 }
 
+// NewIteratorWithPrefix returns an iterator over the keys starting with prefix.
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.db.NewIterator(ldbutil.BytesPrefix(prefix), nil)
+}
+
+// NewIteratorWithRange returns an iterator over the keys in [start, limit).
+func (db *LDBDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	return db.db.NewIterator(&ldbutil.Range{Start: start, Limit: limit}, nil)
+}
+
+// DeleteRange deletes all keys in [start, limit), writing the deletions in
+// batches of about IdealBatchSize.
+func (db *LDBDatabase) DeleteRange(start, limit []byte) error {
+	it := db.db.NewIterator(&ldbutil.Range{Start: start, Limit: limit}, nil)
+	defer it.Release()
+
+	batch, size := new(leveldb.Batch), 0
+	for it.Next() {
+		batch.Delete(it.Key())
+		if size += len(it.Key()); size >= IdealBatchSize {
+			if err := db.db.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+			size = 0
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return db.db.Write(batch, nil)
+}
+
 func (self *LDBDatabase) Close() {
 	if err := self.db.Close(); err != nil {
 		glog.Errorf("eth: DB %s: %s", self.file, err)
@@ -210,6 +266,50 @@ func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+func (dt *table) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &tableIterator{
+		it:     dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)),
+		prefix: dt.prefix,
+	}
+}
+
+func (dt *table) NewIteratorWithRange(start, limit []byte) Iterator {
+	start, limit = dt.keyRange(start, limit)
+	return &tableIterator{
+		it:     dt.db.NewIteratorWithRange(start, limit),
+		prefix: dt.prefix,
+	}
+}
+
+func (dt *table) DeleteRange(start, limit []byte) error {
+	start, limit = dt.keyRange(start, limit)
+	return dt.db.DeleteRange(start, limit)
+}
+
+// keyRange maps a key range of the table to the one of the underlying
+// database, bounding open ends by the prefix of the table.
+func (dt *table) keyRange(start, limit []byte) ([]byte, []byte) {
+	start = append([]byte(dt.prefix), start...)
+	if limit != nil {
+		limit = append([]byte(dt.prefix), limit...)
+	} else {
+		limit = ldbutil.BytesPrefix([]byte(dt.prefix)).Limit
+	}
+	return start, limit
+}
+
+// tableIterator strips the table prefix from the keys of an iterator.
+type tableIterator struct {
+	it     Iterator
+	prefix string
+}
+
+func (ti *tableIterator) Next() bool    { return ti.it.Next() }
+func (ti *tableIterator) Key() []byte   { return ti.it.Key()[len(ti.prefix):] }
+func (ti *tableIterator) Value() []byte { return ti.it.Value() }
+func (ti *tableIterator) Release()      { ti.it.Release() }
+func (ti *tableIterator) Error() error  { return ti.it.Error() }
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -0,0 +1,254 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLDBDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-ldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	testDatabase(t, db)
+}
+
+func TestMemDatabase(t *testing.T) {
+	db, _ := NewMemDatabase()
+	testDatabase(t, db)
+}
+
+func TestTableDatabase(t *testing.T) {
+	db, _ := NewMemDatabase()
+	testDatabase(t, NewTable(db, "t-"))
+}
+
+func TestBoltDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewBoltDatabase(filepath.Join(dir, "chaindata.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	testDatabase(t, db)
+}
+
+// testIteratorKeys is the number of keys iterated over by testDatabase, enough
+// to span several BoltDB iterator chunks.
+const testIteratorKeys = 2*boltIteratorChunk + 5
+
+func testKey(i int) []byte { return []byte(fmt.Sprintf("k%05d", i)) }
+
+func testValue(i int) []byte { return []byte(fmt.Sprintf("v%d", i)) }
+
+// testDatabase checks that db behaves the way every Database should.
+func testDatabase(t *testing.T, db Database) {
+	// Single key operations
+	if _, err := db.Get([]byte("a")); err == nil {
+		t.Fatalf("missing key: no error")
+	}
+	if ok, err := db.Has([]byte("a")); ok || err != nil {
+		t.Fatalf("missing key: have %v (%v), want false", ok, err)
+	}
+	for _, value := range []string{"1", "2"} {
+		if err := db.Put([]byte("a"), []byte(value)); err != nil {
+			t.Fatalf("failed to put a=%s: %v", value, err)
+		}
+		if have, err := db.Get([]byte("a")); err != nil || string(have) != value {
+			t.Fatalf("get a: have %q (%v), want %q", have, err, value)
+		}
+	}
+	if ok, err := db.Has([]byte("a")); !ok || err != nil {
+		t.Fatalf("present key: have %v (%v), want true", ok, err)
+	}
+	if err := db.Delete([]byte("a")); err != nil {
+		t.Fatalf("failed to delete a: %v", err)
+	}
+	if ok, _ := db.Has([]byte("a")); ok {
+		t.Fatalf("deleted key still present")
+	}
+	if err := db.Delete([]byte("a")); err != nil {
+		t.Fatalf("failed to delete missing key: %v", err)
+	}
+
+	// Batches are only visible once written
+	batch := db.NewBatch()
+	for i := 0; i < testIteratorKeys; i++ {
+		if err := batch.Put(testKey(i), testValue(i)); err != nil {
+			t.Fatalf("failed to put key %d to batch: %v", i, err)
+		}
+	}
+	if ok, _ := db.Has(testKey(0)); ok {
+		t.Fatalf("batch visible before written")
+	}
+	if batch.ValueSize() == 0 {
+		t.Fatalf("batch value size not counted")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if have, err := db.Get(testKey(testIteratorKeys - 1)); err != nil || !bytes.Equal(have, testValue(testIteratorKeys-1)) {
+		t.Fatalf("get batched key: have %q (%v)", have, err)
+	}
+	// Keys around the iterated ones must not be picked up
+	db.Put([]byte("j"), []byte("before"))
+	db.Put([]byte("l"), []byte("after"))
+
+	// Iterators, across chunk boundaries
+	checkIterator(t, "prefix", db.NewIteratorWithPrefix([]byte("k")), 0, testIteratorKeys)
+	checkIterator(t, "narrow prefix", db.NewIteratorWithPrefix([]byte("k010")), 1000, 1100)
+	checkIterator(t, "missing prefix", db.NewIteratorWithPrefix([]byte("kx")), 0, 0)
+	checkIterator(t, "range", db.NewIteratorWithRange(testKey(10), testKey(2000)), 10, 2000)
+	checkIterator(t, "empty range", db.NewIteratorWithRange(testKey(10), testKey(10)), 0, 0)
+	checkIterator(t, "open start", db.NewIteratorWithRange([]byte("k"), testKey(1500)), 0, 1500)
+	checkRange(t, db, nil, nil, append(append([]string{"j"}, testKeys(0, testIteratorKeys)...), "l"))
+	checkRange(t, db, nil, testKey(3), append([]string{"j"}, testKeys(0, 3)...))
+	checkRange(t, db, testKey(testIteratorKeys-2), nil, append(testKeys(testIteratorKeys-2, testIteratorKeys), "l"))
+
+	// Range deletion
+	if err := db.DeleteRange(testKey(5), testKey(2040)); err != nil {
+		t.Fatalf("failed to delete range: %v", err)
+	}
+	checkRange(t, db, testKey(0), []byte("l"), append(testKeys(0, 5), testKeys(2040, testIteratorKeys)...))
+	if err := db.DeleteRange(nil, testKey(2)); err != nil {
+		t.Fatalf("failed to delete range with open start: %v", err)
+	}
+	if err := db.DeleteRange(testKey(2045), nil); err != nil {
+		t.Fatalf("failed to delete range with open end: %v", err)
+	}
+	checkRange(t, db, nil, nil, append(testKeys(2, 5), testKeys(2040, 2045)...))
+}
+
+func testKeys(from, to int) []string {
+	var keys []string
+	for i := from; i < to; i++ {
+		keys = append(keys, string(testKey(i)))
+	}
+	return keys
+}
+
+// checkIterator checks that it iterates over the test keys [from, to), and
+// releases it.
+func checkIterator(t *testing.T, name string, it Iterator, from, to int) {
+	defer it.Release()
+
+	i := from
+	for ; it.Next(); i++ {
+		if i >= to {
+			t.Errorf("%s: unexpected key %q", name, it.Key())
+			return
+		}
+		if !bytes.Equal(it.Key(), testKey(i)) || !bytes.Equal(it.Value(), testValue(i)) {
+			t.Errorf("%s: pair %d mismatch: have %q=%q, want %q=%q", name, i, it.Key(), it.Value(), testKey(i), testValue(i))
+			return
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Errorf("%s: iteration failed: %v", name, err)
+	}
+	if i != to {
+		t.Errorf("%s: iterated up to key %d, want %d", name, i, to)
+	}
+}
+
+// checkRange checks that the keys of db in [start, limit) are want.
+func checkRange(t *testing.T, db Database, start, limit []byte, want []string) {
+	it := db.NewIteratorWithRange(start, limit)
+	defer it.Release()
+
+	var have []string
+	for it.Next() {
+		have = append(have, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Errorf("range [%q, %q): iteration failed: %v", start, limit, err)
+	}
+	if fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Errorf("range [%q, %q): have %d keys, want %d", start, limit, len(have), len(want))
+	}
+}
+
+// Tests that table iterators and range deletions stay within the table, with
+// the table prefix stripped from the keys, whatever the bounds.
+func TestTableRanges(t *testing.T) {
+	db, _ := NewMemDatabase()
+	for _, key := range []string{"s-a", "t", "t-", "t-a", "t-b", "t-c", "t.", "u-a"} {
+		db.Put([]byte(key), []byte(key))
+	}
+	table := NewTable(db, "t-")
+
+	// keys returns the keys of it, each of which holds itself with prefix
+	// prepended as its value.
+	keys := func(it Iterator, prefix string) string {
+		defer it.Release()
+		var keys []string
+		for it.Next() {
+			if want := prefix + string(it.Key()); string(it.Value()) != want {
+				t.Errorf("value of %q mismatch: have %q, want %q", it.Key(), it.Value(), want)
+			}
+			keys = append(keys, string(it.Key()))
+		}
+		return fmt.Sprintf("%q", keys)
+	}
+	for _, test := range []struct {
+		name string
+		it   Iterator
+		want string
+	}{
+		{"all", table.NewIteratorWithPrefix(nil), `["" "a" "b" "c"]`},
+		{"prefix", table.NewIteratorWithPrefix([]byte("b")), `["b"]`},
+		{"open range", table.NewIteratorWithRange(nil, nil), `["" "a" "b" "c"]`},
+		{"open start", table.NewIteratorWithRange(nil, []byte("b")), `["" "a"]`},
+		{"open limit", table.NewIteratorWithRange([]byte("b"), nil), `["b" "c"]`},
+		{"range", table.NewIteratorWithRange([]byte("a"), []byte("c")), `["a" "b"]`},
+	} {
+		if have := keys(test.it, "t-"); have != test.want {
+			t.Errorf("%s: keys mismatch: have %s, want %s", test.name, have, test.want)
+		}
+	}
+
+	if err := table.DeleteRange([]byte("b"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := keys(db.NewIteratorWithPrefix(nil), ""), `["s-a" "t" "t-" "t-a" "t." "u-a"]`; have != want {
+		t.Errorf("keys after open limit deletion mismatch: have %s, want %s", have, want)
+	}
+	if err := table.DeleteRange(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := keys(db.NewIteratorWithPrefix(nil), ""), `["s-a" "t" "t." "u-a"]`; have != want {
+		t.Errorf("keys after open range deletion mismatch: have %s, want %s", have, want)
+	}
+}
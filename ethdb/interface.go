@@ -32,6 +32,36 @@ type Database interface {
 	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+
+	// NewIteratorWithPrefix returns an iterator over the keys starting with
+	// prefix, in ascending key order.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+	// NewIteratorWithRange returns an iterator over the keys in [start, limit),
+	// in ascending key order. A nil start begins at the first key, a nil limit
+	// runs to the last one.
+	NewIteratorWithRange(start, limit []byte) Iterator
+	// DeleteRange deletes all keys in [start, limit), with the same bounds as
+	// NewIteratorWithRange.
+	DeleteRange(start, limit []byte) error
+}
+
+// Iterator iterates over key/value pairs of a database in ascending key order.
+// It must be released once done with. Whether writes made to the database while
+// iterating are seen depends on the backend: LevelDB and memory iterators work
+// on a snapshot, BoltDB ones may see writes beyond the current key.
+type Iterator interface {
+	// Next moves the iterator to the next pair, and reports whether there is one.
+	Next() bool
+	// Key returns the key of the current pair. The slice must not be modified,
+	// and is only valid until the next call to Next.
+	Key() []byte
+	// Value returns the value of the current pair, with the same restrictions
+	// as Key.
+	Value() []byte
+	// Release releases the resources of the iterator.
+	Release()
+	// Error returns any error the iteration stopped on.
+	Error() error
 }
 
 type Batch interface {
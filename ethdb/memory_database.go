@@ -17,7 +17,9 @@
 package ethdb
 
 import (
+	"bytes"
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/webchain-network/webchaind/common"
@@ -99,11 +101,92 @@ func (db *MemDatabase) Delete(key []byte) error {
 
 func (db *MemDatabase) Close() {}
 
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.newIterator(func(key []byte) bool { return bytes.HasPrefix(key, prefix) })
+}
+
+func (db *MemDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	return db.newIterator(func(key []byte) bool { return inRange(key, start, limit) })
+}
+
+func (db *MemDatabase) DeleteRange(start, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for key := range db.db {
+		if inRange([]byte(key), start, limit) {
+			delete(db.db, key)
+		}
+	}
+	return nil
+}
+
+// newIterator returns an iterator over a sorted copy of the pairs whose key
+// is matched by match.
+func (db *MemDatabase) newIterator(match func(key []byte) bool) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	it := &memIterator{index: -1}
+	for key, value := range db.db {
+		if match([]byte(key)) {
+			it.pairs = append(it.pairs, kv{[]byte(key), common.CopyBytes(value)})
+		}
+	}
+	sort.Sort(it.pairs)
+	return it
+}
+
+// inRange reports whether key is in [start, limit), a nil limit being
+// unbounded.
+func inRange(key, start, limit []byte) bool {
+	return bytes.Compare(key, start) >= 0 && (limit == nil || bytes.Compare(key, limit) < 0)
+}
+
 func (db *MemDatabase) NewBatch() Batch {
 	return &memBatch{db: db}
 }
 
 type kv struct{ k, v []byte }
+
+type kvs []kv
+
+func (s kvs) Len() int           { return len(s) }
+func (s kvs) Less(i, j int) bool { return bytes.Compare(s[i].k, s[j].k) < 0 }
+func (s kvs) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// memIterator iterates over a snapshot of the pairs of a MemDatabase.
+type memIterator struct {
+	pairs kvs
+	index int
+}
+
+func (it *memIterator) Next() bool {
+	if it.index >= len(it.pairs) {
+		return false
+	}
+	it.index++
+	return it.index < len(it.pairs)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.pairs) {
+		return nil
+	}
+	return it.pairs[it.index].k
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.pairs) {
+		return nil
+	}
+	return it.pairs[it.index].v
+}
+
+func (it *memIterator) Release() { it.pairs = nil }
+
+func (it *memIterator) Error() error { return nil }
+
 type memBatch struct {
 	db     *MemDatabase
 	writes []kv
@@ -0,0 +1,72 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"testing"
+)
+
+// Tests that memory iterators work on a snapshot of the database, and have no
+// current pair before the first call to Next or once exhausted.
+func TestMemIterator(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("b"), []byte("2"))
+	db.Put([]byte("a"), []byte("1"))
+
+	it := db.NewIteratorWithRange(nil, nil)
+	defer it.Release()
+	if it.Key() != nil || it.Value() != nil {
+		t.Fatalf("pair before Next: %q=%q", it.Key(), it.Value())
+	}
+	db.Put([]byte("c"), []byte("3"))
+	db.Delete([]byte("b"))
+
+	var have string
+	for it.Next() {
+		have += string(it.Key()) + "=" + string(it.Value()) + " "
+	}
+	if want := "a=1 b=2 "; have != want {
+		t.Errorf("pairs mismatch: have %q, want %q", have, want)
+	}
+	if it.Next() || it.Key() != nil || it.Value() != nil {
+		t.Errorf("pair after exhaustion: %q=%q", it.Key(), it.Value())
+	}
+	if err := it.Error(); err != nil {
+		t.Errorf("iteration failed: %v", err)
+	}
+}
+
+// Tests that range deletions of a memory database honour both bounds.
+func TestMemDeleteRange(t *testing.T) {
+	db, _ := NewMemDatabase()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		db.Put([]byte(key), nil)
+	}
+	db.DeleteRange([]byte("b"), []byte("d"))
+	db.DeleteRange(nil, []byte("a"))
+	db.DeleteRange([]byte("f"), nil)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": false, "d": true, "e": true} {
+		if have, _ := db.Has([]byte(key)); have != want {
+			t.Errorf("key %s presence mismatch: have %v, want %v", key, have, want)
+		}
+	}
+	db.DeleteRange(nil, nil)
+	if keys := db.Keys(); len(keys) != 0 {
+		t.Errorf("keys left after deleting everything: %q", keys)
+	}
+}
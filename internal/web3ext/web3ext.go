@@ -52,6 +52,11 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'backup',
+			call: 'admin_backup',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -178,6 +183,23 @@ web3._extend({
 			name: 'getATXIBuildStatus',
 			call: 'geth_getATXIBuildStatus',
 			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'getTokenTransfers',
+			call: 'geth_getTokenTransfers',
+			params: 7,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'buildTokenTransferIndex',
+			call: 'geth_buildTokenTransferIndex',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getTokenTransferIndexBuildStatus',
+			call: 'geth_getTokenTransferIndexBuildStatus',
+			params: 0
 		})
 	],
 	properties: []
@@ -209,6 +231,17 @@ web3._extend({
 			call: 'debug_dumpBlock',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'storageRangeAt',
+			call: 'debug_storageRangeAt',
+			params: 5
+		}),
+		new web3._extend.Method({
+			name: 'accountRange',
+			call: 'debug_accountRange',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'metrics',
 			call: 'debug_metrics',
@@ -230,7 +263,41 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'traceTransaction',
 			call: 'debug_traceTransaction',
-			params: 1
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceBlockByNumber',
+			call: 'debug_traceBlockByNumber',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceBlockByHash',
+			call: 'debug_traceBlockByHash',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'traceBadBlock',
+			call: 'debug_traceBadBlock',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getBadBlocks',
+			call: 'debug_getBadBlocks',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'chaindbInspect',
+			call: 'debug_chaindbInspect',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'chaindbStats',
+			call: 'debug_chaindbStats',
+			params: 0
 		}),
 		new web3._extend.Method({
 			name: 'accountExist',
@@ -281,6 +348,18 @@ web3._extend({
 			name: 'chainId',
 			call: 'eth_chainId',
 			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'traceCall',
+			call: 'eth_traceCall',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getProof',
+			call: 'eth_getProof',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		})
 	],
 	properties:
@@ -416,7 +495,14 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'contentFrom',
+			call: 'txpool_contentFrom',
+			params: 1
+		})
+	],
 	properties:
 	[
 		new web3._extend.Property({
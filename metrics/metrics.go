@@ -113,6 +113,10 @@ var (
 	FetchBroadcastDOS   = metrics.NewRegisteredMeter("fetch/broadcast/dos", reg)
 )
 
+var (
+	TxPoolQueuedExpired = metrics.NewRegisteredMeter("txpool/queued/expired", reg)
+)
+
 var (
 	P2PIn       = metrics.NewRegisteredMeter("p2p/in", reg)
 	P2PInBytes  = metrics.NewRegisteredMeter("p2p/in/bytes", reg)
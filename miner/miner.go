@@ -54,8 +54,10 @@ type Miner struct {
 	shouldStart int32 // should start indicates whether we should start after sync
 }
 
-func New(eth core.Backend, config *core.ChainConfig, mux *event.TypeMux, pow pow.PoW) *Miner {
-	miner := &Miner{eth: eth, mux: mux, pow: pow, worker: newWorker(config, common.Address{}, eth), canStart: 1}
+// New creates a miner building blocks with the given transaction selection
+// policy. A nil policy selects DefaultTxPolicy.
+func New(eth core.Backend, config *core.ChainConfig, mux *event.TypeMux, pow pow.PoW, policy TxPolicy) *Miner {
+	miner := &Miner{eth: eth, mux: mux, pow: pow, worker: newWorker(config, common.Address{}, eth, policy), canStart: 1}
 	go miner.update()
 
 	return miner
@@ -0,0 +1,183 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+)
+
+// TxPolicy decides which pending transactions are included in a block, and in
+// which order they are tried. The worker starts a new TxSelection for every
+// block it builds.
+type TxPolicy interface {
+	// NewSelection starts the selection of transactions for the block with the
+	// given header. isLocal reports whether an account is owned by the node.
+	NewSelection(header *types.Header, isLocal func(common.Address) bool) TxSelection
+}
+
+// TxSelection is the per block state of a TxPolicy. It is only used while the
+// worker holds the lock of the current work, so it needn't be thread safe.
+type TxSelection interface {
+	// Order returns the pending transactions in the order they are tried.
+	// Transactions of the same sender must stay in nonce order.
+	Order(txs types.Transactions) types.Transactions
+
+	// Admit reports whether tx may be applied to the block, given the gas
+	// used by the block so far. A rejected transaction causes the later
+	// transactions of its sender to be skipped for this block.
+	Admit(tx *types.Transaction, from common.Address, gasUsed *big.Int) bool
+
+	// Included records a transaction applied to the block, with the gas it
+	// used.
+	Included(tx *types.Transaction, from common.Address, gas *big.Int)
+}
+
+// PolicyConfig holds the settings of the transaction selection policy. The
+// zero value selects the default policy.
+type PolicyConfig struct {
+	Priority     []common.Address // senders whose transactions are tried first
+	Exclude      []common.Address // contracts whose transactions are never included
+	LocalReserve uint64           // percentage of the block gas kept for local transactions
+	SenderGasCap *big.Int         // maximum gas a single remote sender may use in a block
+}
+
+var errInvalidLocalReserve = errors.New("local gas reserve must be a percentage between 0 and 100")
+
+// NewTxPolicy creates the transaction selection policy for the given
+// configuration.
+func NewTxPolicy(config PolicyConfig) (TxPolicy, error) {
+	if config.LocalReserve > 100 {
+		return nil, errInvalidLocalReserve
+	}
+	if len(config.Priority) == 0 && len(config.Exclude) == 0 && config.LocalReserve == 0 && (config.SenderGasCap == nil || config.SenderGasCap.Sign() == 0) {
+		return DefaultTxPolicy, nil
+	}
+	policy := &rulePolicy{
+		priority: make(map[common.Address]bool),
+		exclude:  make(map[common.Address]bool),
+		reserve:  config.LocalReserve,
+	}
+	for _, addr := range config.Priority {
+		policy.priority[addr] = true
+	}
+	for _, addr := range config.Exclude {
+		policy.exclude[addr] = true
+	}
+	if config.SenderGasCap != nil && config.SenderGasCap.Sign() > 0 {
+		policy.senderCap = new(big.Int).Set(config.SenderGasCap)
+	}
+	return policy, nil
+}
+
+// DefaultTxPolicy orders transactions by gas price, keeping the transactions
+// of every sender in nonce order, and includes all of them.
+var DefaultTxPolicy TxPolicy = defaultPolicy{}
+
+type defaultPolicy struct{}
+
+func (defaultPolicy) NewSelection(*types.Header, func(common.Address) bool) TxSelection {
+	return defaultPolicy{}
+}
+
+func (defaultPolicy) Order(txs types.Transactions) types.Transactions {
+	types.SortByPriceAndNonce(txs)
+	return txs
+}
+
+func (defaultPolicy) Admit(*types.Transaction, common.Address, *big.Int) bool { return true }
+
+func (defaultPolicy) Included(*types.Transaction, common.Address, *big.Int) {}
+
+// rulePolicy extends the default ordering with the rules of a PolicyConfig.
+type rulePolicy struct {
+	priority  map[common.Address]bool
+	exclude   map[common.Address]bool
+	reserve   uint64
+	senderCap *big.Int
+}
+
+func (p *rulePolicy) NewSelection(header *types.Header, isLocal func(common.Address) bool) TxSelection {
+	sel := &ruleSelection{
+		policy:  p,
+		isLocal: isLocal,
+		used:    make(map[common.Address]*big.Int),
+	}
+	if p.reserve > 0 {
+		sel.remoteLimit = new(big.Int).Mul(header.GasLimit, big.NewInt(int64(100-p.reserve)))
+		sel.remoteLimit.Div(sel.remoteLimit, big.NewInt(100))
+	}
+	return sel
+}
+
+type ruleSelection struct {
+	policy      *rulePolicy
+	isLocal     func(common.Address) bool
+	remoteLimit *big.Int                    // block gas remote transactions may fill, nil if unlimited
+	used        map[common.Address]*big.Int // gas used in the block per sender
+}
+
+// Order sorts the transactions by price and nonce, then moves those of the
+// priority senders to the front.
+func (s *ruleSelection) Order(txs types.Transactions) types.Transactions {
+	types.SortByPriceAndNonce(txs)
+	if len(s.policy.priority) == 0 {
+		return txs
+	}
+	ordered := make(types.Transactions, 0, len(txs))
+	var rest types.Transactions
+	for _, tx := range txs {
+		if from, err := tx.From(); err == nil && s.policy.priority[from] {
+			ordered = append(ordered, tx)
+		} else {
+			rest = append(rest, tx)
+		}
+	}
+	return append(ordered, rest...)
+}
+
+func (s *ruleSelection) Admit(tx *types.Transaction, from common.Address, gasUsed *big.Int) bool {
+	if to := tx.To(); to != nil && s.policy.exclude[*to] {
+		return false
+	}
+	if s.isLocal(from) {
+		return true
+	}
+	if s.remoteLimit != nil && new(big.Int).Add(gasUsed, tx.Gas()).Cmp(s.remoteLimit) > 0 {
+		return false
+	}
+	if s.policy.senderCap != nil {
+		used := tx.Gas()
+		if prev := s.used[from]; prev != nil {
+			used.Add(used, prev)
+		}
+		if used.Cmp(s.policy.senderCap) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ruleSelection) Included(tx *types.Transaction, from common.Address, gas *big.Int) {
+	if s.used[from] == nil {
+		s.used[from] = new(big.Int)
+	}
+	s.used[from].Add(s.used[from], gas)
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+func policyTestKey(t *testing.T) (*ecdsa.PrivateKey, common.Address) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func policyTestTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, to common.Address, gas, price int64) *types.Transaction {
+	tx, err := types.NewTransaction(nonce, to, big.NewInt(0), big.NewInt(gas), big.NewInt(price), nil).SignECDSA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func newTestSelection(t *testing.T, config PolicyConfig, gasLimit int64, locals ...common.Address) TxSelection {
+	policy, err := NewTxPolicy(config)
+	if err != nil {
+		t.Fatalf("failed to create policy: %v", err)
+	}
+	isLocal := func(addr common.Address) bool {
+		for _, local := range locals {
+			if addr == local {
+				return true
+			}
+		}
+		return false
+	}
+	return policy.NewSelection(&types.Header{GasLimit: big.NewInt(gasLimit)}, isLocal)
+}
+
+func TestNewTxPolicy(t *testing.T) {
+	if policy, err := NewTxPolicy(PolicyConfig{SenderGasCap: new(big.Int)}); policy != DefaultTxPolicy || err != nil {
+		t.Errorf("empty config: have %v (%v), want the default policy", policy, err)
+	}
+	if _, err := NewTxPolicy(PolicyConfig{LocalReserve: 101}); err != errInvalidLocalReserve {
+		t.Errorf("local reserve above 100%%: have %v, want %v", err, errInvalidLocalReserve)
+	}
+}
+
+// Tests that the transactions of priority senders are tried first, cheap as
+// they may be, and that every sender keeps its nonce order.
+func TestPolicyPriority(t *testing.T) {
+	priorityKey, priority := policyTestKey(t)
+	otherKey, other := policyTestKey(t)
+
+	var txs types.Transactions
+	for nonce, price := range []int64{3, 1, 2} {
+		txs = append(txs, policyTestTx(t, priorityKey, uint64(nonce), common.Address{}, 21000, price))
+	}
+	for nonce, price := range []int64{30, 10, 20} {
+		txs = append(txs, policyTestTx(t, otherKey, uint64(nonce), common.Address{}, 21000, price))
+	}
+	sel := newTestSelection(t, PolicyConfig{Priority: []common.Address{priority}}, 1000000)
+
+	ordered := sel.Order(txs)
+	if len(ordered) != len(txs) {
+		t.Fatalf("ordered transaction count mismatch: have %d, want %d", len(ordered), len(txs))
+	}
+	nonces := make(map[common.Address]uint64)
+	for i, tx := range ordered {
+		from, _ := tx.From()
+		if want := priority; i < 3 && from != want {
+			t.Errorf("tx %d: sender mismatch: have %x, want priority sender %x", i, from, want)
+		}
+		if want := other; i >= 3 && from != want {
+			t.Errorf("tx %d: sender mismatch: have %x, want %x", i, from, want)
+		}
+		if tx.Nonce() != nonces[from] {
+			t.Errorf("tx %d: nonce mismatch: have %d, want %d", i, tx.Nonce(), nonces[from])
+		}
+		nonces[from] = tx.Nonce() + 1
+	}
+}
+
+// Tests that transactions to excluded contracts are rejected, even from local
+// senders.
+func TestPolicyExclude(t *testing.T) {
+	key, local := policyTestKey(t)
+	excluded, allowed := common.Address{1}, common.Address{2}
+
+	sel := newTestSelection(t, PolicyConfig{Exclude: []common.Address{excluded}}, 1000000, local)
+	if sel.Admit(policyTestTx(t, key, 0, excluded, 21000, 1), local, new(big.Int)) {
+		t.Errorf("transaction to excluded contract admitted")
+	}
+	if !sel.Admit(policyTestTx(t, key, 0, allowed, 21000, 1), local, new(big.Int)) {
+		t.Errorf("transaction to allowed contract rejected")
+	}
+	creation, _ := types.NewContractCreation(0, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil).SignECDSA(key)
+	if !sel.Admit(creation, local, new(big.Int)) {
+		t.Errorf("contract creation rejected")
+	}
+}
+
+// Tests that remote transactions may not fill the block gas reserved for local
+// ones, while local transactions may use all of it.
+func TestPolicyLocalReserve(t *testing.T) {
+	localKey, local := policyTestKey(t)
+	remoteKey, remote := policyTestKey(t)
+
+	// 30% of 1000 gas is reserved, remote transactions may fill up to 700
+	sel := newTestSelection(t, PolicyConfig{LocalReserve: 30}, 1000, local)
+
+	tx := policyTestTx(t, remoteKey, 0, common.Address{}, 100, 1)
+	if !sel.Admit(tx, remote, big.NewInt(600)) {
+		t.Errorf("remote transaction filling the unreserved gas rejected")
+	}
+	if sel.Admit(tx, remote, big.NewInt(601)) {
+		t.Errorf("remote transaction using reserved gas admitted")
+	}
+	if !sel.Admit(policyTestTx(t, localKey, 0, common.Address{}, 100, 1), local, big.NewInt(900)) {
+		t.Errorf("local transaction using reserved gas rejected")
+	}
+}
+
+// Tests that a remote sender may not use more than the sender gas cap in a
+// block, counting the gas its included transactions used, and that local
+// senders are not capped.
+func TestPolicySenderGasCap(t *testing.T) {
+	localKey, local := policyTestKey(t)
+	remoteKey, remote := policyTestKey(t)
+	otherKey, other := policyTestKey(t)
+
+	sel := newTestSelection(t, PolicyConfig{SenderGasCap: big.NewInt(250)}, 1000000, local)
+
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx := policyTestTx(t, remoteKey, nonce, common.Address{}, 150, 1)
+		if !sel.Admit(tx, remote, new(big.Int)) {
+			t.Fatalf("tx %d within the cap rejected", nonce)
+		}
+		// Only the gas actually used counts against the cap
+		sel.Included(tx, remote, big.NewInt(100))
+	}
+	if sel.Admit(policyTestTx(t, remoteKey, 2, common.Address{}, 100, 1), remote, new(big.Int)) {
+		t.Errorf("transaction exceeding the sender cap admitted")
+	}
+	if !sel.Admit(policyTestTx(t, remoteKey, 2, common.Address{}, 50, 1), remote, new(big.Int)) {
+		t.Errorf("transaction reaching the sender cap rejected")
+	}
+	if !sel.Admit(policyTestTx(t, otherKey, 0, common.Address{}, 250, 1), other, new(big.Int)) {
+		t.Errorf("transaction of another sender rejected")
+	}
+	local0 := policyTestTx(t, localKey, 0, common.Address{}, 200, 1)
+	sel.Included(local0, local, big.NewInt(200))
+	if !sel.Admit(policyTestTx(t, localKey, 1, common.Address{}, 200, 1), local, new(big.Int)) {
+		t.Errorf("local transaction above the sender cap rejected")
+	}
+}
@@ -71,6 +71,7 @@ type Work struct {
 	lowGasTransactors  *set.Set
 	ownedAccounts      *set.Set
 	lowGasTxs          types.Transactions
+	selection          TxSelection       // transaction selection of the block policy
 	localMinedBlocks   *uint64RingBuffer // the most recent block numbers that were mined locally (used to check block inclusion)
 
 	Block *types.Block // the new block
@@ -108,6 +109,7 @@ type worker struct {
 
 	coinbase common.Address
 	gasPrice *big.Int
+	policy   TxPolicy
 
 	currentMu sync.Mutex
 	current   *Work
@@ -124,7 +126,10 @@ type worker struct {
 	fullValidation bool
 }
 
-func newWorker(config *core.ChainConfig, coinbase common.Address, eth core.Backend) *worker {
+func newWorker(config *core.ChainConfig, coinbase common.Address, eth core.Backend, policy TxPolicy) *worker {
+	if policy == nil {
+		policy = DefaultTxPolicy
+	}
 	worker := &worker{
 		config:         config,
 		eth:            eth,
@@ -132,6 +137,7 @@ func newWorker(config *core.ChainConfig, coinbase common.Address, eth core.Backe
 		chainDb:        eth.ChainDb(),
 		recv:           make(chan *Result, resultQueueSize),
 		gasPrice:       new(big.Int),
+		policy:         policy,
 		chain:          eth.BlockChain(),
 		proc:           eth.BlockChain().Validator(),
 		possibleUncles: make(map[common.Hash]*types.Block),
@@ -267,7 +273,10 @@ func (self *worker) wait() {
 				}
 				go self.mux.Post(core.NewMinedBlockEvent{Block: block})
 			} else {
-				work.state.CommitTo(self.chainDb, false)
+				if err := self.chain.CommitState(block, work.state); err != nil {
+					glog.V(logger.Error).Infoln("error writing mined block state", err)
+					continue
+				}
 				parent := self.chain.GetBlock(block.ParentHash())
 				if parent == nil {
 					glog.V(logger.Error).Infoln("Invalid block found during mining")
@@ -393,6 +402,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	work.ignoredTransactors = set.New()
 	work.lowGasTransactors = set.New()
 	work.ownedAccounts = accountAddressesSet(accounts)
+	work.selection = self.policy.NewSelection(header, func(addr common.Address) bool { return work.ownedAccounts.Has(addr) })
 	if self.current != nil {
 		work.localMinedBlocks = self.current.localMinedBlocks
 	}
@@ -490,14 +500,8 @@ func (self *worker) commitNewWork() {
 	// Create the current work task and check any fork transitions needed
 	work := self.current
 
-	/* //approach 1
-	transactions := self.eth.TxPool().GetTransactions()
-	sort.Sort(types.TxByNonce(transactions))
-	*/
-
-	//approach 2
-	transactions := self.eth.TxPool().GetTransactions()
-	types.SortByPriceAndNonce(transactions)
+	// Let the selection policy order the pending transactions
+	transactions := work.selection.Order(self.eth.TxPool().GetTransactions())
 
 	/* // approach 3
 	// commit transactions for this run.
@@ -655,6 +659,16 @@ func (env *Work) commitTransactions(mux *event.TypeMux, transactions types.Trans
 			continue
 		}
 
+		// Ask the selection policy whether the transaction may go in. Later
+		// transactions of a rejected sender would fail on their nonce, so the
+		// sender is ignored for the rest of the block.
+		if !env.selection.Admit(tx, from, env.header.GasUsed) {
+			env.ignoredTransactors.Add(from)
+
+			glog.V(logger.Detail).Infof("Transaction (%x) not admitted by the selection policy, ignoring (%x) in this block\n", tx.Hash().Bytes()[:4], from[:4])
+			continue
+		}
+
 		env.state.StartRecord(tx.Hash(), common.Hash{}, 0)
 
 		err, logs := env.commitTransaction(tx, bc, gp)
@@ -673,6 +687,7 @@ func (env *Work) commitTransactions(mux *event.TypeMux, transactions types.Trans
 			}
 		default:
 			env.tcount++
+			env.selection.Included(tx, from, env.receipts[len(env.receipts)-1].GasUsed)
 			coalescedLogs = append(coalescedLogs, logs...)
 		}
 
@@ -59,6 +59,10 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DatabaseBackend selects the backend of the databases services open within
+	// the data directory (leveldb, bolt or memory). Empty selects LevelDB.
+	DatabaseBackend string
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the chaindata directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
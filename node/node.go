@@ -45,8 +45,9 @@ var (
 // Node represents a P2P node into which arbitrary (uniquely typed) services might
 // be registered.
 type Node struct {
-	datadir  string         // Path to the currently used data directory
-	eventmux *event.TypeMux // Event multiplexer used between the services of a stack
+	datadir   string         // Path to the currently used data directory
+	dbBackend string         // Backend of the databases opened by services
+	eventmux  *event.TypeMux // Event multiplexer used between the services of a stack
 
 	serverConfig p2p.Config
 	server       *p2p.Server // Currently running P2P networking layer
@@ -99,7 +100,8 @@ func New(conf *Config) (*Node, error) {
 		nodeDbPath = filepath.Join(conf.DataDir, datadirNodeDatabase)
 	}
 	return &Node{
-		datadir: conf.DataDir,
+		datadir:   conf.DataDir,
+		dbBackend: conf.DatabaseBackend,
 		serverConfig: p2p.Config{
 			PrivateKey:      conf.NodeKey(),
 			Name:            conf.Name,
@@ -159,9 +161,10 @@ func (n *Node) Start() error {
 	for _, constructor := range n.serviceFuncs {
 		// Create a new context for the particular service
 		ctx := &ServiceContext{
-			datadir:  n.datadir,
-			services: make(map[reflect.Type]Service),
-			EventMux: n.eventmux,
+			datadir:   n.datadir,
+			dbBackend: n.dbBackend,
+			services:  make(map[reflect.Type]Service),
+			EventMux:  n.eventmux,
 		}
 		for kind, s := range services { // copy needed for threaded access
 			ctx.services[kind] = s
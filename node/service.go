@@ -30,19 +30,34 @@ import (
 // the protocol stack, that is passed to all constructors to be optionally used;
 // as well as utility methods to operate on the service environment.
 type ServiceContext struct {
-	datadir  string                   // Data directory for protocol persistence
-	services map[reflect.Type]Service // Index of the already constructed services
-	EventMux *event.TypeMux           // Event multiplexer used for decoupled notifications
+	datadir   string                   // Data directory for protocol persistence
+	dbBackend string                   // Backend of the databases opened by services
+	services  map[reflect.Type]Service // Index of the already constructed services
+	EventMux  *event.TypeMux           // Event multiplexer used for decoupled notifications
 }
 
 // OpenDatabase opens an existing database with the given name (or creates one
-// if no previous can be found) from within the node's data directory. If the
-// node is an ephemeral one, a memory database is returned.
+// if no previous can be found) from within the node's data directory, using the
+// configured database backend. If the node is an ephemeral one, a memory
+// database is returned.
 func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (ethdb.Database, error) {
 	if ctx.datadir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	return ethdb.NewLDBDatabase(filepath.Join(ctx.datadir, name), cache, handles)
+	return ethdb.OpenDatabase(ctx.dbBackend, filepath.Join(ctx.datadir, name), cache, handles)
+}
+
+// ResolvePath resolves a user specified path within the node's data directory.
+// Absolute paths are returned as they are. Relative ones are resolved within
+// the data directory, or to an empty string if the node is an ephemeral one.
+func (ctx *ServiceContext) ResolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if ctx.datadir == "" {
+		return ""
+	}
+	return filepath.Join(ctx.datadir, path)
 }
 
 // Service retrieves a currently running service registered of a specific type.
@@ -62,11 +77,11 @@ type ServiceConstructor func(ctx *ServiceContext) (Service, error)
 // Service is an individual protocol that can be registered into a node.
 //
 // Notes:
-//  - Service life-cycle management is delegated to the node. The service is
-//    allowed to initialize itself upon creation, but no goroutines should be
-//    spun up outside of the Start method.
-//  - Restart logic is not required as the node will create a fresh instance
-//    every time a service is started.
+//   - Service life-cycle management is delegated to the node. The service is
+//     allowed to initialize itself upon creation, but no goroutines should be
+//     spun up outside of the Start method.
+//   - Restart logic is not required as the node will create a fresh instance
+//     every time a service is started.
 type Service interface {
 	// Protocols retrieves the P2P protocols the service wishes to start.
 	Protocols() []p2p.Protocol
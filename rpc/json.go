@@ -34,7 +34,13 @@ const (
 	serviceMethodSeparator = "_"
 	subscribeMethod        = "eth_subscribe"
 	unsubscribeMethod      = "eth_unsubscribe"
-	notificationMethod     = "eth_subscription"
+
+	// subscriptions are made with <service>_subscribe and cancelled with
+	// <service>_unsubscribe on any service, their notifications are sent
+	// as <service>_subscription
+	subscribeMethodSuffix    = "subscribe"
+	unsubscribeMethodSuffix  = "unsubscribe"
+	notificationMethodSuffix = "subscription"
 )
 
 // JSON-RPC request
@@ -152,8 +158,13 @@ func parseRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, RPCError) {
 		return nil, false, &invalidMessageError{err.Error()}
 	}
 
+	elems := strings.Split(in.Method, serviceMethodSeparator)
+	if len(elems) != 2 {
+		return nil, false, &methodNotFoundError{in.Method, ""}
+	}
+
 	// subscribe are special, they will always use `subscribeMethod` as first param in the payload
-	if in.Method == subscribeMethod {
+	if elems[1] == subscribeMethodSuffix {
 		reqs := []rpcRequest{{id: &in.Id, isPubSub: true}}
 		if len(in.Payload) > 0 {
 			// first param must be subscription name
@@ -163,24 +174,20 @@ func parseRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, RPCError) {
 				return nil, false, &invalidRequestError{"Unable to parse subscription request"}
 			}
 
-			// all subscriptions are made on the eth service
-			reqs[0].service, reqs[0].method = "eth", subscribeMethod[0]
+			// subscriptions are made on the service the method belongs to
+			reqs[0].service, reqs[0].method = elems[0], subscribeMethod[0]
 			reqs[0].params = in.Payload
 			return reqs, false, nil
 		}
 		return nil, false, &invalidRequestError{"Unable to parse subscription request"}
 	}
 
-	if in.Method == unsubscribeMethod {
+	if elems[1] == unsubscribeMethodSuffix {
 		return []rpcRequest{{id: &in.Id, isPubSub: true,
 			method: unsubscribeMethod, params: in.Payload}}, false, nil
 	}
 
 	// regular RPC call
-	elems := strings.Split(in.Method, serviceMethodSeparator)
-	if len(elems) != 2 {
-		return nil, false, &methodNotFoundError{in.Method, ""}
-	}
 
 	if len(in.Payload) == 0 {
 		return []rpcRequest{{service: elems[0], method: elems[1], id: &in.Id}}, false, nil
@@ -205,8 +212,13 @@ func parseBatchRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, RPCErro
 
 		id := &in[i].Id
 
+		elems := strings.Split(r.Method, serviceMethodSeparator)
+		if len(elems) != 2 {
+			return nil, true, &methodNotFoundError{r.Method, ""}
+		}
+
 		// subscribe are special, they will always use `subscribeMethod` as first param in the payload
-		if r.Method == subscribeMethod {
+		if elems[1] == subscribeMethodSuffix {
 			requests[i] = rpcRequest{id: id, isPubSub: true}
 			if len(r.Payload) > 0 {
 				// first param must be subscription name
@@ -216,8 +228,8 @@ func parseBatchRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, RPCErro
 					return nil, false, &invalidRequestError{"Unable to parse subscription request"}
 				}
 
-				// all subscriptions are made on the eth service
-				requests[i].service, requests[i].method = "eth", subscribeMethod[0]
+				// subscriptions are made on the service the method belongs to
+				requests[i].service, requests[i].method = elems[0], subscribeMethod[0]
 				requests[i].params = r.Payload
 				continue
 			}
@@ -225,16 +237,11 @@ func parseBatchRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, RPCErro
 			return nil, true, &invalidRequestError{"Unable to parse (un)subscribe request arguments"}
 		}
 
-		if r.Method == unsubscribeMethod {
+		if elems[1] == unsubscribeMethodSuffix {
 			requests[i] = rpcRequest{id: id, isPubSub: true, method: unsubscribeMethod, params: r.Payload}
 			continue
 		}
 
-		elems := strings.Split(r.Method, serviceMethodSeparator)
-		if len(elems) != 2 {
-			return nil, true, &methodNotFoundError{r.Method, ""}
-		}
-
 		if len(r.Payload) == 0 {
 			requests[i] = rpcRequest{service: elems[0], method: elems[1], id: id, params: nil}
 		} else {
@@ -314,13 +321,15 @@ func (c *jsonCodec) CreateErrorResponseWithInfo(id interface{}, err RPCError, in
 }
 
 // CreateNotification will create a JSON-RPC notification with the given subscription id and event as params.
-func (c *jsonCodec) CreateNotification(subid string, event interface{}) interface{} {
+// The notification method is <namespace>_subscription, namespace being the service the subscription was made on.
+func (c *jsonCodec) CreateNotification(subid, namespace string, event interface{}) interface{} {
+	method := namespace + serviceMethodSeparator + notificationMethodSuffix
 	if isHexNum(reflect.TypeOf(event)) {
-		return &jsonNotification{Version: JSONRPCVersion, Method: notificationMethod,
+		return &jsonNotification{Version: JSONRPCVersion, Method: method,
 			Params: jsonSubscription{Subscription: subid, Result: fmt.Sprintf(`%#x`, event)}}
 	}
 
-	return &jsonNotification{Version: JSONRPCVersion, Method: notificationMethod,
+	return &jsonNotification{Version: JSONRPCVersion, Method: method,
 		Params: jsonSubscription{Subscription: subid, Result: event}}
 }
 
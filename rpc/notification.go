@@ -85,6 +85,7 @@ type Subscription interface {
 // notifications to subscribers.
 type bufferedSubscription struct {
 	id               string
+	namespace        string              // service the subscription was made on
 	unsubOnce        sync.Once           // call unsub method once
 	unsub            UnsubscribeCallback // called on Unsubscribed
 	notifier         *bufferedNotifier   // forward notifications to
@@ -270,7 +271,7 @@ func (n *bufferedNotifier) run() {
 				// indicates that the response for the unsubscribe can be send to the client.
 				close(notification.sub.flushed)
 			} else {
-				msg := n.codec.CreateNotification(notification.sub.id, notification.data)
+				msg := n.codec.CreateNotification(notification.sub.id, notification.sub.namespace, notification.data)
 				if err := n.codec.Write(msg); err != nil {
 					n.codec.Close()
 					// unable to send notification to client, unsubscribe all subscriptions
@@ -286,12 +287,13 @@ func (n *bufferedNotifier) run() {
 }
 
 // Marks the subscription as active. This will causes the notifications for this subscription to be
-// forwarded to the client.
-func (n *bufferedNotifier) activate(subid string) {
+// forwarded to the client, as notifications of the given namespace.
+func (n *bufferedNotifier) activate(subid, namespace string) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
 	if sub, found := n.subscriptions[subid]; found {
+		sub.namespace = namespace
 		close(sub.pending)
 	}
 }
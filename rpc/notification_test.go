@@ -105,6 +105,9 @@ func TestNotifications(t *testing.T) {
 			t.Fatalf("%v", err)
 		}
 
+		if notification.Method != "eth_subscription" {
+			t.Fatalf("expected eth_subscription notification, got %s", notification.Method)
+		}
 		if int(notification.Params.Result.(float64)) != val+i {
 			t.Fatalf("expected %d, got %d", val+i, notification.Params.Result)
 		}
@@ -117,3 +120,84 @@ func TestNotifications(t *testing.T) {
 		t.Error("unsubscribe callback not called after closing connection")
 	}
 }
+
+// Tests that subscriptions are made on, and cancelled through, the service
+// the subscribe method belongs to.
+func TestServiceNotifications(t *testing.T) {
+	server := NewServer()
+	service := &NotificationTestService{}
+
+	if err := server.RegisterName("webchain", service); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation|OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	// the subscription isn't available on the eth service
+	request := map[string]interface{}{
+		"id":      1,
+		"method":  "eth_subscribe",
+		"version": "2.0",
+		"params":  []interface{}{"someSubscription", 1, 1},
+	}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var errResponse JSONResponse
+	if err := in.Decode(&errResponse); err != nil {
+		t.Fatal(err)
+	}
+	if errResponse.Error == nil || errResponse.Error.Code != (&methodNotFoundError{}).Code() {
+		t.Fatalf("expected method not found error, got %v", errResponse.Error)
+	}
+
+	n := 3
+	val := 100
+	request["id"], request["method"], request["params"] = 2, "webchain_subscribe", []interface{}{"someSubscription", n, val}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+
+	var response JSONResponse
+	if err := in.Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	subid, ok := response.Result.(string)
+	if !ok {
+		t.Fatalf("expected subscription id, got %T", response.Result)
+	}
+
+	for i := 0; i < n; i++ {
+		var notification jsonNotification
+		if err := in.Decode(&notification); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if notification.Method != "webchain_subscription" {
+			t.Fatalf("expected webchain_subscription notification, got %s", notification.Method)
+		}
+		if notification.Params.Subscription != subid {
+			t.Fatalf("expected subscription %s, got %s", subid, notification.Params.Subscription)
+		}
+		if int(notification.Params.Result.(float64)) != val+i {
+			t.Fatalf("expected %d, got %d", val+i, notification.Params.Result)
+		}
+	}
+
+	request["id"], request["method"], request["params"] = 3, "webchain_unsubscribe", []interface{}{subid}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var unsubResponse JSONResponse
+	if err := in.Decode(&unsubResponse); err != nil {
+		t.Fatal(err)
+	}
+	if unsubResponse.Result != true {
+		t.Fatalf("expected unsubscribe to succeed, got %v", unsubResponse.Result)
+	}
+}
@@ -109,8 +109,8 @@ type ServerCodec interface {
 	CreateErrorResponse(interface{}, RPCError) interface{}
 	// Assemble error response with extra information about the error through info
 	CreateErrorResponseWithInfo(id interface{}, err RPCError, info interface{}) interface{}
-	// Create notification response
-	CreateNotification(string, interface{}) interface{}
+	// Create notification response, expects subscription id and the namespace it was made on
+	CreateNotification(string, string, interface{}) interface{}
 	// Write msg to client.
 	Write(interface{}) error
 	// Close underlying data stream
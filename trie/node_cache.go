@@ -0,0 +1,236 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"sync"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+// cachedNodeSize is the approximate memory overhead of a cached node, on top
+// of its blob and the hashes of its children.
+const cachedNodeSize = 3*common.HashLength + 48
+
+// LeafReferences returns the hashes of the nodes referenced by the value of a
+// trie leaf, such as the storage root of an account in the state trie.
+type LeafReferences func(leaf []byte) []common.Hash
+
+// NodeCache is a trie node database that keeps freshly committed nodes in
+// memory instead of writing them to disk. The nodes are reference counted, so
+// that the nodes of a dereferenced root which are not shared with other tries
+// are dropped without ever reaching the disk, while Commit flushes the nodes
+// of a root which is to be kept.
+//
+// Writes of anything but trie nodes, batches, iterators and deletions go
+// straight to the wrapped disk database, so a NodeCache can be used wherever
+// tries are read and committed.
+type NodeCache struct {
+	ethdb.Database // disk database
+
+	leafRefs LeafReferences
+	nodes    map[common.Hash]*cachedNode
+	size     int // approximate memory used by the cached nodes
+	lock     sync.RWMutex
+}
+
+type cachedNode struct {
+	blob     []byte
+	parents  int           // number of references from cached nodes and from outside
+	children []common.Hash // nodes referenced by this one
+}
+
+// NewNodeCache creates a node cache on top of the given disk database.
+// leafRefs may be nil if the leaves of the cached tries reference no nodes.
+func NewNodeCache(diskdb ethdb.Database, leafRefs LeafReferences) *NodeCache {
+	return &NodeCache{
+		Database: diskdb,
+		leafRefs: leafRefs,
+		nodes:    make(map[common.Hash]*cachedNode),
+	}
+}
+
+// Put caches a trie node. Values which are not trie nodes, such as contract
+// code and hash preimages, are written to the disk database.
+func (c *NodeCache) Put(key, value []byte) error {
+	if len(key) != common.HashLength {
+		return c.Database.Put(key, value)
+	}
+	hash := common.BytesToHash(key)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.nodes[hash]; ok {
+		return nil
+	}
+	n, err := decodeNode(key, value, 0)
+	if err != nil {
+		return c.Database.Put(key, value)
+	}
+	node := &cachedNode{
+		blob:     common.CopyBytes(value),
+		children: c.references(n, nil),
+	}
+	for _, child := range node.children {
+		if cached := c.nodes[child]; cached != nil {
+			cached.parents++
+		}
+	}
+	c.nodes[hash] = node
+	c.size += len(node.blob) + len(node.children)*common.HashLength + cachedNodeSize
+
+	return nil
+}
+
+// references appends the hashes of the nodes referenced by n to refs.
+func (c *NodeCache) references(n node, refs []common.Hash) []common.Hash {
+	switch n := n.(type) {
+	case *shortNode:
+		return c.references(n.Val, refs)
+	case *fullNode:
+		for _, child := range n.Children {
+			refs = c.references(child, refs)
+		}
+	case hashNode:
+		refs = append(refs, common.BytesToHash(n))
+	case valueNode:
+		if c.leafRefs != nil {
+			refs = append(refs, c.leafRefs(n)...)
+		}
+	}
+	return refs
+}
+
+func (c *NodeCache) Get(key []byte) ([]byte, error) {
+	c.lock.RLock()
+	node := c.nodes[common.BytesToHash(key)]
+	c.lock.RUnlock()
+
+	if node != nil && len(key) == common.HashLength {
+		return common.CopyBytes(node.blob), nil
+	}
+	return c.Database.Get(key)
+}
+
+func (c *NodeCache) Has(key []byte) (bool, error) {
+	c.lock.RLock()
+	node := c.nodes[common.BytesToHash(key)]
+	c.lock.RUnlock()
+
+	if node != nil && len(key) == common.HashLength {
+		return true, nil
+	}
+	return c.Database.Has(key)
+}
+
+// Reference adds an outside reference to the cached node with the given hash,
+// keeping it and its children in memory until it is dereferenced.
+func (c *NodeCache) Reference(hash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if node := c.nodes[hash]; node != nil {
+		node.parents++
+	}
+}
+
+// Dereference removes an outside reference from the cached node with the given
+// hash. If nothing references the node any more it is dropped, along with all
+// of its children which are not referenced elsewhere.
+func (c *NodeCache) Dereference(hash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.dereference(hash)
+}
+
+func (c *NodeCache) dereference(hash common.Hash) {
+	node := c.nodes[hash]
+	if node == nil {
+		// Flushed to disk or never cached
+		return
+	}
+	if node.parents > 0 {
+		node.parents--
+	}
+	if node.parents > 0 {
+		return
+	}
+	delete(c.nodes, hash)
+	c.size -= len(node.blob) + len(node.children)*common.HashLength + cachedNodeSize
+
+	for _, child := range node.children {
+		c.dereference(child)
+	}
+}
+
+// Commit writes the cached nodes of the trie with the given root to the disk
+// database and removes them from the cache. Dereferencing them afterwards is
+// a no-op.
+func (c *NodeCache) Commit(root common.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	batch := c.Database.NewBatch()
+	if err := c.commit(root, &batch); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (c *NodeCache) commit(hash common.Hash, batch *ethdb.Batch) error {
+	node := c.nodes[hash]
+	if node == nil {
+		return nil
+	}
+	for _, child := range node.children {
+		if err := c.commit(child, batch); err != nil {
+			return err
+		}
+	}
+	if err := (*batch).Put(hash[:], node.blob); err != nil {
+		return err
+	}
+	if (*batch).ValueSize() >= ethdb.IdealBatchSize {
+		if err := (*batch).Write(); err != nil {
+			return err
+		}
+		*batch = c.Database.NewBatch()
+	}
+	delete(c.nodes, hash)
+	c.size -= len(node.blob) + len(node.children)*common.HashLength + cachedNodeSize
+
+	return nil
+}
+
+// Size returns the approximate memory used by the cached nodes, in bytes.
+func (c *NodeCache) Size() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.size
+}
+
+// Nodes returns the number of cached nodes.
+func (c *NodeCache) Nodes() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return len(c.nodes)
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+// checkNodeCacheTrie checks that the trie with the given root holds the n
+// values written by TestNodeCacheGC, the ith one set to the given version.
+func checkNodeCacheTrie(t *testing.T, db Database, root common.Hash, n int, version string) {
+	trie, err := New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open trie %x: %v", root, err)
+	}
+	for i := 0; i < n; i++ {
+		key := []byte(fmt.Sprintf("key-%03d", i))
+		want := []byte(fmt.Sprintf("value-%03d-%s-padded-to-avoid-embedding", i, version))
+		if i%2 != 0 {
+			want = []byte(fmt.Sprintf("value-%03d-v1-padded-to-avoid-embedding", i))
+		}
+		if have, err := trie.TryGet(key); err != nil || !bytes.Equal(have, want) {
+			t.Fatalf("trie %x key %s: have %q (err %v), want %q", root, key, have, err, want)
+		}
+	}
+}
+
+func TestNodeCacheGC(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	cache := NewNodeCache(diskdb, nil)
+
+	// Commit a first trie and a second one changing half of its values
+	trie, _ := New(common.Hash{}, cache)
+	for i := 0; i < 100; i++ {
+		trie.Update([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%03d-v1-padded-to-avoid-embedding", i)))
+	}
+	root1, err := trie.CommitTo(cache)
+	if err != nil {
+		t.Fatalf("failed to commit first trie: %v", err)
+	}
+	cache.Reference(root1)
+
+	for i := 0; i < 100; i += 2 {
+		trie.Update([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%03d-v2-padded-to-avoid-embedding", i)))
+	}
+	root2, err := trie.CommitTo(cache)
+	if err != nil {
+		t.Fatalf("failed to commit second trie: %v", err)
+	}
+	cache.Reference(root2)
+
+	if len(diskdb.Keys()) != 0 {
+		t.Fatalf("trie nodes written to disk before flushing: %d", len(diskdb.Keys()))
+	}
+	checkNodeCacheTrie(t, cache, root1, 100, "v1")
+	checkNodeCacheTrie(t, cache, root2, 100, "v2")
+
+	// Dropping the first trie must keep the nodes shared with the second one
+	nodes, size := cache.Nodes(), cache.Size()
+	cache.Dereference(root1)
+	if cache.Nodes() >= nodes || cache.Size() >= size {
+		t.Fatalf("no nodes dropped: nodes %d -> %d, size %d -> %d", nodes, cache.Nodes(), size, cache.Size())
+	}
+	if _, err := New(root1, cache); err == nil {
+		t.Fatalf("dereferenced root %x still available", root1)
+	}
+	checkNodeCacheTrie(t, cache, root2, 100, "v2")
+
+	// Flushing the second trie must move all of its nodes to disk
+	if err := cache.Commit(root2); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	if cache.Nodes() != 0 || cache.Size() != 0 {
+		t.Fatalf("nodes left in cache after flush: %d (%d bytes)", cache.Nodes(), cache.Size())
+	}
+	checkNodeCacheTrie(t, diskdb, root2, 100, "v2")
+
+	// Dereferencing flushed nodes is a no-op
+	cache.Dereference(root2)
+	checkNodeCacheTrie(t, cache, root2, 100, "v2")
+}
+
+func TestNodeCacheLeafReferences(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+
+	// Leaves of the outer trie hold the root of an inner trie
+	cache := NewNodeCache(diskdb, func(leaf []byte) []common.Hash {
+		return []common.Hash{common.BytesToHash(leaf)}
+	})
+	inner, _ := New(common.Hash{}, cache)
+	for i := 0; i < 50; i++ {
+		inner.Update([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%03d-v1-padded-to-avoid-embedding", i)))
+	}
+	innerRoot, _ := inner.CommitTo(cache)
+
+	outer, _ := New(common.Hash{}, cache)
+	outer.Update([]byte("inner"), innerRoot[:])
+	outerRoot, _ := outer.CommitTo(cache)
+	cache.Reference(outerRoot)
+
+	checkNodeCacheTrie(t, cache, innerRoot, 50, "v1")
+	cache.Dereference(outerRoot)
+	if cache.Nodes() != 0 {
+		t.Fatalf("nodes left in cache after dereferencing outer trie: %d", cache.Nodes())
+	}
+}
@@ -119,6 +119,33 @@ func VerifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader) (valu
 	}
 }
 
+// ProofList is a merkle proof in the form of a list of encoded trie nodes, in
+// the order they were written by Prove, which is from the root down. It can
+// be passed to Prove as well as to VerifyProof.
+type ProofList [][]byte
+
+// Put implements DatabaseWriter, appending the node to the list.
+func (l *ProofList) Put(key []byte, value []byte) error {
+	*l = append(*l, common.CopyBytes(value))
+	return nil
+}
+
+// Get implements DatabaseReader, looking up a node by its hash.
+func (l ProofList) Get(key []byte) ([]byte, error) {
+	for _, node := range l {
+		if bytes.Equal(crypto.Keccak256(node), key) {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("proof node %x not found", key)
+}
+
+// Has implements DatabaseReader.
+func (l ProofList) Has(key []byte) (bool, error) {
+	node, _ := l.Get(key)
+	return node != nil, nil
+}
+
 func get(tn node, key []byte) ([]byte, node) {
 	for {
 		switch n := tn.(type) {
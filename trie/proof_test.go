@@ -68,6 +68,24 @@ func TestOneElementProof(t *testing.T) {
 	}
 }
 
+func TestSecureProofList(t *testing.T) {
+	_, trie, content := makeTestSecureTrie()
+	root := trie.Hash()
+	for key, value := range content {
+		var proof ProofList
+		if err := trie.Prove([]byte(key), 0, &proof); err != nil {
+			t.Fatalf("failed to prove key %x: %v", key, err)
+		}
+		val, err, _ := VerifyProof(root, crypto.Keccak256([]byte(key)), proof)
+		if err != nil {
+			t.Fatalf("VerifyProof error for key %x: %v", key, err)
+		}
+		if !bytes.Equal(val, value) {
+			t.Fatalf("VerifyProof returned wrong value for key %x: got %x, want %x", key, val, value)
+		}
+	}
+}
+
 func TestVerifyBadProof(t *testing.T) {
 	trie, vals := randomTrie(800)
 	root := trie.Hash()